@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/compliance"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/passwords"
+)
+
+// createAdmin cria um administrador diretamente no banco. Por segurança só roda se ainda não
+// existir nenhum admin ativo, a menos que --force seja informado.
+// A senha vem de ADMIN_PASSWORD ou é lida da entrada padrão, nunca de uma flag.
+func createAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	nome := fs.String("nome", "", "nome do administrador")
+	email := fs.String("email", "", "email do administrador")
+	cpf := fs.String("cpf", "", "CPF do administrador")
+	nascimento := fs.String("nascimento", "", "data de nascimento (YYYY-MM-DD)")
+	force := fs.Bool("force", false, "cria mesmo que já exista um administrador")
+	fs.Parse(args)
+
+	if *nome == "" || *email == "" || *cpf == "" || *nascimento == "" {
+		fs.Usage()
+		return errors.New("--nome, --email, --cpf e --nascimento são obrigatórios")
+	}
+
+	if !models.IsValidCPF(*cpf) {
+		return errors.New("CPF inválido")
+	}
+
+	birthDate, err := time.Parse("2006-01-02", *nascimento)
+	if err != nil {
+		return errors.New("data de nascimento inválida, use YYYY-MM-DD")
+	}
+	if !compliance.IsOfLegalAge(birthDate) {
+		return fmt.Errorf("administradores devem ter no mínimo %d anos", compliance.MinimumAge())
+	}
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "Senha do administrador: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return errors.New("não foi possível ler a senha")
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < 12 {
+		return errors.New("a senha do administrador deve ter no mínimo 12 caracteres")
+	}
+	if err := passwords.Validate(password, *email, *nome); err != nil {
+		return err
+	}
+
+	database.Connect()
+
+	if !*force {
+		var admins int
+		if err := database.DB.QueryRow(
+			"SELECT COUNT(*) FROM users WHERE perfil = $1 AND deleted_at IS NULL", models.PERFIL_ADMIN).Scan(&admins); err != nil {
+			return err
+		}
+		if admins > 0 {
+			return errors.New("já existe um administrador; use convites (POST /api/admin/invitations) ou --force")
+		}
+	}
+
+	hashedPassword, err := passwords.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	var id int
+	err = database.DB.QueryRow(`
+		INSERT INTO users (nome, email, password, cpf, data_nascimento, perfil)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		*nome, *email, hashedPassword, models.NormalizeCPF(*cpf), birthDate.Format("2006-01-02"), models.PERFIL_ADMIN).
+		Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("✓ Administrador %s criado (id %d)", *email, id)
+	return nil
+}
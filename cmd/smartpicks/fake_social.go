@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"smartpicks-backend/internal/social"
+)
+
+// fakeSocial sobe o servidor fake do Telegram e do Discord para testar a publicação social
+// sem bots nem servidores reais. As mensagens recebidas ficam em GET /messages.
+func fakeSocial(args []string) error {
+	fs := flag.NewFlagSet("fake-social", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8099", "endereço do servidor")
+	fs.Parse(args)
+
+	base := "http://" + *addr
+	if strings.HasPrefix(*addr, ":") {
+		base = "http://localhost" + *addr
+	}
+	log.Printf("📨 Servidor fake de Telegram/Discord em %s", base)
+	log.Printf("Configure a API com:\n  TELEGRAM_API_URL=%s/telegram\n  TELEGRAM_BOT_TOKEN=fake\n  DISCORD_API_URL=%s/discord/api", base, base)
+	log.Printf("Webhook do Discord para cadastrar: %s/discord/api/webhooks/1/fake", base)
+	log.Printf("Mensagens recebidas: GET %s/messages", base)
+	return http.ListenAndServe(*addr, social.NewFake())
+}
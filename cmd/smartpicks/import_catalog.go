@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"smartpicks-backend/internal/catalog"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+)
+
+// csvColumns são os cabeçalhos aceitos no CSV de importação
+var csvColumns = []string{"esporte", "competicao", "pais", "mandante", "visitante", "inicio", "ref"}
+
+// importCatalog importa eventos em lote de um arquivo JSON ({"events": [...]}) ou CSV com
+// cabeçalho esporte,competicao,pais,mandante,visitante,inicio,ref (inicio em RFC3339)
+func importCatalog(args []string) error {
+	fs := flag.NewFlagSet("import-catalog", flag.ExitOnError)
+	file := fs.String("file", "", "arquivo .json ou .csv")
+	fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		return errors.New("--file é obrigatório")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var rows []models.CatalogImportRow
+	switch strings.ToLower(filepath.Ext(*file)) {
+	case ".json":
+		var body struct {
+			Events []models.CatalogImportRow `json:"events"`
+		}
+		if err := json.NewDecoder(f).Decode(&body); err != nil {
+			return fmt.Errorf("JSON inválido: %w", err)
+		}
+		rows = body.Events
+	case ".csv":
+		if rows, err = readCatalogCSV(f); err != nil {
+			return err
+		}
+	default:
+		return errors.New("formato não suportado, use .json ou .csv")
+	}
+
+	if len(rows) == 0 {
+		return errors.New("nenhum evento encontrado no arquivo")
+	}
+
+	database.Connect()
+	result, err := catalog.Import(rows)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("✅ Importação concluída: %d eventos criados, %d atualizados", result.Created, result.Updated)
+	return nil
+}
+
+func readCatalogCSV(r io.Reader) ([]models.CatalogImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("CSV sem cabeçalho: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, col := range []string{"esporte", "competicao", "mandante", "visitante", "inicio"} {
+		if _, ok := index[col]; !ok {
+			return nil, fmt.Errorf("coluna obrigatória ausente: %s (colunas aceitas: %s)", col, strings.Join(csvColumns, ","))
+		}
+	}
+
+	field := func(record []string, col string) string {
+		i, ok := index[col]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []models.CatalogImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, models.CatalogImportRow{
+			Esporte:     field(record, "esporte"),
+			Competicao:  field(record, "competicao"),
+			Pais:        field(record, "pais"),
+			Mandante:    field(record, "mandante"),
+			Visitante:   field(record, "visitante"),
+			Inicio:      field(record, "inicio"),
+			ExternalRef: field(record, "ref"),
+		})
+	}
+	return rows, nil
+}
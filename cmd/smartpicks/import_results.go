@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/results"
+	"smartpicks-backend/internal/tasks"
+)
+
+// importResults aplica placares finais de um arquivo JSON ({"results": [...]}) ou CSV
+// (event_id,ref,home_score,away_score,status) e liquida os palpites. Com --dry-run apenas
+// mostra o que seria liquidado.
+func importResults(args []string) error {
+	fs := flag.NewFlagSet("import-results", flag.ExitOnError)
+	file := fs.String("file", "", "arquivo .json ou .csv")
+	dryRun := fs.Bool("dry-run", false, "mostra a prévia sem gravar nada")
+	fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		return errors.New("--file é obrigatório")
+	}
+
+	database.Connect()
+	tasks.Register() // assinantes dos eventos de liquidação (notificações)
+	report, err := results.Sync(context.Background(), results.FileProvider{Path: *file}, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range report.Errors {
+		log.Printf("⚠️  %s", msg)
+	}
+	for _, c := range report.Settled {
+		log.Printf("palpite %d (evento %d, %s %s): %s, lucro %.2f", c.PalpiteID, c.EventID, c.Mercado, c.Selecao, c.Status, c.Lucro)
+	}
+
+	prefix := "✅"
+	if report.DryRun {
+		prefix = "🔎 [dry-run]"
+	}
+	log.Printf("%s %d resultados, %d palpites liquidados, %d sem mercado", prefix, report.Results, len(report.Settled), report.Skipped)
+	return nil
+}
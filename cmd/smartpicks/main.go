@@ -0,0 +1,57 @@
+// Comando de administração do SmartPicks (tarefas fora da API HTTP).
+//
+// Uso:
+//
+//	go run ./cmd/smartpicks <comando> [flags]
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{
+	"create-admin":   {"Cria o primeiro administrador (bootstrap)", createAdmin},
+	"fake-social":    {"Sobe um servidor fake do Telegram e do Discord para testes", fakeSocial},
+	"import-catalog": {"Importa eventos esportivos de um arquivo CSV ou JSON", importCatalog},
+	"import-results": {"Aplica placares finais e liquida os palpites (--dry-run para prévia)", importResults},
+	"vapid-keys":     {"Gera um par de chaves VAPID para o Web Push", vapidKeys},
+}
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️  Arquivo .env não encontrado, usando variáveis de ambiente do sistema")
+	}
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Comando desconhecido: %s\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Fatalf("❌ %s: %v", os.Args[1], err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Uso: smartpicks <comando> [flags]")
+	fmt.Fprintln(os.Stderr, "\nComandos:")
+	for name, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, cmd.description)
+	}
+}
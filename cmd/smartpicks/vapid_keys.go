@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+
+	"smartpicks-backend/internal/push"
+)
+
+// vapidKeys imprime um novo par de chaves VAPID no formato do .env. Trocar as chaves invalida
+// as inscrições existentes: os navegadores precisam se inscrever de novo.
+func vapidKeys(args []string) error {
+	v, err := push.GenerateVAPID()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\nVAPID_PRIVATE_KEY=%s\n", v.PublicKey, v.PrivateKey)
+	return nil
+}
@@ -34,29 +34,21 @@ CREATE TRIGGER update_users_updated_at
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
 
--- Inserir usuários padrão (se não existirem)
-INSERT INTO users (nome, email, password, cpf, data_nascimento, perfil, avatar) VALUES
-(
-    'Admin User',
-    'admin@smartpicks.com',
-    '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi',
-    '00000000000',
-    '1990-01-01',
-    'admin',
-    'https://ui-avatars.com/api/?name=Admin+User&background=0d8abc&color=fff'
-),
-(
-    'User Comum',
-    'user@smartpicks.com',
-    '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi',
-    '11111111111',
-    '1995-06-15',
-    'user',
-    'https://ui-avatars.com/api/?name=User+Comum&background=28a745&color=fff'
-)
-ON CONFLICT (email) DO UPDATE SET
-    nome = EXCLUDED.nome,
-    avatar = EXCLUDED.avatar;
+CREATE TABLE IF NOT EXISTS palpites (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    titulo VARCHAR(255) NULL,
+    img_url TEXT NOT NULL,
+    link TEXT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_palpites_user ON palpites (user_id);
+
+-- Nenhum usuário padrão é criado. Crie o primeiro administrador com:
+--   go run ./cmd/smartpicks create-admin --nome "..." --email "..." --cpf "..." --nascimento YYYY-MM-DD
+-- Demais administradores são convidados pela API (POST /api/admin/invitations).
 
 -- Verificar dados
 SELECT id, nome, email, perfil, avatar, created_at FROM users;
@@ -0,0 +1,158 @@
+// Package audit registra eventos relevantes de segurança (quem fez o quê, em quem, de onde).
+package audit
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+)
+
+// Ações auditadas
+const (
+	ActionLoginSuccess     = "auth.login_success"
+	ActionLoginFailure     = "auth.login_failure"
+	ActionRegister         = "user.register"
+	ActionRoleChange       = "user.role_change"
+	ActionAvatarUpdate     = "user.avatar_update"
+	ActionAvatarDelete     = "user.avatar_delete"
+	ActionProfileUpdate    = "user.profile_update"
+	ActionPasswordChange   = "user.password_change"
+	ActionPasswordReset    = "user.password_reset"
+	ActionDeletionRequest  = "user.deletion_request"
+	ActionImpersonateStart = "admin.impersonate"
+	// ActionImpersonatedRequest é cada requisição feita com o token de personificação
+	ActionImpersonatedRequest = "admin.impersonated_request"
+	// ActionAdminPrefix prefixa as demais ações de moderação (admin.suspender, admin.banir...)
+	ActionAdminPrefix   = "admin."
+	ActionPalpiteCreate = "palpite.create"
+	ActionPalpiteUpdate = "palpite.update"
+	ActionPalpiteDelete = "palpite.delete"
+	ActionPalpiteSettle = "palpite.settle"
+	ActionEventResults  = "event.results"
+	// Assinaturas de palpites premium
+	ActionSubscriptionCreate = "subscription.create"
+	ActionSubscriptionCancel = "subscription.cancel"
+	ActionJobRetry           = "job.retry"
+	ActionWebhookCreate      = "webhook.create"
+	ActionWebhookUpdate      = "webhook.update"
+	ActionWebhookDelete      = "webhook.delete"
+	ActionWebhookRotate      = "webhook.rotate_secret"
+	ActionWebhookRedeliver   = "webhook.redeliver"
+	ActionIdentityLink       = "auth.identity_link"
+	ActionIdentityUnlink     = "auth.identity_unlink"
+	ActionTwoFactorEnable    = "auth.2fa_enable"
+	ActionTwoFactorDisable   = "auth.2fa_disable"
+	ActionRecoveryCodes      = "auth.2fa_recovery_codes"
+	ActionRecoveryCodeUse    = "auth.2fa_recovery_code_used"
+	ActionLogout             = "auth.logout"
+	ActionSessionRevoke      = "auth.session_revoke"
+	ActionRefreshReuse       = "auth.refresh_token_reuse"
+	ActionAPIKeyCreate       = "auth.api_key_create"
+	ActionAPIKeyRevoke       = "auth.api_key_revoke"
+)
+
+// Tipos de alvo
+const (
+	TargetUser         = "user"
+	TargetPalpite      = "palpite"
+	TargetEvent        = "event"
+	TargetSubscription = "subscription"
+	TargetJob          = "job"
+	TargetWebhook      = "webhook"
+)
+
+// Change é o valor de um campo antes e depois da ação
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Event descreve uma ação a ser registrada; ator, IP e user agent vêm da requisição
+type Event struct {
+	Action     string
+	TargetType string
+	TargetID   int
+	Diff       map[string]Change
+	Metadata   map[string]interface{}
+	// ActorID sobrescreve o usuário da sessão (ex.: login, em que a sessão ainda não existe)
+	ActorID int
+}
+
+// Record grava o evento. Falhas são registradas em log e nunca interrompem a requisição.
+func Record(r *http.Request, ev Event) {
+	actorID := ev.ActorID
+	if actorID == 0 {
+		if user := auth.CurrentUser(r); user != nil {
+			actorID = user.ID
+		}
+	}
+
+	// Ações feitas por script ficam marcadas com a chave de API usada
+	if keyID := auth.APIKeyID(r); keyID != 0 {
+		if ev.Metadata == nil {
+			ev.Metadata = map[string]interface{}{}
+		}
+		ev.Metadata["api_key_id"] = keyID
+	}
+
+	var diff, metadata []byte
+	if len(ev.Diff) > 0 {
+		diff, _ = json.Marshal(ev.Diff)
+	}
+	if len(ev.Metadata) > 0 {
+		metadata, _ = json.Marshal(ev.Metadata)
+	}
+
+	_, err := database.DB.Exec(`
+		INSERT INTO audit_events
+			(action, actor_id, impersonator_id, target_type, target_id, ip, user_agent, diff, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		ev.Action, nullableID(actorID), nullableID(auth.ImpersonatorID(r)),
+		nullableString(ev.TargetType), nullableID(ev.TargetID),
+		auth.ClientIP(r), truncate(r.UserAgent(), 512), diff, metadata)
+	if err != nil {
+		log.Printf("Erro ao registrar evento de auditoria %s: %v", ev.Action, err)
+	}
+}
+
+// Diff compara dois conjuntos de campos e retorna apenas os que mudaram
+func Diff(before, after map[string]interface{}) map[string]Change {
+	changes := map[string]Change{}
+	for field, to := range after {
+		from := before[field]
+		if !equal(from, to) {
+			changes[field] = Change{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+func equal(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
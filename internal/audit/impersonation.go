@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"net/http"
+
+	"smartpicks-backend/internal/auth"
+)
+
+// ImpersonatedRequests registra no log de auditoria cada requisição feita com token de
+// personificação: o ator é o usuário personificado e impersonator_id é o admin. Deve vir depois
+// de auth.Authenticate.
+func ImpersonatedRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := auth.CurrentUser(r); user != nil && auth.ImpersonatorID(r) != 0 && r.Method != http.MethodOptions {
+			Record(r, Event{
+				Action:     ActionImpersonatedRequest,
+				TargetType: TargetUser,
+				TargetID:   user.ID,
+				Metadata: map[string]interface{}{
+					"metodo":  r.Method,
+					"caminho": r.URL.Path,
+				},
+			})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/listing"
+	"smartpicks-backend/internal/models"
+)
+
+// Filter restringe a listagem de eventos; campos vazios são ignorados.
+// Action terminada em "." filtra por prefixo (ex.: "palpite.").
+type Filter struct {
+	Action     string
+	ActorID    int
+	TargetType string
+	TargetID   int
+	IP         string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// List retorna os eventos mais recentes que atendem ao filtro e o total sem paginação
+func List(f Filter) ([]models.AuditEvent, int, error) {
+	query := listing.New(`
+		SELECT id, action, actor_id, impersonator_id, target_type, target_id, ip, user_agent, diff, metadata, created_at
+		FROM audit_events`)
+
+	if strings.HasSuffix(f.Action, ".") {
+		query.Where("action LIKE ?", listing.PrefixPattern(f.Action))
+	} else {
+		query.WhereIf(f.Action != "", "action = ?", f.Action)
+	}
+	query.
+		WhereIf(f.ActorID != 0, "actor_id = ?", f.ActorID).
+		WhereIf(f.TargetType != "", "target_type = ?", f.TargetType).
+		WhereIf(f.TargetID != 0, "target_id = ?", f.TargetID).
+		WhereIf(f.IP != "", "ip = ?", f.IP)
+	if f.From != nil {
+		query.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query.Where("created_at < ?", *f.To)
+	}
+
+	countSQL, countArgs := query.CountSQL()
+	var total int
+	if err := database.DB.QueryRow(countSQL, countArgs...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL, listArgs := query.OrderBy("created_at DESC", "id DESC").Limit(f.Limit).Offset(f.Offset).SQL()
+	rows, err := database.DB.Query(listSQL, listArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var e models.AuditEvent
+		var diff, metadata []byte
+		if err := rows.Scan(&e.ID, &e.Action, &e.ActorID, &e.ImpersonatorID, &e.TargetType, &e.TargetID,
+			&e.IP, &e.UserAgent, &diff, &metadata, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		if len(diff) > 0 {
+			e.Diff = diff
+		}
+		if len(metadata) > 0 {
+			e.Metadata = metadata
+		}
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
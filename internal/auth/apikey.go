@@ -0,0 +1,340 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Escopos das chaves de API
+const (
+	ESCOPO_LER_PALPITES      = "read:palpites"
+	ESCOPO_ESCREVER_PALPITES = "write:palpites"
+)
+
+// Scopes lista os escopos aceitos na criação de uma chave
+var Scopes = []string{ESCOPO_LER_PALPITES, ESCOPO_ESCREVER_PALPITES}
+
+var (
+	ErrInvalidAPIKey   = errors.New("chave de API inválida, expirada ou revogada")
+	ErrAPIKeyNotFound  = errors.New("chave de API não encontrada")
+	ErrInvalidScope    = errors.New("escopo inválido")
+	ErrTooManyAPIKeys  = errors.New("limite de chaves de API ativas atingido")
+	ErrInvalidKeyInput = errors.New("nome é obrigatório (até 100 caracteres) e expira_em_dias deve estar entre 1 e 365")
+)
+
+const (
+	// apiKeyPrefix identifica uma chave de API no header Authorization, para não confundi-la
+	// com um token de sessão
+	apiKeyPrefix = "spk_"
+	// MaxAPIKeys é o número de chaves ativas por usuário
+	MaxAPIKeys = 10
+	// lastUsedInterval evita uma escrita por requisição em last_used_at
+	lastUsedInterval = time.Minute
+)
+
+// APIKey é uma chave de acesso programático. O segredo só aparece na criação; depois disso a
+// chave é identificada pelo prefixo.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Nome       string     `json:"nome"`
+	Prefixo    string     `json:"prefixo"`
+	Escopos    []string   `json:"escopos"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP *string    `json:"last_used_ip"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Expirada   bool       `json:"expirada"`
+}
+
+// apiKeyAuth é a chave apresentada na requisição; o usuário só entra no contexto nas rotas que
+// declaram um escopo (RequireScope)
+type apiKeyAuth struct {
+	id     int
+	scopes []string
+	user   *models.User
+}
+
+func (k *apiKeyAuth) hasScope(scope string) bool {
+	for _, s := range k.scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAPIKey indica se o token do header Authorization é uma chave de API
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// APIKeyID retorna a chave de API que autenticou a requisição (0 para sessões)
+func APIKeyID(r *http.Request) int {
+	if key, ok := r.Context().Value(apiKeyContextKey).(*apiKeyAuth); ok {
+		return key.id
+	}
+	return 0
+}
+
+// CreateAPIKey gera uma chave com os escopos pedidos e devolve o segredo, exibido apenas aqui.
+// expiresInDays 0 cria uma chave sem expiração.
+func CreateAPIKey(userID int, nome string, scopes []string, expiresInDays int) (*APIKey, string, error) {
+	nome = strings.TrimSpace(nome)
+	if nome == "" || len(nome) > 100 || expiresInDays < 0 || expiresInDays > 365 {
+		return nil, "", ErrInvalidKeyInput
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var active int
+	if err := database.DB.QueryRow(`
+		SELECT COUNT(*) FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE k.user_id = $1 AND (k.expires_at IS NULL OR k.expires_at > NOW()) AND `+apiKeyNotRevoked,
+		userID).Scan(&active); err != nil {
+		return nil, "", err
+	}
+	if active >= MaxAPIKeys {
+		return nil, "", ErrTooManyAPIKeys
+	}
+
+	id, err := GenerateSecureToken(6)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := GenerateSecureToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	prefix := apiKeyPrefix + id
+	raw := prefix + "_" + secret
+
+	var expiresAt *time.Time
+	if expiresInDays > 0 {
+		t := time.Now().Add(time.Duration(expiresInDays) * 24 * time.Hour)
+		expiresAt = &t
+	}
+
+	key := &APIKey{Nome: nome, Prefixo: prefix, Escopos: scopes, ExpiresAt: expiresAt}
+	err = database.DB.QueryRow(`
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`, userID, nome, prefix, HashToken(raw), pq.Array(scopes), expiresAt).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+// normalizeScopes remove repetições e recusa escopos desconhecidos ou lista vazia
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		known := false
+		for _, k := range Scopes {
+			known = known || s == k
+		}
+		if !known {
+			return nil, ErrInvalidScope
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrInvalidScope
+	}
+	return out, nil
+}
+
+// apiKeyNotRevoked descarta chaves revogadas e as criadas antes de tokens_valid_after: troca ou
+// reset de senha, reset de 2FA e desvinculação de login social encerram as sessões e também as
+// chaves (k = api_keys, u = users)
+const apiKeyNotRevoked = `k.revoked_at IS NULL
+	AND (u.tokens_valid_after IS NULL OR k.created_at >= u.tokens_valid_after)`
+
+// ListAPIKeys devolve as chaves não revogadas do usuário, incluindo as expiradas
+func ListAPIKeys(userID int) ([]APIKey, error) {
+	rows, err := database.DB.Query(`
+		SELECT k.id, k.name, k.prefix, k.scopes, k.created_at, k.last_used_at, k.last_used_ip, k.expires_at
+		FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE k.user_id = $1 AND `+apiKeyNotRevoked+`
+		ORDER BY k.created_at DESC, k.id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	list := []APIKey{}
+	for rows.Next() {
+		var k APIKey
+		if err := rows.Scan(&k.ID, &k.Nome, &k.Prefixo, pq.Array(&k.Escopos), &k.CreatedAt,
+			&k.LastUsedAt, &k.LastUsedIP, &k.ExpiresAt); err != nil {
+			return nil, err
+		}
+		k.Expirada = k.ExpiresAt != nil && k.ExpiresAt.Before(now)
+		list = append(list, k)
+	}
+	return list, rows.Err()
+}
+
+// RevokeAllAPIKeys revoga todas as chaves do usuário (troca de senha) e devolve quantas eram
+func RevokeAllAPIKeys(userID int) (int, error) {
+	result, err := database.DB.Exec(
+		"UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// RevokeAPIKey revoga a chave do usuário e devolve o prefixo dela
+func RevokeAPIKey(userID, keyID int) (string, error) {
+	var prefix string
+	err := database.DB.QueryRow(`
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		RETURNING prefix`, keyID, userID).Scan(&prefix)
+	if err == sql.ErrNoRows {
+		return "", ErrAPIKeyNotFound
+	}
+	return prefix, err
+}
+
+// lookupAPIKey carrega a chave válida e o dono dela
+func lookupAPIKey(token string) (*apiKeyAuth, error) {
+	key := &apiKeyAuth{}
+	var userID int
+	err := database.DB.QueryRow(`
+		SELECT k.id, k.user_id, k.scopes FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1 AND (k.expires_at IS NULL OR k.expires_at > NOW()) AND `+apiKeyNotRevoked,
+		HashToken(token)).Scan(&key.id, &userID, pq.Array(&key.scopes))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.user, err = loadUser(userID)
+	if err == sql.ErrNoRows || (err == nil && key.user.IsDeleted()) {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, err
+}
+
+// authenticateAPIKey é o Authenticate das chaves de API: valida a chave e a guarda no contexto.
+// O usuário não entra no contexto aqui; sem RequireScope, a requisição segue como anônima.
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, token string, next http.Handler) {
+	key, err := lookupAPIKey(token)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidAPIKey) {
+			log.Printf("Erro ao carregar chave de API: %v", err)
+		}
+		sendError(w, ErrInvalidAPIKey.Error(), http.StatusUnauthorized)
+		return
+	}
+	if key.user.IsSuspended(time.Now()) {
+		SendSuspendedError(w, key.user)
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey, key)))
+}
+
+// RequireScope libera a rota para chaves de API com o escopo informado, aplicando o limite de
+// requisições da chave. Requisições com token de sessão passam direto.
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := r.Context().Value(apiKeyContextKey).(*apiKeyAuth)
+		if r.Method == http.MethodOptions || !ok {
+			next(w, r)
+			return
+		}
+		if !key.hasScope(scope) {
+			sendError(w, "A chave de API não tem o escopo "+scope, http.StatusForbidden)
+			return
+		}
+		if !allowAPIKeyRequest(w, r, key, scope) {
+			return
+		}
+		next(w, r.WithContext(WithUser(r.Context(), key.user)))
+	}
+}
+
+// allowAPIKeyRequest conta a requisição na janela de um minuto da chave e do escopo e responde
+// 429 quando o limite é excedido. A contagem fica no banco para valer entre instâncias.
+func allowAPIKeyRequest(w http.ResponseWriter, r *http.Request, key *apiKeyAuth, scope string) bool {
+	now := time.Now()
+	if _, err := database.DB.Exec(`
+		UPDATE api_keys SET last_used_at = $2, last_used_ip = $3
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $4)`,
+		key.id, now, ClientIP(r), now.Add(-lastUsedInterval)); err != nil {
+		log.Printf("Erro ao atualizar uso da chave de API %d: %v", key.id, err)
+	}
+
+	window := now.Truncate(time.Minute)
+	var count int
+	err := database.DB.QueryRow(`
+		INSERT INTO api_key_usage (api_key_id, scope, window_start, requests)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (api_key_id, scope, window_start)
+		DO UPDATE SET requests = api_key_usage.requests + 1
+		RETURNING requests`, key.id, scope, window).Scan(&count)
+	if err != nil {
+		// Sem a contagem, a requisição segue: o limite protege o serviço, não a conta
+		log.Printf("Erro ao contar uso da chave de API %d: %v", key.id, err)
+		return true
+	}
+
+	limit := apiKeyRateLimit(scope)
+	reset := window.Add(time.Minute)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(limit-count, 0)))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	if count > limit {
+		w.Header().Set("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())+1))
+		sendError(w, "Limite de requisições da chave de API excedido. Tente novamente em instantes", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// apiKeyRateLimit lê API_KEY_READ_RATE_LIMIT (padrão 120) ou API_KEY_WRITE_RATE_LIMIT (padrão
+// 30): requisições por minuto por chave
+func apiKeyRateLimit(scope string) int {
+	env, fallback := "API_KEY_READ_RATE_LIMIT", 120
+	if strings.HasPrefix(scope, "write:") {
+		env, fallback = "API_KEY_WRITE_RATE_LIMIT", 30
+	}
+	if n, err := strconv.Atoi(os.Getenv(env)); err == nil && n > 0 {
+		return n
+	}
+	return fallback
+}
+
+// PurgeAPIKeyUsage remove as janelas de contagem com mais de um dia
+func PurgeAPIKeyUsage(now time.Time) (int, error) {
+	result, err := database.DB.Exec("DELETE FROM api_key_usage WHERE window_start < $1", now.Add(-24*time.Hour))
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
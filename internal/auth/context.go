@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"smartpicks-backend/internal/models"
+)
+
+type contextKey string
+
+const (
+	userContextKey         contextKey = "auth_user"
+	impersonatorContextKey contextKey = "auth_impersonator"
+	sessionContextKey      contextKey = "auth_session"
+	apiKeyContextKey       contextKey = "auth_api_key"
+)
+
+// WithUser anexa o usuário autenticado ao contexto
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext retorna o usuário autenticado, se houver
+func UserFromContext(ctx context.Context) (*models.User, bool) {
+	user, ok := ctx.Value(userContextKey).(*models.User)
+	return user, ok && user != nil
+}
+
+// CurrentUser retorna o usuário autenticado da requisição ou nil para requisições anônimas
+func CurrentUser(r *http.Request) *models.User {
+	user, _ := UserFromContext(r.Context())
+	return user
+}
+
+// ImpersonatorID retorna o id do admin que está personificando o usuário da requisição (0 se não houver)
+func ImpersonatorID(r *http.Request) int {
+	id, _ := r.Context().Value(impersonatorContextKey).(int)
+	return id
+}
+
+// SessionID retorna a sessão do token da requisição (0 para tokens sem sessão, como os de personificação)
+func SessionID(r *http.Request) int {
+	id, _ := r.Context().Value(sessionContextKey).(int)
+	return id
+}
+
+// ClientIP retorna o IP de origem, considerando o X-Forwarded-For do proxy (Vercel)
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+)
+
+// RequireCronOrAdmin libera rotas de manutenção para o cron da Vercel, que envia
+// "Authorization: Bearer <CRON_SECRET>", ou para administradores autenticados.
+// Deve ser registrada fora do subrouter que já aplica Authenticate.
+func RequireCronOrAdmin(next http.HandlerFunc) http.Handler {
+	adminOnly := Authenticate(RequireAdmin(next))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsCronRequest(r) {
+			next(w, r)
+			return
+		}
+		adminOnly.ServeHTTP(w, r)
+	})
+}
+
+// IsCronRequest indica se a requisição traz o segredo do cron (CRON_SECRET vazio desabilita)
+func IsCronRequest(r *http.Request) bool {
+	secret := os.Getenv("CRON_SECRET")
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(bearerToken(r)), []byte(secret)) == 1
+}
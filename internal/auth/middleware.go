@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+)
+
+// Authenticate identifica o usuário pelo header "Authorization: Bearer <token>", com um token
+// de sessão ou uma chave de API (ver RequireScope). Requisições sem token seguem como anônimas;
+// tokens inválidos são rejeitados.
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if IsAPIKey(token) {
+			authenticateAPIKey(w, r, token, next)
+			return
+		}
+
+		claims, err := ParseToken(token)
+		if err != nil {
+			sendError(w, "Sessão inválida ou expirada", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := loadUser(claims.UserID)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Printf("Erro ao carregar usuário da sessão: %v", err)
+			}
+			sendError(w, "Sessão inválida ou expirada", http.StatusUnauthorized)
+			return
+		}
+
+		if user.IsDeleted() {
+			sendError(w, "Sessão inválida ou expirada", http.StatusUnauthorized)
+			return
+		}
+
+		if user.TokensValidAfter != nil && claims.IssuedAt < user.TokensValidAfter.Unix() {
+			sendError(w, "Sessão encerrada. Faça login novamente", http.StatusUnauthorized)
+			return
+		}
+
+		if claims.SessionID != 0 && !sessionActive(r, claims.SessionID, user.ID) {
+			sendError(w, "Sessão encerrada. Faça login novamente", http.StatusUnauthorized)
+			return
+		}
+
+		if user.IsSuspended(time.Now()) {
+			SendSuspendedError(w, user)
+			return
+		}
+
+		if claims.ImpersonatorID != 0 && !impersonatorAllowed(claims.ImpersonatorID) {
+			sendError(w, "Sessão de suporte encerrada", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := WithUser(r.Context(), user)
+		if claims.SessionID != 0 {
+			ctx = context.WithValue(ctx, sessionContextKey, claims.SessionID)
+		}
+		if claims.ImpersonatorID != 0 {
+			w.Header().Set("X-Impersonated-By", strconv.Itoa(claims.ImpersonatorID))
+			ctx = context.WithValue(ctx, impersonatorContextKey, claims.ImpersonatorID)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireAuth exige um usuário autenticado
+func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+		if CurrentUser(r) == nil {
+			if APIKeyID(r) != 0 {
+				sendError(w, "Chaves de API não dão acesso a esta rota", http.StatusForbidden)
+				return
+			}
+			sendError(w, "Autenticação necessária", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// DenyImpersonation recusa com 403 as requisições feitas com token de personificação. Envolve
+// as rotas de credenciais, identidade, email, exclusão de conta, pagamentos e integrações: o
+// suporte pode ver a conta como o usuário, mas não tomá-la nem criar acessos que durem além do token.
+func DenyImpersonation(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions && ImpersonatorID(r) != 0 {
+			sendError(w, "Não permitido em sessão de suporte (personificação)", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// RequireAdmin exige um usuário autenticado com perfil admin. Com ADMIN_2FA_REQUIRED, admins
+// sem verificação em duas etapas ativa só conseguem usar as rotas de ativação do 2FA.
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		user := CurrentUser(r)
+		if user != nil && !user.IsAdmin() {
+			sendError(w, "Acesso restrito a administradores", http.StatusForbidden)
+			return
+		}
+		if user != nil && TwoFactorRequired(user) && !user.TwoFactorEnabled() {
+			sendError(w, "Ative a verificação em duas etapas para usar as funções de administrador", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// TwoFactorRequired indica se o 2FA é obrigatório para o usuário: ADMIN_2FA_REQUIRED=true o
+// torna obrigatório para o perfil admin
+func TwoFactorRequired(user *models.User) bool {
+	return user.IsAdmin() && os.Getenv("ADMIN_2FA_REQUIRED") == "true"
+}
+
+// SendSuspendedError responde 403 informando o tipo, o motivo e o fim da suspensão
+func SendSuspendedError(w http.ResponseWriter, user *models.User) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Conta suspensa",
+		"tipo":          user.SuspensaoTipo,
+		"motivo":        user.SuspensaoMotivo,
+		"suspensao_ate": user.SuspensoAte,
+	})
+}
+
+// TokenFromQuery aceita o token de sessão em ?token= quando não há header Authorization. Serve
+// para clientes que não enviam headers, como o EventSource do navegador; deve envolver Authenticate.
+func TokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// impersonatorAllowed confere a cada requisição que o admin que gerou o token de personificação
+// continua existindo, ativo e com perfil admin; rebaixado ou suspenso, o token deixa de valer
+func impersonatorAllowed(adminID int) bool {
+	admin, err := loadUser(adminID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Erro ao carregar admin %d da personificação: %v", adminID, err)
+		}
+		return false
+	}
+	return !admin.IsDeleted() && !admin.IsSuspended(time.Now()) && admin.IsAdmin()
+}
+
+func loadUser(id int) (*models.User, error) {
+	return models.ScanUser(database.DB.QueryRow(`
+		SELECT `+models.UserColumns+`
+		FROM users WHERE id = $1`, id))
+}
+
+func sendError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,336 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token inválido ou expirado")
+	// ErrRefreshTokenReused indica que um refresh token já trocado foi apresentado de novo: ele
+	// vazou, e a sessão inteira (a família de tokens) é encerrada
+	ErrRefreshTokenReused = errors.New("refresh token reutilizado; a sessão foi encerrada por segurança")
+	ErrSessionNotFound    = errors.New("sessão não encontrada")
+	ErrSuspended          = errors.New("conta suspensa")
+)
+
+// ReuseError identifica a sessão encerrada pela reutilização de um refresh token
+type ReuseError struct {
+	UserID    int
+	SessionID int
+}
+
+func (e *ReuseError) Error() string        { return ErrRefreshTokenReused.Error() }
+func (e *ReuseError) Is(target error) bool { return target == ErrRefreshTokenReused }
+
+// Motivos de encerramento de sessão
+const (
+	REVOGACAO_LOGOUT      = "logout"
+	REVOGACAO_USUARIO     = "encerrada_pelo_usuario"
+	REVOGACAO_SENHA       = "senha_alterada"
+	REVOGACAO_REUSO       = "refresh_token_reutilizado"
+	REVOGACAO_INVALIDADAS = "sessoes_invalidadas"
+)
+
+// lastSeenInterval evita uma escrita por requisição: last_seen_at só é atualizado depois disso
+const lastSeenInterval = 5 * time.Minute
+
+// Session é um login ativo em um dispositivo
+type Session struct {
+	ID          int       `json:"id"`
+	Dispositivo string    `json:"dispositivo"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Atual       bool      `json:"atual"`
+}
+
+// Tokens é o par emitido no login e a cada renovação
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+	SessionID    int
+}
+
+// StartSession registra a sessão do dispositivo da requisição e emite o token de acesso e o
+// primeiro refresh token
+func StartSession(r *http.Request, user *models.User) (*Tokens, error) {
+	refresh, err := GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ua := truncate(r.UserAgent(), 512)
+	var sessionID int
+	if err := tx.QueryRow(`
+		INSERT INTO sessions (user_id, device, ip, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`, user.ID, DescribeDevice(ua), ClientIP(r), ua, time.Now().Add(sessionTTL())).Scan(&sessionID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO refresh_tokens (session_id, token_hash) VALUES ($1, $2)", sessionID, HashToken(refresh)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	access, err := IssueToken(user.ID, user.Perfil, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{AccessToken: access, RefreshToken: refresh, ExpiresIn: int(AccessTokenTTL().Seconds()), SessionID: sessionID}, nil
+}
+
+// Refresh troca o refresh token por um novo par (rotação). Cada refresh token vale uma única
+// vez; a reapresentação de um token já trocado encerra a sessão. Sessões anteriores a uma
+// invalidação geral (tokens_valid_after: troca de senha, reset pelo admin) não renovam.
+func Refresh(r *http.Request, refreshToken string) (*Tokens, *models.User, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var tokenID, sessionID, userID int
+	var usedAt, revokedAt *time.Time
+	var expiresAt, createdAt time.Time
+	err = tx.QueryRow(`
+		SELECT rt.id, rt.used_at, s.id, s.user_id, s.revoked_at, s.expires_at, s.created_at
+		FROM refresh_tokens rt
+		JOIN sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt, s`, HashToken(refreshToken)).Scan(&tokenID, &usedAt, &sessionID, &userID, &revokedAt, &expiresAt, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if usedAt != nil {
+		if revokedAt == nil {
+			if err := revoke(tx, sessionID, REVOGACAO_REUSO); err != nil {
+				return nil, nil, err
+			}
+			if err := tx.Commit(); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, &ReuseError{UserID: userID, SessionID: sessionID}
+	}
+	if revokedAt != nil || time.Now().After(expiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	user, err := models.ScanUser(tx.QueryRow("SELECT "+models.UserColumns+" FROM users WHERE id = $1", userID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.IsDeleted() || (user.TokensValidAfter != nil && createdAt.Before(*user.TokensValidAfter)) {
+		if err := revoke(tx, sessionID, REVOGACAO_INVALIDADAS); err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if user.IsSuspended(time.Now()) {
+		return nil, user, ErrSuspended
+	}
+
+	refresh, err := GenerateSecureToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.Exec("UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1", tokenID); err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO refresh_tokens (session_id, token_hash) VALUES ($1, $2)", sessionID, HashToken(refresh)); err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.Exec(`
+		UPDATE sessions SET expires_at = $2, last_seen_at = NOW(), ip = $3
+		WHERE id = $1`, sessionID, time.Now().Add(sessionTTL()), ClientIP(r)); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	access, err := IssueToken(user.ID, user.Perfil, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Tokens{AccessToken: access, RefreshToken: refresh, ExpiresIn: int(AccessTokenTTL().Seconds()), SessionID: sessionID}, user, nil
+}
+
+// ListSessions devolve as sessões ativas do usuário, da mais recente para a mais antiga;
+// currentID marca a sessão da própria requisição. Sessões anteriores a tokens_valid_after já
+// não renovam e ficam de fora.
+func ListSessions(userID, currentID int) ([]Session, error) {
+	rows, err := database.DB.Query(`
+		SELECT s.id, s.device, COALESCE(s.ip, ''), COALESCE(s.user_agent, ''), s.created_at, s.last_seen_at, s.expires_at
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.user_id = $1 AND s.revoked_at IS NULL AND s.expires_at > NOW()
+		  AND (u.tokens_valid_after IS NULL OR s.created_at >= u.tokens_valid_after)
+		ORDER BY s.last_seen_at DESC, s.id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Session{}
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.Dispositivo, &s.IP, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		s.Atual = s.ID == currentID
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// RevokeSession encerra uma sessão do usuário
+func RevokeSession(userID, sessionID int, reason string) error {
+	result, err := database.DB.Exec(`
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()`, sessionID, userID, reason)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeOtherSessions encerra todas as sessões do usuário exceto exceptID (0 encerra todas)
+// e devolve quantas foram encerradas
+func RevokeOtherSessions(userID, exceptID int, reason string) (int, error) {
+	result, err := database.DB.Exec(`
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $3
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL AND expires_at > NOW()`, userID, exceptID, reason)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func revoke(tx *sql.Tx, sessionID int, reason string) error {
+	_, err := tx.Exec(
+		"UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2 WHERE id = $1 AND revoked_at IS NULL", sessionID, reason)
+	return err
+}
+
+// sessionActive confere se a sessão do token continua válida e atualiza last_seen_at e o IP
+// no máximo a cada lastSeenInterval
+func sessionActive(r *http.Request, sessionID, userID int) bool {
+	var active bool
+	var lastSeen time.Time
+	err := database.DB.QueryRow(`
+		SELECT revoked_at IS NULL AND expires_at > NOW(), last_seen_at
+		FROM sessions WHERE id = $1 AND user_id = $2`, sessionID, userID).Scan(&active, &lastSeen)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Erro ao carregar sessão %d: %v", sessionID, err)
+		}
+		return false
+	}
+	if active && time.Since(lastSeen) > lastSeenInterval {
+		if _, err := database.DB.Exec(
+			"UPDATE sessions SET last_seen_at = NOW(), ip = $2 WHERE id = $1", sessionID, ClientIP(r)); err != nil {
+			log.Printf("Erro ao atualizar sessão %d: %v", sessionID, err)
+		}
+	}
+	return active
+}
+
+// PurgeSessions remove sessões encerradas ou expiradas há mais de 30 dias (com os refresh tokens)
+func PurgeSessions(now time.Time) (int, error) {
+	cutoff := now.Add(-30 * 24 * time.Hour)
+	result, err := database.DB.Exec(`
+		DELETE FROM sessions WHERE expires_at < $1 OR revoked_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// DescribeDevice resume o user agent em "navegador · sistema" para a lista de sessões
+func DescribeDevice(ua string) string {
+	if ua == "" {
+		return "Dispositivo desconhecido"
+	}
+	browser := "Navegador"
+	switch {
+	case strings.Contains(ua, "Edg/") || strings.Contains(ua, "EdgA/") || strings.Contains(ua, "EdgiOS/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(ua, "SamsungBrowser/"):
+		browser = "Samsung Internet"
+	case strings.Contains(ua, "Firefox/") || strings.Contains(ua, "FxiOS/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/") || strings.Contains(ua, "CriOS/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "okhttp") || strings.Contains(ua, "Dart/"):
+		browser = "App"
+	case strings.HasPrefix(ua, "curl/") || strings.HasPrefix(ua, "python-requests") || strings.HasPrefix(ua, "Go-http-client"):
+		return "Script (" + strings.SplitN(ua, " ", 2)[0] + ")"
+	}
+
+	system := ""
+	switch {
+	case strings.Contains(ua, "iPhone"):
+		system = "iPhone"
+	case strings.Contains(ua, "iPad"):
+		system = "iPad"
+	case strings.Contains(ua, "Android"):
+		system = "Android"
+	case strings.Contains(ua, "Windows"):
+		system = "Windows"
+	case strings.Contains(ua, "Mac OS X") || strings.Contains(ua, "Macintosh"):
+		system = "macOS"
+	case strings.Contains(ua, "CrOS"):
+		system = "ChromeOS"
+	case strings.Contains(ua, "Linux"):
+		system = "Linux"
+	}
+	if system == "" {
+		return browser
+	}
+	return browser + " · " + system
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
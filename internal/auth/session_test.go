@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"smartpicks-backend/internal/database"
+)
+
+// sessionStore é um banco em memória que responde às consultas de Refresh até a carga do
+// usuário: a busca do refresh token com a sessão e o encerramento da sessão
+type sessionStore struct {
+	mu       sync.Mutex
+	tokens   map[string]*fakeRefreshToken // por hash
+	sessions map[int]*fakeSession
+	commits  int
+}
+
+type fakeRefreshToken struct {
+	id        int
+	sessionID int
+	usedAt    *time.Time
+}
+
+type fakeSession struct {
+	userID        int
+	createdAt     time.Time
+	expiresAt     time.Time
+	revokedAt     *time.Time
+	revokedReason string
+}
+
+func (s *sessionStore) Open(string) (driver.Conn, error) { return &sessionConn{s}, nil }
+
+type sessionConn struct{ store *sessionStore }
+
+func (c *sessionConn) Prepare(query string) (driver.Stmt, error) {
+	return &sessionStmt{c.store, strings.Join(strings.Fields(query), " ")}, nil
+}
+func (c *sessionConn) Close() error              { return nil }
+func (c *sessionConn) Begin() (driver.Tx, error) { return &sessionTx{c.store}, nil }
+
+type sessionTx struct{ store *sessionStore }
+
+func (tx *sessionTx) Commit() error {
+	tx.store.mu.Lock()
+	tx.store.commits++
+	tx.store.mu.Unlock()
+	return nil
+}
+func (tx *sessionTx) Rollback() error { return nil }
+
+type sessionStmt struct {
+	store *sessionStore
+	query string
+}
+
+func (st *sessionStmt) Close() error  { return nil }
+func (st *sessionStmt) NumInput() int { return -1 }
+
+func (st *sessionStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s := st.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !strings.HasPrefix(st.query, "UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2 WHERE id = $1 AND revoked_at IS NULL") {
+		return nil, fmt.Errorf("consulta inesperada: %s", st.query)
+	}
+	sess := s.sessions[int(args[0].(int64))]
+	if sess == nil || sess.revokedAt != nil {
+		return driver.RowsAffected(0), nil
+	}
+	now := time.Now()
+	sess.revokedAt, sess.revokedReason = &now, args[1].(string)
+	return driver.RowsAffected(1), nil
+}
+
+func (st *sessionStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s := st.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !strings.HasPrefix(st.query, "SELECT rt.id, rt.used_at, s.id, s.user_id, s.revoked_at, s.expires_at, s.created_at") {
+		return nil, fmt.Errorf("consulta inesperada: %s", st.query)
+	}
+	rows := &sessionRows{cols: []string{"id", "used_at", "id", "user_id", "revoked_at", "expires_at", "created_at"}}
+	if rt := s.tokens[args[0].(string)]; rt != nil {
+		sess := s.sessions[rt.sessionID]
+		rows.values = [][]driver.Value{{int64(rt.id), nullTime(rt.usedAt), int64(rt.sessionID), int64(sess.userID),
+			nullTime(sess.revokedAt), sess.expiresAt, sess.createdAt}}
+	}
+	return rows, nil
+}
+
+func nullTime(t *time.Time) driver.Value {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+type sessionRows struct {
+	cols   []string
+	values [][]driver.Value
+}
+
+func (r *sessionRows) Columns() []string { return r.cols }
+func (r *sessionRows) Close() error      { return nil }
+func (r *sessionRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// sessionDrivers numera os drivers registrados: sql.Register não aceita nomes repetidos
+var sessionDrivers atomic.Int64
+
+// useSessionStore troca database.DB por um banco em memória durante o teste
+func useSessionStore(t *testing.T, store *sessionStore) {
+	t.Helper()
+	name := fmt.Sprintf("sessionstore-%d", sessionDrivers.Add(1))
+	sql.Register(name, store)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		db.Close()
+	})
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	now := time.Now()
+	used := now.Add(-time.Minute)
+	expired := now.Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		presented   string
+		session     fakeSession
+		wantErr     error
+		wantReason  string // motivo gravado na sessão depois da chamada
+		wantCommits int
+	}{
+		{
+			name:        "token já trocado encerra a sessão",
+			presented:   "token-1",
+			session:     fakeSession{userID: 7, expiresAt: now.Add(time.Hour)},
+			wantErr:     ErrRefreshTokenReused,
+			wantReason:  REVOGACAO_REUSO,
+			wantCommits: 1,
+		},
+		{
+			name:       "token já trocado em sessão encerrada não regrava o motivo",
+			presented:  "token-1",
+			session:    fakeSession{userID: 7, expiresAt: now.Add(time.Hour), revokedAt: &expired, revokedReason: REVOGACAO_LOGOUT},
+			wantErr:    ErrRefreshTokenReused,
+			wantReason: REVOGACAO_LOGOUT,
+		},
+		{
+			name:       "token atual de sessão encerrada",
+			presented:  "token-2",
+			session:    fakeSession{userID: 7, expiresAt: now.Add(time.Hour), revokedAt: &expired, revokedReason: REVOGACAO_REUSO},
+			wantErr:    ErrInvalidRefreshToken,
+			wantReason: REVOGACAO_REUSO,
+		},
+		{
+			name:      "token atual de sessão expirada",
+			presented: "token-2",
+			session:   fakeSession{userID: 7, expiresAt: expired},
+			wantErr:   ErrInvalidRefreshToken,
+		},
+		{
+			name:      "token desconhecido",
+			presented: "token-x",
+			session:   fakeSession{userID: 7, expiresAt: now.Add(time.Hour)},
+			wantErr:   ErrInvalidRefreshToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// token-1 foi trocado por token-2, o atual da mesma sessão
+			session := tt.session
+			store := &sessionStore{
+				tokens: map[string]*fakeRefreshToken{
+					HashToken("token-1"): {id: 1, sessionID: 10, usedAt: &used},
+					HashToken("token-2"): {id: 2, sessionID: 10},
+				},
+				sessions: map[int]*fakeSession{10: &session},
+			}
+			useSessionStore(t, store)
+			r := httptest.NewRequest("POST", "/api/auth/refresh", nil)
+
+			tokens, _, err := Refresh(r, tt.presented)
+			if !errors.Is(err, tt.wantErr) || tokens != nil {
+				t.Fatalf("Refresh = %v, %v; want erro %v", tokens, err, tt.wantErr)
+			}
+			if session.revokedReason != tt.wantReason {
+				t.Errorf("motivo = %q, want %q", session.revokedReason, tt.wantReason)
+			}
+			if store.commits != tt.wantCommits {
+				t.Errorf("commits = %d, want %d", store.commits, tt.wantCommits)
+			}
+
+			var reuse *ReuseError
+			if errors.As(err, &reuse) && (reuse.UserID != 7 || reuse.SessionID != 10) {
+				t.Errorf("ReuseError = %+v, want usuário 7 e sessão 10", reuse)
+			}
+		})
+	}
+}
+
+func TestRefreshAfterReuseRejectsSuccessor(t *testing.T) {
+	// Quem roubou token-1 o apresenta depois da rotação: a sessão cai e o token-2, que está com
+	// o dono legítimo (ou com o atacante), deixa de renovar
+	used := time.Now().Add(-time.Minute)
+	session := fakeSession{userID: 7, expiresAt: time.Now().Add(time.Hour)}
+	store := &sessionStore{
+		tokens: map[string]*fakeRefreshToken{
+			HashToken("token-1"): {id: 1, sessionID: 10, usedAt: &used},
+			HashToken("token-2"): {id: 2, sessionID: 10},
+		},
+		sessions: map[int]*fakeSession{10: &session},
+	}
+	useSessionStore(t, store)
+	r := httptest.NewRequest("POST", "/api/auth/refresh", nil)
+
+	if _, _, err := Refresh(r, "token-1"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuso: %v, want ErrRefreshTokenReused", err)
+	}
+	if _, _, err := Refresh(r, "token-2"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("sucessor: %v, want ErrInvalidRefreshToken", err)
+	}
+	if session.revokedReason != REVOGACAO_REUSO {
+		t.Errorf("motivo = %q, want %q", session.revokedReason, REVOGACAO_REUSO)
+	}
+}
+
+func TestDescribeDevice(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want string
+	}{
+		{"", "Dispositivo desconhecido"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", "Chrome · Windows"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36 Edg/120.0", "Edge · Windows"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", "Safari · iPhone"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", "Firefox · Linux"},
+		{"curl/8.4.0", "Script (curl/8.4.0)"},
+		{"okhttp/4.12.0", "App"},
+	}
+	for _, tt := range tests {
+		if got := DescribeDevice(tt.ua); got != tt.want {
+			t.Errorf("DescribeDevice(%q) = %q, want %q", tt.ua, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken  = errors.New("token inválido")
+	ErrExpiredToken  = errors.New("token expirado")
+	ErrMissingSecret = errors.New("SESSION_SECRET não definida")
+)
+
+// Claims são os dados carregados dentro do token de sessão
+type Claims struct {
+	UserID int    `json:"uid"`
+	Perfil string `json:"perfil"`
+	// ImpersonatorID é o admin que gerou o token para agir como o usuário (suporte)
+	ImpersonatorID int `json:"imp,omitempty"`
+	// SessionID é a sessão (tabela sessions) do token; a revogação da sessão invalida o token
+	SessionID int   `json:"sid,omitempty"`
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// IssueToken gera o token de acesso da sessão, assinado com HMAC-SHA256 (formato
+// payload.assinatura). Expira em AccessTokenTTL e é renovado com o refresh token da sessão.
+func IssueToken(userID int, perfil string, sessionID int) (string, error) {
+	return issue(Claims{UserID: userID, Perfil: perfil, SessionID: sessionID}, AccessTokenTTL())
+}
+
+// IssueImpersonationToken gera um token de curta duração para um admin agir como outro usuário
+func IssueImpersonationToken(userID int, perfil string, adminID int, ttl time.Duration) (string, error) {
+	return issue(Claims{UserID: userID, Perfil: perfil, ImpersonatorID: adminID}, ttl)
+}
+
+func issue(claims Claims, ttl time.Duration) (string, error) {
+	secret, err := sessionSecret()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + sign(encoded, secret), nil
+}
+
+// ParseToken valida a assinatura e a expiração do token e retorna suas claims
+func ParseToken(token string) (*Claims, error) {
+	secret, err := sessionSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(sign(parts[0], secret)), []byte(parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Now().Unix() > claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+
+	return &claims, nil
+}
+
+func sign(data string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func sessionSecret() ([]byte, error) {
+	secret := os.Getenv("SESSION_SECRET")
+	if secret == "" {
+		return nil, ErrMissingSecret
+	}
+	return []byte(secret), nil
+}
+
+// AccessTokenTTL lê ACCESS_TOKEN_TTL_MINUTES (padrão 15 minutos)
+func AccessTokenTTL() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL_MINUTES")); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return 15 * time.Minute
+}
+
+// sessionTTL lê SESSION_TTL_DAYS (padrão 30 dias): a sessão expira após esse tempo sem renovação
+func sessionTTL() time.Duration {
+	if days, err := strconv.Atoi(os.Getenv("SESSION_TTL_DAYS")); err == nil && days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return 30 * 24 * time.Hour
+}
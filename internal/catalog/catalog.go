@@ -0,0 +1,265 @@
+// Package catalog mantém o catálogo de esportes, competições, times e eventos (partidas).
+package catalog
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/listing"
+	"smartpicks-backend/internal/models"
+)
+
+var (
+	ErrNotFound    = errors.New("registro não encontrado")
+	ErrUnknownTeam = errors.New("mandante ou visitante não encontrado")
+)
+
+const eventColumns = `e.id, e.competition_id, e.home_team_id, e.away_team_id, e.nome, e.kickoff_at, e.status,
+	e.external_ref, e.home_score, e.away_score, c.nome, h.nome, a.nome, e.created_at, e.updated_at`
+
+const eventFrom = `FROM events e
+	JOIN competitions c ON c.id = e.competition_id
+	JOIN teams h ON h.id = e.home_team_id
+	JOIN teams a ON a.id = e.away_team_id`
+
+// EventFilter restringe a listagem de eventos; campos vazios são ignorados
+type EventFilter struct {
+	SportID       int
+	CompetitionID int
+	TeamID        int
+	Status        string
+	From          *time.Time
+	To            *time.Time
+}
+
+func ListSports() ([]models.Sport, error) {
+	rows, err := database.DB.Query("SELECT id, nome, slug, created_at FROM sports ORDER BY nome")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sports := []models.Sport{}
+	for rows.Next() {
+		var s models.Sport
+		if err := rows.Scan(&s.ID, &s.Nome, &s.Slug, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		sports = append(sports, s)
+	}
+	return sports, rows.Err()
+}
+
+func SaveSport(id int, nome, slug string) (*models.Sport, error) {
+	var s models.Sport
+	var err error
+	if id == 0 {
+		err = database.DB.QueryRow(`
+			INSERT INTO sports (nome, slug) VALUES ($1, $2)
+			RETURNING id, nome, slug, created_at`, nome, slug).
+			Scan(&s.ID, &s.Nome, &s.Slug, &s.CreatedAt)
+	} else {
+		err = database.DB.QueryRow(`
+			UPDATE sports SET nome = $1, slug = $2 WHERE id = $3
+			RETURNING id, nome, slug, created_at`, nome, slug, id).
+			Scan(&s.ID, &s.Nome, &s.Slug, &s.CreatedAt)
+	}
+	return &s, notFound(err)
+}
+
+// ListCompetitions lista as competições, opcionalmente de um esporte
+func ListCompetitions(sportID int) ([]models.Competition, error) {
+	query := listing.New("SELECT id, sport_id, nome, slug, pais, created_at FROM competitions").
+		WhereIf(sportID != 0, "sport_id = ?", sportID).
+		OrderBy("nome")
+	q, args := query.SQL()
+	rows, err := database.DB.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	competitions := []models.Competition{}
+	for rows.Next() {
+		var c models.Competition
+		if err := rows.Scan(&c.ID, &c.SportID, &c.Nome, &c.Slug, &c.Pais, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		competitions = append(competitions, c)
+	}
+	return competitions, rows.Err()
+}
+
+func SaveCompetition(id int, req models.CatalogItemRequest) (*models.Competition, error) {
+	var c models.Competition
+	var err error
+	if id == 0 {
+		err = database.DB.QueryRow(`
+			INSERT INTO competitions (sport_id, nome, slug, pais) VALUES ($1, $2, $3, $4)
+			RETURNING id, sport_id, nome, slug, pais, created_at`, req.SportID, req.Nome, req.Slug, req.Pais).
+			Scan(&c.ID, &c.SportID, &c.Nome, &c.Slug, &c.Pais, &c.CreatedAt)
+	} else {
+		err = database.DB.QueryRow(`
+			UPDATE competitions SET sport_id = $1, nome = $2, slug = $3, pais = $4 WHERE id = $5
+			RETURNING id, sport_id, nome, slug, pais, created_at`, req.SportID, req.Nome, req.Slug, req.Pais, id).
+			Scan(&c.ID, &c.SportID, &c.Nome, &c.Slug, &c.Pais, &c.CreatedAt)
+	}
+	return &c, notFound(err)
+}
+
+// ListTeams lista os times, opcionalmente de um esporte e por prefixo do nome
+func ListTeams(sportID int, search string) ([]models.Team, error) {
+	query := listing.New("SELECT id, sport_id, nome, slug, pais, created_at FROM teams").
+		WhereIf(sportID != 0, "sport_id = ?", sportID).
+		WhereIf(search != "", "nome ILIKE ?", listing.PrefixPattern(search)).
+		OrderBy("nome").
+		Limit(listing.MaxLimit)
+	q, args := query.SQL()
+	rows, err := database.DB.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		var t models.Team
+		if err := rows.Scan(&t.ID, &t.SportID, &t.Nome, &t.Slug, &t.Pais, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
+
+func SaveTeam(id int, req models.CatalogItemRequest) (*models.Team, error) {
+	var t models.Team
+	var err error
+	if id == 0 {
+		err = database.DB.QueryRow(`
+			INSERT INTO teams (sport_id, nome, slug, pais) VALUES ($1, $2, $3, $4)
+			RETURNING id, sport_id, nome, slug, pais, created_at`, req.SportID, req.Nome, req.Slug, req.Pais).
+			Scan(&t.ID, &t.SportID, &t.Nome, &t.Slug, &t.Pais, &t.CreatedAt)
+	} else {
+		err = database.DB.QueryRow(`
+			UPDATE teams SET sport_id = $1, nome = $2, slug = $3, pais = $4 WHERE id = $5
+			RETURNING id, sport_id, nome, slug, pais, created_at`, req.SportID, req.Nome, req.Slug, req.Pais, id).
+			Scan(&t.ID, &t.SportID, &t.Nome, &t.Slug, &t.Pais, &t.CreatedAt)
+	}
+	return &t, notFound(err)
+}
+
+// Delete remove um registro do catálogo (sports, competitions, teams ou events)
+func Delete(table string, id int) error {
+	allowed := map[string]bool{"sports": true, "competitions": true, "teams": true, "events": true}
+	if !allowed[table] {
+		return ErrNotFound
+	}
+	result, err := database.DB.Exec("DELETE FROM "+table+" WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListEvents lista eventos por data de início com paginação por cursor
+func ListEvents(f EventFilter, page *listing.Params) ([]models.Event, bool, error) {
+	query := listing.New("SELECT "+eventColumns+" "+eventFrom).
+		WhereIf(f.SportID != 0, "c.sport_id = ?", f.SportID).
+		WhereIf(f.CompetitionID != 0, "e.competition_id = ?", f.CompetitionID).
+		WhereIf(f.TeamID != 0, "? IN (e.home_team_id, e.away_team_id)", f.TeamID).
+		WhereIf(f.Status != "", "e.status = ?", f.Status)
+	if f.From != nil {
+		query.Where("e.kickoff_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query.Where("e.kickoff_at < ?", *f.To)
+	}
+	page.Apply(query)
+
+	q, args := query.SQL()
+	rows, err := database.DB.Query(q, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := page.HasMore(len(events))
+	if hasMore {
+		events = events[:page.Limit]
+	}
+	return events, hasMore, nil
+}
+
+func GetEvent(id int) (*models.Event, error) {
+	e, err := scanEvent(database.DB.QueryRow("SELECT "+eventColumns+" "+eventFrom+" WHERE e.id = $1", id))
+	return e, notFound(err)
+}
+
+// SaveEvent cria (id 0) ou atualiza um evento, gerando o nome a partir dos times
+func SaveEvent(id int, req models.EventRequest) (*models.Event, error) {
+	if req.Status == "" {
+		req.Status = models.EVENTO_AGENDADO
+	}
+
+	var eventID int
+	var err error
+	if id == 0 {
+		err = database.DB.QueryRow(`
+			INSERT INTO events (competition_id, home_team_id, away_team_id, nome, kickoff_at, status, external_ref)
+			SELECT $1, h.id, a.id, h.nome || ' x ' || a.nome, $4, $5, $6
+			FROM teams h, teams a WHERE h.id = $2 AND a.id = $3
+			RETURNING id`,
+			req.CompetitionID, req.HomeTeamID, req.AwayTeamID, req.KickoffAt, req.Status, req.ExternalRef).Scan(&eventID)
+	} else {
+		err = database.DB.QueryRow(`
+			UPDATE events SET competition_id = $1, home_team_id = h.id, away_team_id = a.id,
+				nome = h.nome || ' x ' || a.nome, kickoff_at = $4, status = $5, external_ref = $6,
+				updated_at = CURRENT_TIMESTAMP
+			FROM teams h, teams a
+			WHERE h.id = $2 AND a.id = $3 AND events.id = $7
+			RETURNING events.id`,
+			req.CompetitionID, req.HomeTeamID, req.AwayTeamID, req.KickoffAt, req.Status, req.ExternalRef, id).Scan(&eventID)
+	}
+	if err == sql.ErrNoRows && id == 0 {
+		return nil, ErrUnknownTeam
+	}
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return GetEvent(eventID)
+}
+
+func scanEvent(row models.RowScanner) (*models.Event, error) {
+	var e models.Event
+	err := row.Scan(&e.ID, &e.CompetitionID, &e.HomeTeamID, &e.AwayTeamID, &e.Nome, &e.KickoffAt, &e.Status,
+		&e.ExternalRef, &e.HomeScore, &e.AwayScore, &e.Competicao, &e.Mandante, &e.Visitante, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package catalog
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+)
+
+// ImportResult resume uma importação em lote
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// Import cria ou atualiza eventos a partir de linhas que referenciam esporte, competição e
+// times pelo nome (criados se não existirem). O evento é identificado pela ref externa quando
+// informada; senão, pela combinação competição + times + início. Tudo roda em uma transação.
+func Import(rows []models.CatalogImportRow) (*ImportResult, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &ImportResult{}
+	for i, row := range rows {
+		created, err := importRow(tx, row)
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: %w", i+1, err)
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func importRow(tx *sql.Tx, row models.CatalogImportRow) (bool, error) {
+	if row.Esporte == "" || row.Competicao == "" || row.Mandante == "" || row.Visitante == "" {
+		return false, fmt.Errorf("esporte, competicao, mandante e visitante são obrigatórios")
+	}
+	kickoff, err := time.Parse(time.RFC3339, strings.TrimSpace(row.Inicio))
+	if err != nil {
+		return false, fmt.Errorf("inicio inválido, use RFC3339: %q", row.Inicio)
+	}
+
+	var pais *string
+	if row.Pais != "" {
+		pais = &row.Pais
+	}
+
+	var sportID, competitionID, homeID, awayID int
+	if err := tx.QueryRow(`
+		INSERT INTO sports (nome, slug) VALUES ($1, $2)
+		ON CONFLICT (slug) DO UPDATE SET nome = sports.nome
+		RETURNING id`, row.Esporte, models.Slugify(row.Esporte)).Scan(&sportID); err != nil {
+		return false, err
+	}
+	if err := tx.QueryRow(`
+		INSERT INTO competitions (sport_id, nome, slug, pais) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (slug) DO UPDATE SET pais = COALESCE(EXCLUDED.pais, competitions.pais)
+		RETURNING id`, sportID, row.Competicao, models.Slugify(row.Esporte+" "+row.Competicao), pais).Scan(&competitionID); err != nil {
+		return false, err
+	}
+	for _, team := range []struct {
+		nome string
+		id   *int
+	}{{row.Mandante, &homeID}, {row.Visitante, &awayID}} {
+		if err := tx.QueryRow(`
+			INSERT INTO teams (sport_id, nome, slug) VALUES ($1, $2, $3)
+			ON CONFLICT (slug) DO UPDATE SET nome = teams.nome
+			RETURNING id`, sportID, team.nome, models.Slugify(row.Esporte+" "+team.nome)).Scan(team.id); err != nil {
+			return false, err
+		}
+	}
+
+	var ref *string
+	if row.ExternalRef != "" {
+		ref = &row.ExternalRef
+	}
+	nome := row.Mandante + " x " + row.Visitante
+
+	var eventID int
+	if ref != nil {
+		err = tx.QueryRow("SELECT id FROM events WHERE external_ref = $1", *ref).Scan(&eventID)
+	} else {
+		err = tx.QueryRow(`
+			SELECT id FROM events
+			WHERE competition_id = $1 AND home_team_id = $2 AND away_team_id = $3 AND kickoff_at = $4`,
+			competitionID, homeID, awayID, kickoff).Scan(&eventID)
+	}
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(`
+			INSERT INTO events (competition_id, home_team_id, away_team_id, nome, kickoff_at, status, external_ref)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			competitionID, homeID, awayID, nome, kickoff, models.EVENTO_AGENDADO, ref)
+		return true, err
+	case err != nil:
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE events SET competition_id = $1, home_team_id = $2, away_team_id = $3, nome = $4,
+			kickoff_at = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6`,
+		competitionID, homeID, awayID, nome, kickoff, eventID)
+	return false, err
+}
@@ -0,0 +1,53 @@
+package compliance
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultJurisdiction é usada quando JURISDICTION não está definida
+const DefaultJurisdiction = "BR"
+
+// minimumAgeByJurisdiction guarda a idade mínima para apostas por jurisdição
+var minimumAgeByJurisdiction = map[string]int{
+	"BR": 18,
+	"PT": 18,
+	"GB": 18,
+	"AR": 18,
+	"US": 21,
+}
+
+// Jurisdiction retorna a jurisdição configurada para esta instância
+func Jurisdiction() string {
+	if j := strings.ToUpper(strings.TrimSpace(os.Getenv("JURISDICTION"))); j != "" {
+		return j
+	}
+	return DefaultJurisdiction
+}
+
+// MinimumAge retorna a idade mínima de cadastro. MINIMUM_AGE sobrescreve o valor da jurisdição.
+func MinimumAge() int {
+	if age, err := strconv.Atoi(os.Getenv("MINIMUM_AGE")); err == nil && age > 0 {
+		return age
+	}
+	if age, ok := minimumAgeByJurisdiction[Jurisdiction()]; ok {
+		return age
+	}
+	return minimumAgeByJurisdiction[DefaultJurisdiction]
+}
+
+// AgeAt calcula a idade completa em anos na data informada
+func AgeAt(birthDate, at time.Time) int {
+	age := at.Year() - birthDate.Year()
+	if at.Month() < birthDate.Month() || (at.Month() == birthDate.Month() && at.Day() < birthDate.Day()) {
+		age--
+	}
+	return age
+}
+
+// IsOfLegalAge indica se quem nasceu em birthDate já atingiu a idade mínima
+func IsOfLegalAge(birthDate time.Time) bool {
+	return AgeAt(birthDate, time.Now()) >= MinimumAge()
+}
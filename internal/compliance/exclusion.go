@@ -0,0 +1,79 @@
+package compliance
+
+import (
+	"database/sql"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+)
+
+// LoadSettings retorna as configurações de jogo responsável do usuário (padrão se não houver registro)
+func LoadSettings(userID int) (*models.GamblingSettings, error) {
+	settings := models.GamblingSettings{UserID: userID}
+	err := database.DB.QueryRow(`
+		SELECT hide_odds, exclusion_type, excluded_from, excluded_until, exclusion_reason, updated_at
+		FROM user_gambling_settings WHERE user_id = $1`, userID).
+		Scan(&settings.OcultarOdds, &settings.TipoExclusao, &settings.ExcluidoDesde,
+			&settings.ExcluidoAte, &settings.MotivoExclusao, &settings.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	settings.ExclusaoAtiva = settings.IsExcluded(time.Now())
+	return &settings, nil
+}
+
+// SaveSettings grava (upsert) as configurações de jogo responsável do usuário
+func SaveSettings(s *models.GamblingSettings) error {
+	return database.DB.QueryRow(`
+		INSERT INTO user_gambling_settings
+			(user_id, hide_odds, exclusion_type, excluded_from, excluded_until, exclusion_reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			hide_odds = EXCLUDED.hide_odds,
+			exclusion_type = EXCLUDED.exclusion_type,
+			excluded_from = EXCLUDED.excluded_from,
+			excluded_until = EXCLUDED.excluded_until,
+			exclusion_reason = EXCLUDED.exclusion_reason,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`,
+		s.UserID, s.OcultarOdds, s.TipoExclusao, s.ExcluidoDesde, s.ExcluidoAte, s.MotivoExclusao).
+		Scan(&s.UpdatedAt)
+}
+
+// NotExcluded é o filtro SQL que descarta usuários com pausa ou autoexclusão vigente, para os
+// envios em massa (notificações e stream) não alcançarem quem pediu para se afastar. column é a
+// coluna com o id do usuário na consulta.
+func NotExcluded(column string) string {
+	return `NOT EXISTS (
+		SELECT 1 FROM user_gambling_settings gs
+		WHERE gs.user_id = ` + column + ` AND gs.exclusion_type IS NOT NULL
+		  AND (gs.excluded_until IS NULL OR gs.excluded_until > CURRENT_TIMESTAMP))`
+}
+
+// ListActiveExclusions lista os usuários com pausa ou autoexclusão vigente
+func ListActiveExclusions() ([]models.ExclusionResponse, error) {
+	rows, err := database.DB.Query(`
+		SELECT u.id, u.nome, u.email, s.exclusion_type, s.excluded_from, s.excluded_until, s.exclusion_reason
+		FROM user_gambling_settings s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.exclusion_type IS NOT NULL
+		  AND (s.excluded_until IS NULL OR s.excluded_until > CURRENT_TIMESTAMP)
+		ORDER BY s.excluded_from DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exclusions := []models.ExclusionResponse{}
+	for rows.Next() {
+		var e models.ExclusionResponse
+		if err := rows.Scan(&e.UserID, &e.Nome, &e.Email, &e.TipoExclusao,
+			&e.ExcluidoDesde, &e.ExcluidoAte, &e.Motivo); err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, e)
+	}
+	return exclusions, rows.Err()
+}
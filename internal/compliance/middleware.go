@@ -0,0 +1,65 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/models"
+)
+
+type contextKey string
+
+const settingsContextKey contextKey = "gambling_settings"
+
+// EnforceExclusion bloqueia o acesso a rotas de palpites para usuários em pausa ou autoexclusão
+// e disponibiliza as configurações de jogo responsável no contexto. Deve rodar após auth.RequireAuth.
+func EnforceExclusion(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := auth.CurrentUser(r)
+		if user == nil || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		settings, err := LoadSettings(user.ID)
+		if err != nil {
+			log.Printf("Erro ao carregar configurações de jogo responsável: %v", err)
+			sendError(w, map[string]interface{}{"message": "Erro ao verificar restrições da conta"}, http.StatusInternalServerError)
+			return
+		}
+
+		if settings.IsExcluded(time.Now()) {
+			sendError(w, map[string]interface{}{
+				"message":       "Conta pausada pelo programa de jogo responsável",
+				"tipo_exclusao": settings.TipoExclusao,
+				"excluido_ate":  settings.ExcluidoAte,
+			}, http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), settingsContextKey, settings)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// SettingsFromContext retorna as configurações carregadas por EnforceExclusion
+func SettingsFromContext(ctx context.Context) (*models.GamblingSettings, bool) {
+	settings, ok := ctx.Value(settingsContextKey).(*models.GamblingSettings)
+	return settings, ok && settings != nil
+}
+
+// HideOdds indica se o conteúdo de odds deve ser omitido na resposta para o usuário da requisição
+func HideOdds(r *http.Request) bool {
+	settings, ok := SettingsFromContext(r.Context())
+	return ok && settings.OcultarOdds
+}
+
+func sendError(w http.ResponseWriter, body map[string]interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,106 @@
+// Package events é o barramento interno de eventos de domínio. Produtores publicam eventos sem
+// conhecer quem os consome; cada assinante recebe o evento em um job próprio da fila, então a
+// entrega é durável, funciona em modo serverless e a falha de um assinante não afeta os outros.
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/jobs"
+)
+
+// Event é um fato de domínio; EventName identifica o tipo para os assinantes
+type Event interface {
+	EventName() string
+}
+
+type subscriber struct {
+	name    string
+	handler func(ctx context.Context, data json.RawMessage) error
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers = map[string][]subscriber{}
+)
+
+// Subscribe registra fn para os eventos do tipo T. name identifica o assinante nos jobs e deve
+// ser único por tipo de evento; fn precisa ser idempotente, pois pode ser reexecutada.
+func Subscribe[T Event](name string, fn func(ctx context.Context, e T) error) {
+	var zero T
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers[zero.EventName()] = append(subscribers[zero.EventName()], subscriber{
+		name: name,
+		handler: func(ctx context.Context, data json.RawMessage) error {
+			var e T
+			if err := json.Unmarshal(data, &e); err != nil {
+				return jobs.Permanent(fmt.Errorf("evento inválido: %w", err))
+			}
+			return fn(ctx, e)
+		},
+	})
+}
+
+// Delivery é o job que entrega um evento a um assinante
+type Delivery struct {
+	Event      string          `json:"event"`
+	Subscriber string          `json:"subscriber"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func (Delivery) JobType() string { return "event_delivery" }
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Publish enfileira uma entrega do evento para cada assinante. Falhas são registradas no log e
+// não interrompem o produtor: o evento é um efeito colateral da ação principal.
+func Publish(e Event) {
+	if err := publish(database.DB, e); err != nil {
+		log.Printf("Erro ao publicar evento %s: %v", e.EventName(), err)
+	}
+}
+
+// PublishTx enfileira as entregas na transação: só são entregues se ela for confirmada
+func PublishTx(tx *sql.Tx, e Event) error {
+	return publish(tx, e)
+}
+
+func publish(db queryRower, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	mu.RLock()
+	subs := subscribers[e.EventName()]
+	mu.RUnlock()
+	for _, s := range subs {
+		d := Delivery{Event: e.EventName(), Subscriber: s.name, Data: data}
+		if _, err := jobs.EnqueueWith(db, d, jobs.Options{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deliver é o handler do job Delivery (registrado em internal/tasks)
+func Deliver(ctx context.Context, d Delivery) error {
+	mu.RLock()
+	subs := subscribers[d.Event]
+	mu.RUnlock()
+	for _, s := range subs {
+		if s.name == d.Subscriber {
+			return s.handler(ctx, d.Data)
+		}
+	}
+	return jobs.Permanent(fmt.Errorf("assinante %s não registrado para %s", d.Subscriber, d.Event))
+}
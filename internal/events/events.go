@@ -0,0 +1,54 @@
+package events
+
+// PalpitePublished: um palpite ficou visível (criado já publicado, saiu do rascunho ou chegou o publish_at)
+type PalpitePublished struct {
+	PalpiteID int    `json:"palpite_id"`
+	AuthorID  int    `json:"author_id"`
+	Titulo    string `json:"titulo"`
+}
+
+func (PalpitePublished) EventName() string { return "palpite.published" }
+
+// PalpiteSettled: a liquidação definiu (ou corrigiu) o resultado de um palpite
+type PalpiteSettled struct {
+	PalpiteID int     `json:"palpite_id"`
+	UserID    int     `json:"user_id"`
+	Status    string  `json:"status"`
+	Lucro     float64 `json:"lucro"`
+}
+
+func (PalpiteSettled) EventName() string { return "palpite.settled" }
+
+// CommentCreated: alguém comentou em um palpite
+type CommentCreated struct {
+	CommentID int    `json:"comment_id"`
+	PalpiteID int    `json:"palpite_id"`
+	OwnerID   int    `json:"owner_id"` // autor do palpite
+	AuthorID  int    `json:"author_id"`
+	Texto     string `json:"texto"`
+}
+
+func (CommentCreated) EventName() string { return "comment.created" }
+
+// UserFollowed: followerID passou a seguir followedID
+type UserFollowed struct {
+	FollowerID int `json:"follower_id"`
+	FollowedID int `json:"followed_id"`
+}
+
+func (UserFollowed) EventName() string { return "user.followed" }
+
+// NotificationCreated: uma notificação foi criada na central (consumido pelo Web Push)
+type NotificationCreated struct {
+	NotificationID int `json:"notification_id"`
+	UserID         int `json:"user_id"`
+}
+
+func (NotificationCreated) EventName() string { return "notification.created" }
+
+// NotificationsRead: o usuário marcou notificações como lidas (muda o total de não lidas)
+type NotificationsRead struct {
+	UserID int `json:"user_id"`
+}
+
+func (NotificationsRead) EventName() string { return "notifications.read" }
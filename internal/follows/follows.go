@@ -0,0 +1,50 @@
+// Package follows mantém quem segue quais tipsters.
+package follows
+
+import (
+	"errors"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+)
+
+var ErrSelfFollow = errors.New("não é possível seguir a si mesmo")
+
+// Follow registra que followerID segue followedID; created é falso se já seguia
+func Follow(followerID, followedID int) (bool, error) {
+	if followerID == followedID {
+		return false, ErrSelfFollow
+	}
+	result, err := database.DB.Exec(`
+		INSERT INTO user_follows (follower_id, followed_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, followerID, followedID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	if n > 0 {
+		events.Publish(events.UserFollowed{FollowerID: followerID, FollowedID: followedID})
+	}
+	return n > 0, nil
+}
+
+func Unfollow(followerID, followedID int) error {
+	_, err := database.DB.Exec("DELETE FROM user_follows WHERE follower_id = $1 AND followed_id = $2", followerID, followedID)
+	return err
+}
+
+func IsFollowing(followerID, followedID int) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM user_follows WHERE follower_id = $1 AND followed_id = $2)`,
+		followerID, followedID).Scan(&exists)
+	return exists, err
+}
+
+// Counts retorna quantos seguidores o usuário tem e quantos usuários ele segue
+func Counts(userID int) (followers int, following int, err error) {
+	err = database.DB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM user_follows WHERE followed_id = $1),
+			   (SELECT COUNT(*) FROM user_follows WHERE follower_id = $1)`, userID).Scan(&followers, &following)
+	return followers, following, err
+}
@@ -0,0 +1,497 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/passwords"
+	"smartpicks-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	impersonationTTL  = time.Hour
+	passwordResetTTL  = 24 * time.Hour
+	minModerationNote = 5
+)
+
+// AdminGetUser retorna o usuário com status de suspensão e o histórico de moderação
+func AdminGetUser(w http.ResponseWriter, r *http.Request) {
+	admin := auth.CurrentUser(r)
+
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := listAdminActions(target.ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar histórico de moderação", http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"user":      target.ToResponseFor(admin),
+		"deleted":   target.IsDeleted(),
+		"historico": history,
+	})
+}
+
+func AdminChangePerfil(w http.ResponseWriter, r *http.Request) {
+	admin := auth.CurrentUser(r)
+
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ChangePerfilRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	if !models.IsValidPerfil(req.Perfil) {
+		sendErrorResponse(w, "Perfil inválido. Use 'admin' ou 'user'", http.StatusBadRequest)
+		return
+	}
+
+	if target.ID == admin.ID {
+		sendErrorResponse(w, "Não é permitido alterar o próprio perfil", http.StatusBadRequest)
+		return
+	}
+
+	if req.Perfil == target.Perfil {
+		sendErrorResponse(w, "Usuário já possui este perfil", http.StatusConflict)
+		return
+	}
+
+	if _, err := database.DB.Exec("UPDATE users SET perfil = $1 WHERE id = $2", req.Perfil, target.ID); err != nil {
+		sendErrorResponse(w, "Erro ao alterar perfil", http.StatusInternalServerError)
+		return
+	}
+
+	recordAdminAction(r, admin.ID, target.ID, models.ACAO_ALTERAR_PERFIL, nil, map[string]interface{}{
+		"de":   target.Perfil,
+		"para": req.Perfil,
+	})
+
+	sendModeratedUser(w, admin, target.ID, "Perfil alterado com sucesso")
+}
+
+// AdminSuspendUser suspende temporariamente (dias > 0) ou bane (tipo "banimento") o usuário
+func AdminSuspendUser(w http.ResponseWriter, r *http.Request) {
+	admin := auth.CurrentUser(r)
+
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SuspendUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	if target.ID == admin.ID {
+		sendErrorResponse(w, "Não é permitido suspender a própria conta", http.StatusBadRequest)
+		return
+	}
+
+	motivo := strings.TrimSpace(req.Motivo)
+	if len(motivo) < minModerationNote {
+		sendErrorResponse(w, "Motivo é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	var until *time.Time
+	action := models.ACAO_SUSPENDER
+	switch req.Tipo {
+	case models.SUSPENSAO_TEMPORARIA:
+		if req.Dias <= 0 {
+			sendErrorResponse(w, "Informe a duração da suspensão em dias", http.StatusBadRequest)
+			return
+		}
+		t := time.Now().AddDate(0, 0, req.Dias)
+		until = &t
+	case models.SUSPENSAO_BANIMENTO:
+		action = models.ACAO_BANIR
+	default:
+		sendErrorResponse(w, "Tipo inválido. Use 'suspensao' ou 'banimento'", http.StatusBadRequest)
+		return
+	}
+
+	_, err := database.DB.Exec(`
+		UPDATE users
+		SET suspension_type = $1, suspended_until = $2, suspension_reason = $3, suspended_at = CURRENT_TIMESTAMP
+		WHERE id = $4`, req.Tipo, until, motivo, target.ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao suspender usuário", http.StatusInternalServerError)
+		return
+	}
+
+	details := map[string]interface{}{"tipo": req.Tipo}
+	if until != nil {
+		details["ate"] = until
+	}
+	recordAdminAction(r, admin.ID, target.ID, action, &motivo, details)
+
+	sendModeratedUser(w, admin, target.ID, "Usuário suspenso com sucesso")
+}
+
+func AdminUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	admin := auth.CurrentUser(r)
+
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ModerationReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	if target.SuspensaoTipo == nil {
+		sendErrorResponse(w, "Usuário não está suspenso", http.StatusConflict)
+		return
+	}
+
+	_, err := database.DB.Exec(`
+		UPDATE users
+		SET suspension_type = NULL, suspended_until = NULL, suspension_reason = NULL, suspended_at = NULL
+		WHERE id = $1`, target.ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao reativar usuário", http.StatusInternalServerError)
+		return
+	}
+
+	recordAdminAction(r, admin.ID, target.ID, models.ACAO_REATIVAR, optionalReason(req.Motivo), map[string]interface{}{
+		"tipo_anterior": *target.SuspensaoTipo,
+	})
+
+	sendModeratedUser(w, admin, target.ID, "Usuário reativado com sucesso")
+}
+
+// AdminForcePasswordReset invalida as sessões do usuário e envia um link de redefinição de senha
+func AdminForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	admin := auth.CurrentUser(r)
+
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ModerationReasonRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	token, err := auth.GenerateSecureToken(32)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao gerar token de redefinição", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = database.DB.Exec(`
+		UPDATE users
+		SET must_reset_password = TRUE,
+			password_reset_token_hash = $1,
+			password_reset_expires_at = $2,
+			tokens_valid_after = CURRENT_TIMESTAMP
+		WHERE id = $3`, auth.HashToken(token), time.Now().Add(passwordResetTTL), target.ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao forçar redefinição de senha", http.StatusInternalServerError)
+		return
+	}
+
+	body := fmt.Sprintf("Olá, %s!\n\nPor segurança, sua senha do SmartPicks precisa ser redefinida. Acesse:\n%s/redefinir-senha?token=%s\n\nO link expira em 24 horas.",
+		target.Nome, services.FrontendURL(), token)
+	if _, err := jobs.Enqueue(jobs.SendEmail{To: target.Email, Subject: "Redefina sua senha", Body: body}); err != nil {
+		log.Printf("Erro ao enfileirar email de redefinição para o usuário %d: %v", target.ID, err)
+	}
+
+	recordAdminAction(r, admin.ID, target.ID, models.ACAO_RESET_SENHA, optionalReason(req.Motivo), nil)
+
+	sendModeratedUser(w, admin, target.ID, "Redefinição de senha exigida e email enviado")
+}
+
+// AdminImpersonateUser gera um token de curta duração para o admin agir como o usuário (suporte).
+// O motivo é obrigatório e toda requisição feita com o token é identificada e registrada.
+func AdminImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	admin := auth.CurrentUser(r)
+
+	if auth.ImpersonatorID(r) != 0 {
+		sendErrorResponse(w, "Não é permitido personificar a partir de uma sessão personificada", http.StatusForbidden)
+		return
+	}
+
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ModerationReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	motivo := strings.TrimSpace(req.Motivo)
+	if len(motivo) < minModerationNote {
+		sendErrorResponse(w, "Motivo é obrigatório para personificar um usuário", http.StatusBadRequest)
+		return
+	}
+
+	if target.IsAdmin() || target.ID == admin.ID {
+		sendErrorResponse(w, "Não é permitido personificar administradores", http.StatusForbidden)
+		return
+	}
+
+	token, err := auth.IssueImpersonationToken(target.ID, target.Perfil, admin.ID, impersonationTTL)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao gerar sessão", http.StatusInternalServerError)
+		return
+	}
+
+	recordAdminAction(r, admin.ID, target.ID, models.ACAO_PERSONIFICAR, &motivo, map[string]interface{}{
+		"expira_em": time.Now().Add(impersonationTTL),
+	})
+	log.Printf("👤 Admin %d iniciou personificação do usuário %d: %s", admin.ID, target.ID, motivo)
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"token":      token,
+		"user":       target.ToResponseFor(admin),
+		"expires_in": int(impersonationTTL.Seconds()),
+		"message":    "Sessão de suporte criada. Todas as ações serão registradas",
+	})
+}
+
+// AdminDeleteUser faz a exclusão lógica (soft delete) do usuário
+func AdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	admin := auth.CurrentUser(r)
+
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ModerationReasonRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	if target.ID == admin.ID {
+		sendErrorResponse(w, "Não é permitido excluir a própria conta por aqui", http.StatusBadRequest)
+		return
+	}
+
+	if target.IsDeleted() {
+		sendErrorResponse(w, "Usuário já excluído", http.StatusConflict)
+		return
+	}
+
+	if _, err := database.DB.Exec("UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1", target.ID); err != nil {
+		sendErrorResponse(w, "Erro ao excluir usuário", http.StatusInternalServerError)
+		return
+	}
+
+	recordAdminAction(r, admin.ID, target.ID, models.ACAO_EXCLUIR, optionalReason(req.Motivo), nil)
+
+	sendSuccessResponse(w, map[string]string{
+		"message": "Usuário excluído com sucesso",
+	})
+}
+
+// ResetPassword conclui a redefinição de senha com o token enviado por email
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	if req.Token == "" || req.NovaSenha == "" {
+		sendErrorResponse(w, "Token e nova senha são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	// A política compara a senha com o email e o nome do dono do token
+	var email, nome string
+	err := database.DB.QueryRow(`
+		SELECT email, nome FROM users
+		WHERE password_reset_token_hash = $1
+		  AND password_reset_expires_at > CURRENT_TIMESTAMP
+		  AND deleted_at IS NULL`, auth.HashToken(req.Token)).Scan(&email, &nome)
+	if err == sql.ErrNoRows {
+		sendErrorResponse(w, "Token inválido ou expirado", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao redefinir senha", http.StatusInternalServerError)
+		return
+	}
+	if !validNewPassword(w, req.NovaSenha, email, nome) {
+		return
+	}
+
+	hashedPassword, err := passwords.Hash(req.NovaSenha)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao processar password", http.StatusInternalServerError)
+		return
+	}
+
+	var userID int
+	err = database.DB.QueryRow(`
+		UPDATE users
+		SET password = $1,
+			must_reset_password = FALSE,
+			password_reset_token_hash = NULL,
+			password_reset_expires_at = NULL,
+			tokens_valid_after = CURRENT_TIMESTAMP
+		WHERE password_reset_token_hash = $2
+		  AND password_reset_expires_at > CURRENT_TIMESTAMP
+		  AND deleted_at IS NULL
+		RETURNING id`, hashedPassword, auth.HashToken(req.Token)).Scan(&userID)
+	if err == sql.ErrNoRows {
+		sendErrorResponse(w, "Token inválido ou expirado", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao redefinir senha", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := auth.RevokeOtherSessions(userID, 0, auth.REVOGACAO_SENHA); err != nil {
+		log.Printf("Erro ao encerrar sessões do usuário %d: %v", userID, err)
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionPasswordReset,
+		ActorID:    userID,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+
+	sendSuccessResponse(w, map[string]string{
+		"message": "Senha redefinida com sucesso. Faça login novamente",
+	})
+}
+
+// loadTargetUser carrega o usuário do parâmetro {id} da rota, respondendo 400/404 quando necessário
+func loadTargetUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		sendErrorResponse(w, "ID do usuário inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	user, err := models.ScanUser(database.DB.QueryRow(`
+		SELECT `+models.UserColumns+`
+		FROM users WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar usuário", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func sendModeratedUser(w http.ResponseWriter, admin *models.User, userID int, message string) {
+	user, err := models.ScanUser(database.DB.QueryRow(`
+		SELECT `+models.UserColumns+`
+		FROM users WHERE id = $1`, userID))
+	if err != nil {
+		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"user":    user.ToResponseFor(admin),
+		"message": message,
+	})
+}
+
+// recordAdminAction grava o histórico de moderação e o evento de auditoria correspondente;
+// adminID 0 grava a ação sem autor. Falhas são apenas registradas em log.
+func recordAdminAction(r *http.Request, adminID, targetID int, action string, reason *string, details map[string]interface{}) {
+	var detailsJSON []byte
+	if details != nil {
+		detailsJSON, _ = json.Marshal(details)
+	}
+
+	_, err := database.DB.Exec(`
+		INSERT INTO admin_actions (admin_id, target_user_id, action, reason, details)
+		VALUES (NULLIF($1, 0), $2, $3, $4, $5)`, adminID, targetID, action, reason, detailsJSON)
+	if err != nil {
+		log.Printf("Erro ao registrar ação administrativa %s (admin %d, usuário %d): %v", action, adminID, targetID, err)
+	}
+
+	ev := audit.Event{
+		Action:     audit.ActionAdminPrefix + action,
+		TargetType: audit.TargetUser,
+		TargetID:   targetID,
+		Metadata:   map[string]interface{}{},
+	}
+	for k, v := range details {
+		ev.Metadata[k] = v
+	}
+	if reason != nil {
+		ev.Metadata["motivo"] = *reason
+	}
+	switch action {
+	case models.ACAO_ALTERAR_PERFIL:
+		ev.Action = audit.ActionRoleChange
+		ev.Diff = map[string]audit.Change{"perfil": {From: details["de"], To: details["para"]}}
+	case models.ACAO_PERSONIFICAR:
+		ev.Action = audit.ActionImpersonateStart
+	}
+	audit.Record(r, ev)
+}
+
+func listAdminActions(userID int) ([]models.AdminAction, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, admin_id, target_user_id, action, reason, details, created_at
+		FROM admin_actions WHERE target_user_id = $1
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []models.AdminAction{}
+	for rows.Next() {
+		var a models.AdminAction
+		var details []byte
+		if err := rows.Scan(&a.ID, &a.AdminID, &a.TargetUserID, &a.Acao, &a.Motivo, &details, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(details) > 0 {
+			json.Unmarshal(details, &a.Detalhes)
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+func optionalReason(reason string) *string {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil
+	}
+	return &reason
+}
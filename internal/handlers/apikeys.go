@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+)
+
+// GetMyAPIKeys lista as chaves de API do usuário, identificadas pelo prefixo
+func GetMyAPIKeys(w http.ResponseWriter, r *http.Request) {
+	list, err := auth.ListAPIKeys(auth.CurrentUser(r).ID)
+	if err != nil {
+		log.Printf("Erro ao listar chaves de API: %v", err)
+		sendErrorResponse(w, "Erro ao buscar chaves de API", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{
+		"api_keys":         list,
+		"total":            len(list),
+		"escopos_validos":  auth.Scopes,
+		"limite_de_chaves": auth.MaxAPIKeys,
+	})
+}
+
+// CreateMyAPIKey cria uma chave de API; o valor completo só é exibido nesta resposta. Não é
+// permitido em sessão personificada: a chave sobreviveria ao token de suporte.
+func CreateMyAPIKey(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	if auth.ImpersonatorID(r) != 0 {
+		sendErrorResponse(w, "Chaves de API não podem ser criadas em sessão personificada", http.StatusForbidden)
+		return
+	}
+	var req struct {
+		Nome         string   `json:"nome"`
+		Escopos      []string `json:"escopos"`
+		ExpiraEmDias int      `json:"expira_em_dias"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	key, raw, err := auth.CreateAPIKey(user.ID, req.Nome, req.Escopos, req.ExpiraEmDias)
+	if err != nil {
+		sendAPIKeyError(w, err)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionAPIKeyCreate,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata: map[string]interface{}{
+			"prefixo":    key.Prefixo,
+			"escopos":    key.Escopos,
+			"expires_at": key.ExpiresAt,
+		},
+	})
+
+	w.WriteHeader(http.StatusCreated)
+	sendSuccessResponse(w, map[string]interface{}{
+		"api_key": key,
+		"key":     raw,
+		"message": "Copie a chave agora: ela não será exibida novamente",
+	})
+}
+
+// DeleteMyAPIKey revoga a chave; scripts que a usam passam a receber 401
+func DeleteMyAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+	user := auth.CurrentUser(r)
+	prefix, err := auth.RevokeAPIKey(user.ID, id)
+	if err != nil {
+		sendAPIKeyError(w, err)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionAPIKeyRevoke,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   map[string]interface{}{"prefixo": prefix},
+	})
+	sendSuccessResponse(w, map[string]string{"message": "Chave de API revogada"})
+}
+
+func sendAPIKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidKeyInput), errors.Is(err, auth.ErrInvalidScope):
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, auth.ErrTooManyAPIKeys):
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, auth.ErrAPIKeyNotFound):
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	default:
+		log.Printf("Erro nas chaves de API: %v", err)
+		sendErrorResponse(w, "Erro ao processar chave de API", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"smartpicks-backend/internal/audit"
+)
+
+const (
+	auditDefaultLimit = 50
+	auditMaxLimit     = 200
+)
+
+// GetAuditEvents lista o log de auditoria com filtros (action, actor_id, target_type, target_id,
+// ip, from, to) e paginação (page, limit)
+func GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := audit.Filter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		IP:         q.Get("ip"),
+		Limit:      auditDefaultLimit,
+	}
+
+	var err error
+	if filter.ActorID, err = optionalInt(q.Get("actor_id")); err != nil {
+		sendErrorResponse(w, "actor_id inválido", http.StatusBadRequest)
+		return
+	}
+	if filter.TargetID, err = optionalInt(q.Get("target_id")); err != nil {
+		sendErrorResponse(w, "target_id inválido", http.StatusBadRequest)
+		return
+	}
+	if filter.From, err = optionalTime(q.Get("from")); err != nil {
+		sendErrorResponse(w, "from inválido. Use YYYY-MM-DD ou RFC3339", http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = optionalTime(q.Get("to")); err != nil {
+		sendErrorResponse(w, "to inválido. Use YYYY-MM-DD ou RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	if limit, err := optionalInt(q.Get("limit")); err != nil || limit < 0 {
+		sendErrorResponse(w, "limit inválido", http.StatusBadRequest)
+		return
+	} else if limit > 0 {
+		filter.Limit = min(limit, auditMaxLimit)
+	}
+
+	page, err := optionalInt(q.Get("page"))
+	if err != nil || page < 0 {
+		sendErrorResponse(w, "page inválido", http.StatusBadRequest)
+		return
+	}
+	if page == 0 {
+		page = 1
+	}
+	filter.Offset = (page - 1) * filter.Limit
+
+	events, total, err := audit.List(filter)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar eventos de auditoria", http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"events":  events,
+		"total":   total,
+		"page":    page,
+		"limit":   filter.Limit,
+		"message": "Eventos de auditoria listados com sucesso",
+	})
+}
+
+func optionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// optionalTime aceita datas YYYY-MM-DD ou timestamps RFC3339
+func optionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
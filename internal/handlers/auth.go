@@ -2,14 +2,20 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/compliance"
 	"smartpicks-backend/internal/database"
 	"smartpicks-backend/internal/models"
-
-	"golang.org/x/crypto/bcrypt"
+	"smartpicks-backend/internal/passwords"
+	"smartpicks-backend/internal/twofactor"
 )
 
 func Login(w http.ResponseWriter, r *http.Request) {
@@ -25,26 +31,80 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var user models.User
-	err := database.DB.QueryRow(`
-		SELECT id, nome, email, password, cpf,
-			   TO_CHAR(data_nascimento, 'YYYY-MM-DD') as data_nascimento,
-			   perfil, COALESCE(avatar, '') as avatar, created_at, updated_at 
-		FROM users WHERE email = $1`, loginData.Email).
-		Scan(&user.ID, &user.Nome, &user.Email, &user.Password,
-			&user.CPF, &user.DataNascimento, &user.Perfil, &user.Avatar, &user.CreatedAt, &user.UpdatedAt)
+	var passwordHash string
+	user, err := models.ScanUser(database.DB.QueryRow(`
+		SELECT `+models.UserColumns+`, password
+		FROM users WHERE email = $1 AND deleted_at IS NULL`, loginData.Email), &passwordHash)
 	if err != nil {
 		log.Printf("Erro ao buscar usuário: %v", err)
+		passwords.VerifyDummy(loginData.Password)
+		recordLoginFailure(r, loginData.Email, 0, "usuario_nao_encontrado")
 		sendErrorResponse(w, "Email ou senha incorretos", http.StatusUnauthorized)
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginData.Password)); err != nil {
+	if err := passwords.Verify(passwordHash, loginData.Password); err != nil {
+		if !errors.Is(err, passwords.ErrMismatch) {
+			log.Printf("Erro ao conferir senha do usuário %d: %v", user.ID, err)
+		}
+		recordLoginFailure(r, loginData.Email, user.ID, "senha_incorreta")
 		sendErrorResponse(w, "Email ou senha incorretos", http.StatusUnauthorized)
 		return
 	}
+	// Hashes com custo ou algoritmo antigos são refeitos enquanto a senha está em mãos
+	passwords.Upgrade(user.ID, passwordHash, loginData.Password)
+
+	if !loginAllowed(w, r, user, loginData.Email) {
+		return
+	}
+
+	completeLogin(w, r, user, nil)
+}
+
+// completeLogin emite a sessão depois do primeiro fator. Com 2FA ativo, responde com o desafio
+// do segundo passo (POST /api/login/2fa) em vez do token.
+func completeLogin(w http.ResponseWriter, r *http.Request, user *models.User, metadata map[string]interface{}) {
+	if user.TwoFactorEnabled() {
+		challenge, err := twofactor.NewChallenge(user.ID)
+		if err != nil {
+			log.Printf("Erro ao criar desafio de 2FA: %v", err)
+			sendErrorResponse(w, "Erro ao gerar sessão", http.StatusInternalServerError)
+			return
+		}
+		sendSuccessResponse(w, map[string]interface{}{
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+			"expires_in":          int(twofactor.ChallengeTTL.Seconds()),
+			"message":             "Informe o código do aplicativo autenticador",
+		})
+		return
+	}
 
-	sendSuccessResponse(w, user.ToResponse())
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionLoginSuccess,
+		ActorID:    user.ID,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   metadata,
+	})
+
+	sendAuthResponse(w, r, user, http.StatusOK)
+}
+
+// loginAllowed aplica as restrições de conta comuns a todas as formas de login
+func loginAllowed(w http.ResponseWriter, r *http.Request, user *models.User, email string) bool {
+	if user.IsSuspended(time.Now()) {
+		recordLoginFailure(r, email, user.ID, "conta_suspensa")
+		auth.SendSuspendedError(w, user)
+		return false
+	}
+
+	if user.MustResetPassword {
+		recordLoginFailure(r, email, user.ID, "reset_de_senha_pendente")
+		sendErrorResponse(w, "É necessário redefinir sua senha. Verifique o link enviado por email", http.StatusForbidden)
+		return false
+	}
+	return true
 }
 
 func Register(w http.ResponseWriter, r *http.Request) {
@@ -55,31 +115,39 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user.Email = strings.TrimSpace(user.Email)
 	if user.Nome == "" || user.Email == "" || user.Password == "" || user.CPF == "" || user.DataNascimento == "" {
 		sendErrorResponse(w, "Nome, email, password, CPF e data de nascimento são obrigatórios", http.StatusBadRequest)
 		return
 	}
 
-	if user.Perfil == "" {
-		user.Perfil = "user"
+	if !models.IsValidEmail(user.Email) {
+		sendErrorResponse(w, "Email inválido", http.StatusBadRequest)
+		return
 	}
 
-	if !models.IsValidPerfil(user.Perfil) {
-		sendErrorResponse(w, "Perfil inválido. Use 'admin' ou 'user'", http.StatusBadRequest)
+	if !models.IsValidCPF(user.CPF) {
+		sendErrorResponse(w, "CPF inválido", http.StatusBadRequest)
 		return
 	}
+	user.CPF = models.NormalizeCPF(user.CPF)
+
+	// O cadastro público sempre cria usuários comuns; admins são criados por convite ou pelo CLI
+	user.Perfil = models.PERFIL_USER
 
 	// Validar formato da data
-	parsedDate, err := time.Parse("2006-01-02", user.DataNascimento)
+	parsedDate, err := parseBirthDate(user.DataNascimento)
 	if err != nil {
-		parsedDate, err = time.Parse("02/01/2006", user.DataNascimento)
-		if err != nil {
-			sendErrorResponse(w, "Formato de data inválido. Use YYYY-MM-DD ou DD/MM/YYYY", http.StatusBadRequest)
-			return
-		}
+		sendErrorResponse(w, "Formato de data inválido. Use YYYY-MM-DD ou DD/MM/YYYY", http.StatusBadRequest)
+		return
 	}
 	user.DataNascimento = parsedDate.Format("2006-01-02")
 
+	if !compliance.IsOfLegalAge(parsedDate) {
+		sendErrorResponse(w, fmt.Sprintf("Cadastro permitido apenas para maiores de %d anos", compliance.MinimumAge()), http.StatusForbidden)
+		return
+	}
+
 	if userExists("email", user.Email) {
 		sendErrorResponse(w, "Email já cadastrado", http.StatusConflict)
 		return
@@ -90,7 +158,11 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if !validNewPassword(w, user.Password, user.Email, user.Nome) {
+		return
+	}
+
+	hashedPassword, err := passwords.Hash(user.Password)
 	if err != nil {
 		sendErrorResponse(w, "Erro ao processar password", http.StatusInternalServerError)
 		return
@@ -101,45 +173,59 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		INSERT INTO users (nome, email, password, cpf, data_nascimento, perfil, avatar)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id`,
-		user.Nome, user.Email, string(hashedPassword), user.CPF, user.DataNascimento, user.Perfil, user.Avatar).Scan(&userID)
+		user.Nome, user.Email, hashedPassword, user.CPF, user.DataNascimento, user.Perfil, user.Avatar).Scan(&userID)
 
 	if err != nil {
 		sendErrorResponse(w, "Erro ao cadastrar usuário", http.StatusInternalServerError)
 		return
 	}
 
-	err = database.DB.QueryRow(`
-		SELECT id, nome, email, cpf,
-			   TO_CHAR(data_nascimento, 'YYYY-MM-DD') as data_nascimento,
-			   perfil, COALESCE(avatar, '') as avatar, created_at, updated_at 
-		FROM users WHERE id = $1`, userID).
-		Scan(&user.ID, &user.Nome, &user.Email, &user.CPF,
-			&user.DataNascimento, &user.Perfil, &user.Avatar, &user.CreatedAt, &user.UpdatedAt)
-
+	created, err := models.ScanUser(database.DB.QueryRow(`
+		SELECT `+models.UserColumns+`
+		FROM users WHERE id = $1`, userID))
 	if err != nil {
-		log.Printf("Erro ao cadastrar usuário: %v", err)
-		sendErrorResponse(w, "Erro ao cadastrar usuário: "+err.Error(), http.StatusInternalServerError)
+		log.Printf("Erro ao buscar usuário cadastrado: %v", err)
+		sendErrorResponse(w, "Erro ao buscar usuário cadastrado: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Buscar usuário recém-criado
-	err = database.DB.QueryRow(`
-		SELECT id, nome, email, password, cpf,
-			   TO_CHAR(data_nascimento, 'YYYY-MM-DD') as data_nascimento,
-			   perfil, avatar, created_at, updated_at
-		FROM users WHERE id=$1
-	`, user.ID).Scan(
-		&user.ID, &user.Nome, &user.Email, &user.Password,
-		&user.CPF, &user.DataNascimento, &user.Perfil, &user.Avatar,
-		&user.CreatedAt, &user.UpdatedAt,
-	)
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionRegister,
+		ActorID:    created.ID,
+		TargetType: audit.TargetUser,
+		TargetID:   created.ID,
+	})
+
+	sendAuthResponse(w, r, created, http.StatusCreated)
+}
 
+func recordLoginFailure(r *http.Request, email string, userID int, reason string) {
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionLoginFailure,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+		Metadata:   map[string]interface{}{"email": email, "motivo": reason},
+	})
+}
+
+// sendAuthResponse abre uma sessão para o dispositivo e responde com os dados do usuário
+func sendAuthResponse(w http.ResponseWriter, r *http.Request, user *models.User, status int) {
+	tokens, err := auth.StartSession(r, user)
 	if err != nil {
-		log.Printf("Erro ao buscar usuário cadastrado: %v", err)
-		sendErrorResponse(w, "Erro ao buscar usuário cadastrado: "+err.Error(), http.StatusInternalServerError)
+		log.Printf("Erro ao gerar token de sessão: %v", err)
+		sendErrorResponse(w, "Erro ao gerar sessão", http.StatusInternalServerError)
 		return
 	}
+	writeAuthResponse(w, user, tokens, status)
+}
 
-	w.WriteHeader(http.StatusCreated)
-	sendSuccessResponse(w, user.ToResponse())
+func writeAuthResponse(w http.ResponseWriter, user *models.User, tokens *auth.Tokens, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.AuthResponse{
+		UserResponse: user.ToResponse(),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	})
 }
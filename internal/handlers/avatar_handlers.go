@@ -3,13 +3,16 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
 	"smartpicks-backend/internal/database"
 	"smartpicks-backend/internal/models"
 )
 
+// UpdateAvatar troca o avatar do usuário logado. Um user_id no corpo é ignorado: o avatar é
+// sempre o da própria conta.
 func UpdateAvatar(w http.ResponseWriter, r *http.Request) {
 	var requestData struct {
-		UserID int    `json:"user_id"`
 		Avatar string `json:"avatar"`
 	}
 
@@ -18,11 +21,6 @@ func UpdateAvatar(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if requestData.UserID <= 0 {
-		sendErrorResponse(w, "ID do usuário é obrigatório", http.StatusBadRequest)
-		return
-	}
-
 	if requestData.Avatar != "" {
 		const maxBase64Len = 7 * 1024 * 1024 // ~5MB em Base64
 		if len(requestData.Avatar) > maxBase64Len {
@@ -36,85 +34,41 @@ func UpdateAvatar(w http.ResponseWriter, r *http.Request) {
 		avatarPtr = &requestData.Avatar
 	}
 
-	result, err := database.DB.Exec("UPDATE users SET avatar = $1 WHERE id = $2", avatarPtr, requestData.UserID)
-	if err != nil {
-		sendErrorResponse(w, "Erro na query SQL: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
+	current := auth.CurrentUser(r)
+	user, err := models.ScanUser(database.DB.QueryRow(`
+		UPDATE users SET avatar = $1 WHERE id = $2
+		RETURNING `+models.UserColumns, avatarPtr, current.ID))
 	if err != nil {
-		sendErrorResponse(w, "Erro ao verificar linhas afetadas: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if rowsAffected == 0 {
-		sendErrorResponse(w, "Usuário não encontrado ou não foi possível atualizar", http.StatusNotFound)
+		sendErrorResponse(w, "Erro ao atualizar avatar", http.StatusInternalServerError)
 		return
 	}
 
-	var user models.User
-	err = database.DB.QueryRow(`
-		SELECT id, nome, email, cpf,
-			   TO_CHAR(data_nascimento, 'YYYY-MM-DD') as data_nascimento,
-			   perfil, COALESCE(avatar, '') as avatar, created_at, updated_at 
-		FROM users WHERE id = $1`, requestData.UserID).
-		Scan(&user.ID, &user.Nome, &user.Email, &user.CPF,
-			&user.DataNascimento, &user.Perfil, &user.Avatar, &user.CreatedAt, &user.UpdatedAt)
-	if err != nil {
-		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
-		return
-	}
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionAvatarUpdate,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   map[string]interface{}{"tamanho": len(requestData.Avatar)},
+	})
 
 	sendSuccessResponse(w, map[string]interface{}{
-		"user":    user.ToResponse(),
+		"user":    user.ToResponseFor(current),
 		"message": "Avatar atualizado com sucesso",
 	})
 }
 
+// DeleteAvatar remove o avatar do usuário logado
 func DeleteAvatar(w http.ResponseWriter, r *http.Request) {
-	var requestData struct {
-		UserID int `json:"user_id"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		sendErrorResponse(w, "Dados inválidos fornecidos", http.StatusBadRequest)
-		return
-	}
-
-	if requestData.UserID <= 0 {
-		sendErrorResponse(w, "ID do usuário é obrigatório", http.StatusBadRequest)
-		return
-	}
-
-	var userExists bool
-	err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", requestData.UserID).Scan(&userExists)
-	if err != nil {
-		sendErrorResponse(w, "Erro ao verificar usuário: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if !userExists {
-		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
-		return
-	}
-
-	result, err := database.DB.Exec("UPDATE users SET avatar = NULL WHERE id = $1", requestData.UserID)
-	if err != nil {
-		sendErrorResponse(w, "Erro na query SQL: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		sendErrorResponse(w, "Erro ao verificar linhas afetadas: "+err.Error(), http.StatusInternalServerError)
+	userID := auth.CurrentUser(r).ID
+	if _, err := database.DB.Exec("UPDATE users SET avatar = NULL WHERE id = $1", userID); err != nil {
+		sendErrorResponse(w, "Erro ao remover avatar", http.StatusInternalServerError)
 		return
 	}
 
-	if rowsAffected == 0 {
-		sendErrorResponse(w, "Não foi possível remover o avatar", http.StatusInternalServerError)
-		return
-	}
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionAvatarDelete,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
 
 	sendSuccessResponse(w, map[string]string{
 		"message": "Avatar removido com sucesso",
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/catalog"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/listing"
+	"smartpicks-backend/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+var eventSortFields = map[string]listing.SortField{
+	"kickoff_at": {Column: "e.kickoff_at", Cast: "timestamptz"},
+	"created_at": {Column: "e.created_at", Cast: "timestamptz"},
+}
+
+// GetSports lista os esportes do catálogo
+func GetSports(w http.ResponseWriter, r *http.Request) {
+	sports, err := catalog.ListSports()
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar esportes", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"sports": sports, "total": len(sports)})
+}
+
+// GetCompetitions lista as competições (?sport_id=)
+func GetCompetitions(w http.ResponseWriter, r *http.Request) {
+	sportID, err := optionalInt(r.URL.Query().Get("sport_id"))
+	if err != nil {
+		sendErrorResponse(w, "sport_id inválido", http.StatusBadRequest)
+		return
+	}
+
+	competitions, err := catalog.ListCompetitions(sportID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar competições", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"competitions": competitions, "total": len(competitions)})
+}
+
+// GetTeams lista os times (?sport_id=&q=prefixo)
+func GetTeams(w http.ResponseWriter, r *http.Request) {
+	sportID, err := optionalInt(r.URL.Query().Get("sport_id"))
+	if err != nil {
+		sendErrorResponse(w, "sport_id inválido", http.StatusBadRequest)
+		return
+	}
+
+	teams, err := catalog.ListTeams(sportID, strings.TrimSpace(r.URL.Query().Get("q")))
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar times", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"teams": teams, "total": len(teams)})
+}
+
+// GetEvents lista eventos com filtros (sport_id, competition_id, team_id, status, from, to)
+// e paginação por cursor, ordenados por início
+func GetEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	page, err := listing.ParseParams(q, eventSortFields, "kickoff_at", false, "e.id")
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var f catalog.EventFilter
+	if f.SportID, err = optionalInt(q.Get("sport_id")); err != nil {
+		sendErrorResponse(w, "sport_id inválido", http.StatusBadRequest)
+		return
+	}
+	if f.CompetitionID, err = optionalInt(q.Get("competition_id")); err != nil {
+		sendErrorResponse(w, "competition_id inválido", http.StatusBadRequest)
+		return
+	}
+	if f.TeamID, err = optionalInt(q.Get("team_id")); err != nil {
+		sendErrorResponse(w, "team_id inválido", http.StatusBadRequest)
+		return
+	}
+	if f.Status = q.Get("status"); f.Status != "" && !models.IsValidStatusEvento(f.Status) {
+		sendErrorResponse(w, "status inválido. Use "+strings.Join(models.ValidStatusEvento, ", "), http.StatusBadRequest)
+		return
+	}
+	if f.From, err = optionalTime(q.Get("from")); err != nil {
+		sendErrorResponse(w, "from inválido. Use YYYY-MM-DD ou RFC3339", http.StatusBadRequest)
+		return
+	}
+	if f.To, err = optionalTime(q.Get("to")); err != nil {
+		sendErrorResponse(w, "to inválido. Use YYYY-MM-DD ou RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	events, hasMore, err := catalog.ListEvents(f, page)
+	if err != nil {
+		log.Printf("Erro ao listar eventos: %v", err)
+		sendErrorResponse(w, "Erro ao buscar eventos", http.StatusInternalServerError)
+		return
+	}
+
+	var nextCursor string
+	if hasMore {
+		last := events[len(events)-1]
+		value := last.KickoffAt
+		if page.Sort == "created_at" {
+			value = last.CreatedAt
+		}
+		nextCursor = listing.EncodeCursor(value.Format(time.RFC3339Nano), last.ID)
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"events":      events,
+		"next_cursor": nextCursor,
+	})
+}
+
+// GetEvent retorna um evento e a quantidade de palpites vinculados
+func GetEvent(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := catalog.GetEvent(id)
+	if err != nil {
+		sendCatalogError(w, err, "Evento")
+		return
+	}
+
+	var totalPalpites int
+	database.DB.QueryRow("SELECT COUNT(*) FROM palpites WHERE event_id = $1 AND published_at <= NOW()", id).Scan(&totalPalpites)
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"event":          event,
+		"total_palpites": totalPalpites,
+		"accepts_picks":  event.AcceptsPicks(time.Now()),
+	})
+}
+
+// SaveSport cria (POST /api/admin/sports) ou atualiza (PUT /api/admin/sports/{id}) um esporte
+func SaveSport(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeCatalogItem(w, r, false)
+	if !ok {
+		return
+	}
+
+	sport, err := catalog.SaveSport(id, req.Nome, req.Slug)
+	if err != nil {
+		sendCatalogError(w, err, "Esporte")
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"sport": sport, "message": "Esporte salvo com sucesso"})
+}
+
+// SaveCompetition cria ou atualiza uma competição
+func SaveCompetition(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeCatalogItem(w, r, true)
+	if !ok {
+		return
+	}
+
+	competition, err := catalog.SaveCompetition(id, req)
+	if err != nil {
+		sendCatalogError(w, err, "Competição")
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"competition": competition, "message": "Competição salva com sucesso"})
+}
+
+// SaveTeam cria ou atualiza um time
+func SaveTeam(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := decodeCatalogItem(w, r, true)
+	if !ok {
+		return
+	}
+
+	team, err := catalog.SaveTeam(id, req)
+	if err != nil {
+		sendCatalogError(w, err, "Time")
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"team": team, "message": "Time salvo com sucesso"})
+}
+
+// SaveEvent cria ou atualiza um evento
+func SaveEvent(w http.ResponseWriter, r *http.Request) {
+	var id int
+	if _, hasID := mux.Vars(r)["id"]; hasID {
+		var ok bool
+		if id, ok = catalogID(w, r); !ok {
+			return
+		}
+	}
+
+	var req models.EventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.CompetitionID <= 0 || req.HomeTeamID <= 0 || req.AwayTeamID <= 0 || req.KickoffAt.IsZero() {
+		sendErrorResponse(w, "competition_id, home_team_id, away_team_id e kickoff_at são obrigatórios", http.StatusBadRequest)
+		return
+	}
+	if req.HomeTeamID == req.AwayTeamID {
+		sendErrorResponse(w, "Mandante e visitante devem ser times diferentes", http.StatusBadRequest)
+		return
+	}
+	if req.Status != "" && !models.IsValidStatusEvento(req.Status) {
+		sendErrorResponse(w, "status inválido. Use "+strings.Join(models.ValidStatusEvento, ", "), http.StatusBadRequest)
+		return
+	}
+
+	event, err := catalog.SaveEvent(id, req)
+	if err != nil {
+		sendCatalogError(w, err, "Evento")
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"event": event, "message": "Evento salvo com sucesso"})
+}
+
+// ImportCatalog importa eventos em lote: JSON {"events": [...]} no corpo da requisição
+func ImportCatalog(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Events []models.CatalogImportRow `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body.Events) == 0 {
+		sendErrorResponse(w, "Nenhum evento informado", http.StatusBadRequest)
+		return
+	}
+
+	result, err := catalog.Import(body.Events)
+	if err != nil {
+		sendErrorResponse(w, "Erro na importação: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{
+		"created": result.Created,
+		"updated": result.Updated,
+		"message": "Importação concluída",
+	})
+}
+
+// DeleteCatalogItem remove um registro do catálogo; a tabela vem da rota registrada
+func DeleteCatalogItem(table, label string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := catalogID(w, r)
+		if !ok {
+			return
+		}
+		if err := catalog.Delete(table, id); err != nil {
+			sendCatalogError(w, err, label)
+			return
+		}
+		sendSuccessResponse(w, map[string]string{"message": label + " removido(a) com sucesso"})
+	}
+}
+
+// decodeCatalogItem lê o {id} opcional da rota e o corpo de esportes, competições e times
+func decodeCatalogItem(w http.ResponseWriter, r *http.Request, needsSport bool) (int, models.CatalogItemRequest, bool) {
+	var id int
+	var req models.CatalogItemRequest
+	if _, hasID := mux.Vars(r)["id"]; hasID {
+		var ok bool
+		if id, ok = catalogID(w, r); !ok {
+			return 0, req, false
+		}
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return 0, req, false
+	}
+	req.Nome = strings.TrimSpace(req.Nome)
+	if req.Nome == "" {
+		sendErrorResponse(w, "nome é obrigatório", http.StatusBadRequest)
+		return 0, req, false
+	}
+	if needsSport && req.SportID <= 0 {
+		sendErrorResponse(w, "sport_id é obrigatório", http.StatusBadRequest)
+		return 0, req, false
+	}
+	if req.Slug == "" {
+		req.Slug = models.Slugify(req.Nome)
+	} else {
+		req.Slug = models.Slugify(req.Slug)
+	}
+	return id, req, true
+}
+
+func catalogID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		sendErrorResponse(w, "ID inválido", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// sendCatalogError traduz erros do catálogo: não encontrado (404), slug duplicado ou
+// registro ainda referenciado (409)
+func sendCatalogError(w http.ResponseWriter, err error, label string) {
+	if errors.Is(err, catalog.ErrUnknownTeam) {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, catalog.ErrNotFound) {
+		sendErrorResponse(w, label+" não encontrado(a)", http.StatusNotFound)
+		return
+	}
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23505":
+			sendErrorResponse(w, "Já existe um registro com este slug ou referência externa", http.StatusConflict)
+			return
+		case "23503":
+			sendErrorResponse(w, label+" está em uso ou referencia um registro inexistente", http.StatusConflict)
+			return
+		}
+	}
+	log.Printf("Erro no catálogo (%s): %v", label, err)
+	sendErrorResponse(w, "Erro ao processar "+strings.ToLower(label), http.StatusInternalServerError)
+}
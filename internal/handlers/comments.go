@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/listing"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/subscriptions"
+
+	"github.com/gorilla/mux"
+)
+
+const commentColumns = `c.id, c.palpite_id, c.user_id, u.nome, u.avatar, c.texto, c.created_at`
+
+var commentSortFields = map[string]listing.SortField{
+	"created_at": {Column: "c.created_at", Cast: "timestamptz"},
+}
+
+func scanComment(row interface{ Scan(...interface{}) error }) (*models.Comment, error) {
+	var c models.Comment
+	err := row.Scan(&c.ID, &c.PalpiteID, &c.UserID, &c.AutorNome, &c.AutorAvatar, &c.Texto, &c.CreatedAt)
+	return &c, err
+}
+
+// GetComments lista os comentários do palpite, mais antigos primeiro
+func GetComments(w http.ResponseWriter, r *http.Request) {
+	palpite, ok := loadCommentablePalpite(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := listing.ParseParams(r.URL.Query(), commentSortFields, "created_at", false, "c.id")
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := listing.New("SELECT "+commentColumns+" FROM palpite_comments c JOIN users u ON u.id = c.user_id").
+		Where("c.palpite_id = ?", palpite.ID)
+	page.Apply(query)
+	q, args := query.SQL()
+	rows, err := database.DB.Query(q, args...)
+	if err != nil {
+		log.Printf("Erro ao listar comentários do palpite %d: %v", palpite.ID, err)
+		sendErrorResponse(w, "Erro ao buscar comentários", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			sendErrorResponse(w, "Erro ao buscar comentários", http.StatusInternalServerError)
+			return
+		}
+		comments = append(comments, *c)
+	}
+	if err := rows.Err(); err != nil {
+		sendErrorResponse(w, "Erro ao buscar comentários", http.StatusInternalServerError)
+		return
+	}
+
+	var nextCursor string
+	if page.HasMore(len(comments)) {
+		comments = comments[:page.Limit]
+		last := comments[len(comments)-1]
+		nextCursor = listing.EncodeCursor(last.CreatedAt.Format(time.RFC3339Nano), last.ID)
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"comments":    comments,
+		"next_cursor": nextCursor,
+	})
+}
+
+// PostComment comenta em um palpite: {texto}. O autor do palpite é notificado pelo barramento de eventos.
+func PostComment(w http.ResponseWriter, r *http.Request) {
+	palpite, ok := loadCommentablePalpite(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	texto := strings.TrimSpace(req.Texto)
+	if texto == "" {
+		sendErrorResponse(w, "texto é obrigatório", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(texto) > models.MaxComentarioLen {
+		sendErrorResponse(w, "texto deve ter no máximo "+strconv.Itoa(models.MaxComentarioLen)+" caracteres", http.StatusBadRequest)
+		return
+	}
+
+	user := auth.CurrentUser(r)
+	var id int
+	err := database.DB.QueryRow(`
+		INSERT INTO palpite_comments (palpite_id, user_id, texto) VALUES ($1, $2, $3)
+		RETURNING id`, palpite.ID, user.ID, texto).Scan(&id)
+	if err != nil {
+		log.Printf("Erro ao salvar comentário no palpite %d: %v", palpite.ID, err)
+		sendErrorResponse(w, "Erro ao salvar comentário", http.StatusInternalServerError)
+		return
+	}
+	comment, err := scanComment(database.DB.QueryRow(
+		"SELECT "+commentColumns+" FROM palpite_comments c JOIN users u ON u.id = c.user_id WHERE c.id = $1", id))
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar comentário", http.StatusInternalServerError)
+		return
+	}
+
+	events.Publish(events.CommentCreated{
+		CommentID: comment.ID,
+		PalpiteID: palpite.ID,
+		OwnerID:   palpite.UserID,
+		AuthorID:  user.ID,
+		Texto:     comment.Texto,
+	})
+
+	w.WriteHeader(http.StatusCreated)
+	sendSuccessResponse(w, map[string]interface{}{
+		"comment": comment,
+		"message": "Comentário publicado",
+	})
+}
+
+// DeleteComment remove um comentário (autor do comentário, autor do palpite ou admin)
+func DeleteComment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		sendErrorResponse(w, "ID do comentário inválido", http.StatusBadRequest)
+		return
+	}
+
+	var authorID, ownerID int
+	err = database.DB.QueryRow(`
+		SELECT c.user_id, p.user_id FROM palpite_comments c JOIN palpites p ON p.id = c.palpite_id
+		WHERE c.id = $1`, id).Scan(&authorID, &ownerID)
+	if err == sql.ErrNoRows {
+		sendErrorResponse(w, "Comentário não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar comentário", http.StatusInternalServerError)
+		return
+	}
+
+	user := auth.CurrentUser(r)
+	if user.ID != authorID && user.ID != ownerID && !user.IsAdmin() {
+		sendErrorResponse(w, "Apenas o autor do comentário, o autor do palpite ou um administrador pode removê-lo", http.StatusForbidden)
+		return
+	}
+
+	if _, err := database.DB.Exec("DELETE FROM palpite_comments WHERE id = $1", id); err != nil {
+		sendErrorResponse(w, "Erro ao remover comentário", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]string{"message": "Comentário removido"})
+}
+
+// loadCommentablePalpite carrega o palpite da rota e exige que o usuário tenha acesso ao conteúdo
+// completo: comentários de palpites bloqueados também ficam bloqueados
+func loadCommentablePalpite(w http.ResponseWriter, r *http.Request) (*models.Palpite, bool) {
+	palpite, ok := loadPalpite(w, r)
+	if !ok {
+		return nil, false
+	}
+	user := auth.CurrentUser(r)
+	isAuthor := user != nil && (user.ID == palpite.UserID || user.IsAdmin())
+	if !palpite.IsPublished(time.Now()) && !isAuthor {
+		sendErrorResponse(w, "Palpite não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	if !subscriptions.NewViewer(user).CanView(palpite.UserID, palpite.Visibilidade) {
+		sendErrorResponse(w, "Comentários disponíveis apenas para quem tem acesso ao palpite", http.StatusForbidden)
+		return nil, false
+	}
+	return palpite, true
+}
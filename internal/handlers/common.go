@@ -25,6 +25,7 @@ func userExists(field, value string) bool {
 	var count int
 	allowedFields := map[string]bool{
 		"email":    true,
+		"cpf":      true,
 		"username": true,
 		"id":       true,
 	}
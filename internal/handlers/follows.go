@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/follows"
+
+	"github.com/gorilla/mux"
+)
+
+// FollowUser segue (POST) o usuário da rota
+func FollowUser(w http.ResponseWriter, r *http.Request) {
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+	if target.DeletedAt != nil {
+		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+
+	if _, err := follows.Follow(auth.CurrentUser(r).ID, target.ID); err != nil {
+		if errors.Is(err, follows.ErrSelfFollow) {
+			sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sendErrorResponse(w, "Erro ao seguir usuário", http.StatusInternalServerError)
+		return
+	}
+	sendFollowStats(w, target.ID, true, "Agora você segue "+target.Nome)
+}
+
+// UnfollowUser deixa de seguir (DELETE) o usuário da rota
+func UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := follows.Unfollow(auth.CurrentUser(r).ID, target.ID); err != nil {
+		sendErrorResponse(w, "Erro ao deixar de seguir usuário", http.StatusInternalServerError)
+		return
+	}
+	sendFollowStats(w, target.ID, false, "Você deixou de seguir "+target.Nome)
+}
+
+// GetFollowStats retorna seguidores/seguindo do usuário e se o usuário logado o segue
+func GetFollowStats(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		sendErrorResponse(w, "ID do usuário inválido", http.StatusBadRequest)
+		return
+	}
+
+	following := false
+	if viewer := auth.CurrentUser(r); viewer != nil {
+		following, _ = follows.IsFollowing(viewer.ID, id)
+	}
+	sendFollowStats(w, id, following, "")
+}
+
+func sendFollowStats(w http.ResponseWriter, userID int, following bool, message string) {
+	followers, followingCount, err := follows.Counts(userID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar seguidores", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"user_id":    userID,
+		"seguidores": followers,
+		"seguindo":   followingCount,
+		"voce_segue": following,
+	}
+	if message != "" {
+		response["message"] = message
+	}
+	sendSuccessResponse(w, response)
+}
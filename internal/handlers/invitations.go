@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+const invitationColumns = `id, email, perfil, invited_by, expires_at, accepted_at, accepted_by, revoked_at, created_at`
+
+// CreateInvitation convida um email a receber perfil de administrador.
+// O convite é aceito pelo dono do email depois de se cadastrar normalmente.
+func CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	admin := auth.CurrentUser(r)
+
+	var req models.CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if !models.IsValidEmail(email) {
+		sendErrorResponse(w, "Email inválido", http.StatusBadRequest)
+		return
+	}
+
+	if req.Perfil == "" {
+		req.Perfil = models.PERFIL_ADMIN
+	}
+	if req.Perfil != models.PERFIL_ADMIN {
+		sendErrorResponse(w, "Convites são usados apenas para conceder perfil 'admin'", http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.GenerateSecureToken(32)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao gerar convite", http.StatusInternalServerError)
+		return
+	}
+
+	// Um novo convite substitui os pendentes para o mesmo email
+	if _, err := database.DB.Exec(`
+		UPDATE admin_invitations SET revoked_at = CURRENT_TIMESTAMP
+		WHERE LOWER(email) = LOWER($1) AND accepted_at IS NULL AND revoked_at IS NULL`, email); err != nil {
+		sendErrorResponse(w, "Erro ao criar convite", http.StatusInternalServerError)
+		return
+	}
+
+	invitation, err := scanInvitation(database.DB.QueryRow(`
+		INSERT INTO admin_invitations (email, perfil, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+invitationColumns,
+		email, req.Perfil, auth.HashToken(token), admin.ID, time.Now().Add(invitationTTL)))
+	if err != nil {
+		log.Printf("Erro ao criar convite: %v", err)
+		sendErrorResponse(w, "Erro ao criar convite", http.StatusInternalServerError)
+		return
+	}
+
+	body := fmt.Sprintf("Olá!\n\n%s convidou você para administrar o SmartPicks. Entre com sua conta (ou cadastre-se com este email) e acesse:\n%s/aceitar-convite?token=%s\n\nO convite expira em 7 dias.",
+		admin.Nome, services.FrontendURL(), token)
+	if _, err := jobs.Enqueue(jobs.SendEmail{To: email, Subject: "Convite para administrador do SmartPicks", Body: body}); err != nil {
+		log.Printf("Erro ao enfileirar convite %d: %v", invitation.ID, err)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	sendSuccessResponse(w, map[string]interface{}{
+		"invitation": invitation,
+		"message":    "Convite enviado com sucesso",
+	})
+}
+
+func GetInvitations(w http.ResponseWriter, r *http.Request) {
+	rows, err := database.DB.Query(`
+		SELECT ` + invitationColumns + `
+		FROM admin_invitations
+		ORDER BY created_at DESC`)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar convites", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	invitations := []models.AdminInvitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			sendErrorResponse(w, "Erro ao processar convites", http.StatusInternalServerError)
+			return
+		}
+		invitations = append(invitations, *invitation)
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"invitations": invitations,
+		"total":       len(invitations),
+		"message":     "Convites listados com sucesso",
+	})
+}
+
+func RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendErrorResponse(w, "ID do convite inválido", http.StatusBadRequest)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE admin_invitations SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`, id)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao revogar convite", http.StatusInternalServerError)
+		return
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		sendErrorResponse(w, "Convite não encontrado ou já utilizado", http.StatusNotFound)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]string{
+		"message": "Convite revogado com sucesso",
+	})
+}
+
+// AcceptInvitation promove o usuário logado, desde que o email da conta seja o email convidado
+func AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var req models.AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		sendErrorResponse(w, "Token é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	invitation, err := scanInvitation(database.DB.QueryRow(`
+		SELECT `+invitationColumns+`
+		FROM admin_invitations WHERE token_hash = $1`, auth.HashToken(req.Token)))
+	if err == sql.ErrNoRows {
+		sendErrorResponse(w, "Convite inválido", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar convite", http.StatusInternalServerError)
+		return
+	}
+
+	if !invitation.IsPending(time.Now()) {
+		sendErrorResponse(w, "Convite expirado, revogado ou já utilizado", http.StatusGone)
+		return
+	}
+
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		sendErrorResponse(w, "Este convite foi enviado para outro email", http.StatusForbidden)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		sendErrorResponse(w, "Erro ao aceitar convite", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE admin_invitations SET accepted_at = CURRENT_TIMESTAMP, accepted_by = $1
+		WHERE id = $2 AND accepted_at IS NULL AND revoked_at IS NULL`, user.ID, invitation.ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao aceitar convite", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		sendErrorResponse(w, "Convite expirado, revogado ou já utilizado", http.StatusGone)
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE users SET perfil = $1 WHERE id = $2", invitation.Perfil, user.ID); err != nil {
+		sendErrorResponse(w, "Erro ao aceitar convite", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendErrorResponse(w, "Erro ao aceitar convite", http.StatusInternalServerError)
+		return
+	}
+
+	inviter := 0 // sem autor se quem convidou já foi removido
+	if invitation.InvitedBy != nil {
+		inviter = *invitation.InvitedBy
+	}
+	recordAdminAction(r, inviter, user.ID, models.ACAO_ALTERAR_PERFIL, nil, map[string]interface{}{
+		"de":      user.Perfil,
+		"para":    invitation.Perfil,
+		"convite": invitation.ID,
+	})
+
+	// O perfil vai no token de acesso: emite um novo para a mesma sessão
+	user.Perfil = invitation.Perfil
+	token, err := auth.IssueToken(user.ID, user.Perfil, auth.SessionID(r))
+	if err != nil {
+		log.Printf("Erro ao gerar token de sessão: %v", err)
+		sendErrorResponse(w, "Erro ao gerar sessão", http.StatusInternalServerError)
+		return
+	}
+	writeAuthResponse(w, user, &auth.Tokens{AccessToken: token, ExpiresIn: int(auth.AccessTokenTTL().Seconds())}, http.StatusOK)
+}
+
+func scanInvitation(row models.RowScanner) (*models.AdminInvitation, error) {
+	var i models.AdminInvitation
+	err := row.Scan(&i.ID, &i.Email, &i.Perfil, &i.InvitedBy, &i.ExpiresAt,
+		&i.AcceptedAt, &i.AcceptedBy, &i.RevokedAt, &i.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/listing"
+)
+
+const (
+	// cronJobsLimit e cronJobsBudget limitam uma execução do cron para caber no tempo máximo
+	// de uma função serverless
+	cronJobsLimit  = 100
+	cronJobsBudget = 45 * time.Second
+)
+
+var jobSortFields = map[string]listing.SortField{
+	"created_at": {Column: "created_at", Cast: "timestamptz"},
+	"run_at":     {Column: "run_at", Cast: "timestamptz"},
+}
+
+// RunJobs executa os jobs pendentes até esvaziar a fila ou atingir o limite (?limit=). É a
+// variante serverless dos workers de main.go, chamada pelo cron da Vercel.
+func RunJobs(w http.ResponseWriter, r *http.Request) {
+	limit := cronJobsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			sendErrorResponse(w, "limit inválido", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, cronJobsLimit)
+	}
+
+	report, err := jobs.Drain(r.Context(), jobs.WorkerName("cron"), limit, cronJobsBudget)
+	if err != nil {
+		log.Printf("Erro ao executar jobs: %v", err)
+		sendErrorResponse(w, "Erro ao executar jobs", http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"report":  report,
+		"message": "Jobs executados",
+	})
+}
+
+// GetJobs lista os jobs (mais recentes primeiro) com filtros status e tipo, e a contagem por status
+func GetJobs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	page, err := listing.ParseParams(q, jobSortFields, "created_at", true, "id")
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f := jobs.Filter{Status: q.Get("status"), Tipo: q.Get("tipo")}
+	if f.Status != "" && !jobs.IsValidStatus(f.Status) {
+		sendErrorResponse(w, "status inválido. Use "+strings.Join(jobs.ValidStatus, ", "), http.StatusBadRequest)
+		return
+	}
+
+	list, hasMore, err := jobs.List(f, page)
+	if err != nil {
+		log.Printf("Erro ao listar jobs: %v", err)
+		sendErrorResponse(w, "Erro ao buscar jobs", http.StatusInternalServerError)
+		return
+	}
+	counts, err := jobs.Counts()
+	if err != nil {
+		log.Printf("Erro ao contar jobs: %v", err)
+		sendErrorResponse(w, "Erro ao buscar jobs", http.StatusInternalServerError)
+		return
+	}
+
+	var nextCursor string
+	if hasMore {
+		last := list[len(list)-1]
+		value := last.CreatedAt
+		if page.Sort == "run_at" {
+			value = last.RunAt
+		}
+		nextCursor = listing.EncodeCursor(value.Format(time.RFC3339Nano), last.ID)
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"jobs":        list,
+		"counts":      counts,
+		"next_cursor": nextCursor,
+	})
+}
+
+func GetJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := jobs.Get(id)
+	if err != nil {
+		sendJobError(w, err)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"job": job})
+}
+
+// RetryJob devolve à fila um job da dead-letter (ou reexecuta um concluído)
+func RetryJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := jobs.Retry(id)
+	if err != nil {
+		sendJobError(w, err)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionJobRetry,
+		TargetType: audit.TargetJob,
+		TargetID:   job.ID,
+		Metadata:   map[string]interface{}{"tipo": job.Tipo},
+	})
+	sendSuccessResponse(w, map[string]interface{}{
+		"job":     job,
+		"message": "Job reenfileirado",
+	})
+}
+
+func sendJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, jobs.ErrNotRetryable):
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Erro na fila de jobs: %v", err)
+		sendErrorResponse(w, "Erro ao processar job", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/listing"
+	"smartpicks-backend/internal/notifications"
+)
+
+var notificationSortFields = map[string]listing.SortField{
+	"created_at": {Column: "n.created_at", Cast: "timestamptz"},
+}
+
+// GetNotifications lista as notificações do usuário (?unread=true para apenas as não lidas),
+// mais recentes primeiro, junto com o total de não lidas
+func GetNotifications(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := listing.ParseParams(q, notificationSortFields, "created_at", true, "n.id")
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.CurrentUser(r)
+	list, hasMore, err := notifications.List(user.ID, q.Get("unread") == "true", page)
+	if err != nil {
+		log.Printf("Erro ao listar notificações do usuário %d: %v", user.ID, err)
+		sendErrorResponse(w, "Erro ao buscar notificações", http.StatusInternalServerError)
+		return
+	}
+	unread, err := notifications.UnreadCount(user.ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar notificações", http.StatusInternalServerError)
+		return
+	}
+
+	var nextCursor string
+	if hasMore {
+		last := list[len(list)-1]
+		nextCursor = listing.EncodeCursor(last.CreatedAt.Format(time.RFC3339Nano), last.ID)
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"notifications": list,
+		"unread_count":  unread,
+		"next_cursor":   nextCursor,
+	})
+}
+
+func GetUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	unread, err := notifications.UnreadCount(auth.CurrentUser(r).ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao contar notificações", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"unread_count": unread})
+}
+
+// MarkNotificationRead marca uma notificação como lida
+func MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+
+	user := auth.CurrentUser(r)
+	n, err := notifications.MarkRead(user.ID, id)
+	if errors.Is(err, notifications.ErrNotFound) {
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao marcar notificação", http.StatusInternalServerError)
+		return
+	}
+	unread, _ := notifications.UnreadCount(user.ID)
+	sendSuccessResponse(w, map[string]interface{}{
+		"notification": n,
+		"unread_count": unread,
+	})
+}
+
+// MarkAllNotificationsRead marca todas as notificações do usuário como lidas
+func MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	n, err := notifications.MarkAllRead(auth.CurrentUser(r).ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao marcar notificações", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{
+		"marked":       n,
+		"unread_count": 0,
+		"message":      "Notificações marcadas como lidas",
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strings"
+
+	"smartpicks-backend/internal/odds"
+)
+
+// ConvertOdds converte uma odd para todos os formatos (?odd=5/2) e, com ?odds=2.10,3.40,3.60
+// (um valor por resultado do mercado), calcula o overround e as probabilidades sem margem
+func ConvertOdds(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	response := map[string]interface{}{}
+
+	if v := q.Get("odd"); v != "" {
+		decimal, err := odds.Parse(v)
+		if err != nil {
+			sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response["odd"] = oddSummary(decimal)
+	}
+
+	if v := q.Get("odds"); v != "" {
+		var decimals []float64
+		var outcomes []map[string]interface{}
+		for _, part := range strings.Split(v, ",") {
+			decimal, err := odds.Parse(part)
+			if err != nil {
+				sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			decimals = append(decimals, decimal)
+		}
+		if len(decimals) < 2 {
+			sendErrorResponse(w, "Informe ao menos duas odds em 'odds' para calcular o overround", http.StatusBadRequest)
+			return
+		}
+
+		overround := odds.Overround(decimals...)
+		for _, d := range decimals {
+			outcome := oddSummary(d)
+			outcome["probabilidade_justa"] = roundProbability(odds.ImpliedProbability(d) / (1 + overround))
+			outcomes = append(outcomes, outcome)
+		}
+		response["mercado"] = map[string]interface{}{
+			"overround":  roundProbability(overround),
+			"resultados": outcomes,
+		}
+	}
+
+	if len(response) == 0 {
+		sendErrorResponse(w, "Informe 'odd' ou 'odds'", http.StatusBadRequest)
+		return
+	}
+	sendSuccessResponse(w, response)
+}
+
+func oddSummary(decimal float64) map[string]interface{} {
+	return map[string]interface{}{
+		odds.FORMATO_DECIMAL:      decimal,
+		odds.FORMATO_FRACIONARIO:  odds.Format(decimal, odds.FORMATO_FRACIONARIO),
+		odds.FORMATO_AMERICANO:    odds.Format(decimal, odds.FORMATO_AMERICANO),
+		"probabilidade_implicita": roundProbability(odds.ImpliedProbability(decimal)),
+	}
+}
+
+func roundProbability(p float64) float64 {
+	return math.Round(p*10000) / 10000
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/oidc"
+
+	"github.com/gorilla/mux"
+)
+
+// GetLoginProviders lista os provedores de login social disponíveis
+func GetLoginProviders(w http.ResponseWriter, r *http.Request) {
+	providers := []string{}
+	for _, p := range oidc.Providers() {
+		providers = append(providers, p.Name)
+	}
+	sendSuccessResponse(w, map[string]interface{}{
+		"providers": providers,
+		"firebase":  oidc.FirebaseProjectID() != "",
+	})
+}
+
+// StartOIDCLogin devolve a URL de autorização do provedor; o frontend redireciona o usuário
+// para ela e, na volta, envia code e state para OIDCCallback
+func StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	p, err := oidc.GetProvider(mux.Vars(r)["provider"])
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	authURL, err := oidc.Begin(r.Context(), p)
+	if err != nil {
+		log.Printf("Erro ao iniciar login com %s: %v", p.Name, err)
+		sendErrorResponse(w, "Não foi possível iniciar o login com o provedor", http.StatusBadGateway)
+		return
+	}
+	sendSuccessResponse(w, map[string]string{"authorization_url": authURL})
+}
+
+// OIDCCallback conclui o login: troca o code no provedor, valida o ID token e emite a sessão
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code  string `json:"code"`
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" || req.State == "" {
+		sendErrorResponse(w, "Code e state são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	identity, err := oidc.Complete(r.Context(), req.State, req.Code)
+	if err != nil {
+		sendOIDCError(w, r, "", err)
+		return
+	}
+	loginWithIdentity(w, r, identity)
+}
+
+// FirebaseLogin troca um ID token do Firebase Authentication por uma sessão SmartPicks
+func FirebaseLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IDToken == "" {
+		sendErrorResponse(w, "id_token é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	identity, err := oidc.VerifyFirebaseToken(r.Context(), req.IDToken)
+	if err != nil {
+		sendOIDCError(w, r, "", err)
+		return
+	}
+	loginWithIdentity(w, r, identity)
+}
+
+func loginWithIdentity(w http.ResponseWriter, r *http.Request, identity *oidc.Identity) {
+	userID, linked, err := oidc.Resolve(r.Context(), identity)
+	if err != nil {
+		sendOIDCError(w, r, identity.Email, err)
+		return
+	}
+
+	user, err := models.ScanUser(database.DB.QueryRow(`
+		SELECT `+models.UserColumns+`
+		FROM users WHERE id = $1 AND deleted_at IS NULL`, userID))
+	if err != nil {
+		log.Printf("Erro ao buscar usuário %d do login social: %v", userID, err)
+		sendErrorResponse(w, "Conta indisponível", http.StatusUnauthorized)
+		return
+	}
+
+	if linked {
+		audit.Record(r, audit.Event{
+			Action:     audit.ActionIdentityLink,
+			ActorID:    user.ID,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Metadata:   map[string]interface{}{"provedor": identity.Provider, "email": identity.Email},
+		})
+	}
+
+	if !loginAllowed(w, r, user, identity.Email) {
+		return
+	}
+
+	completeLogin(w, r, user, map[string]interface{}{"provedor": identity.Provider})
+}
+
+func sendOIDCError(w http.ResponseWriter, r *http.Request, email string, err error) {
+	var exchangeErr *oidc.ExchangeError
+	switch {
+	case errors.Is(err, oidc.ErrInvalidState):
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &exchangeErr):
+		recordLoginFailure(r, email, 0, "code_recusado")
+		sendErrorResponse(w, "O provedor recusou o login; tente novamente", http.StatusUnauthorized)
+	case errors.Is(err, oidc.ErrInvalidToken):
+		log.Printf("ID token recusado: %v", err)
+		recordLoginFailure(r, email, 0, "id_token_invalido")
+		sendErrorResponse(w, oidc.ErrInvalidToken.Error(), http.StatusUnauthorized)
+	case errors.Is(err, oidc.ErrEmailNotVerified):
+		recordLoginFailure(r, email, 0, "email_nao_verificado")
+		sendErrorResponse(w, "Confirme seu email no provedor antes de entrar", http.StatusForbidden)
+	case errors.Is(err, oidc.ErrNoAccount):
+		recordLoginFailure(r, email, 0, "usuario_nao_encontrado")
+		sendErrorResponse(w, "Nenhuma conta com este email. Cadastre-se e depois entre com o mesmo email", http.StatusNotFound)
+	case errors.Is(err, oidc.ErrUnknownProvider):
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, oidc.ErrNotConfigured):
+		sendErrorResponse(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		log.Printf("Erro no login social: %v", err)
+		sendErrorResponse(w, "Não foi possível concluir o login com o provedor", http.StatusBadGateway)
+	}
+}
+
+// GetMyIdentities lista as contas externas vinculadas ao usuário
+func GetMyIdentities(w http.ResponseWriter, r *http.Request) {
+	list, err := oidc.ListByUser(auth.CurrentUser(r).ID)
+	if err != nil {
+		log.Printf("Erro ao listar vínculos: %v", err)
+		sendErrorResponse(w, "Erro ao buscar contas vinculadas", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"identities": list, "total": len(list)})
+}
+
+// DeleteMyIdentity desfaz o vínculo com a conta externa; o próximo login por ela vincula de novo
+// se o email ainda for o mesmo
+func DeleteMyIdentity(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+	user := auth.CurrentUser(r)
+	identity, err := oidc.Unlink(user.ID, id)
+	if errors.Is(err, oidc.ErrNotFound) {
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao desfazer vínculo %d: %v", id, err)
+		sendErrorResponse(w, "Erro ao remover conta vinculada", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionIdentityUnlink,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   map[string]interface{}{"provedor": identity.Provider, "email": identity.Email},
+	})
+	sendSuccessResponse(w, map[string]string{"message": "Conta desvinculada"})
+}
@@ -2,12 +2,30 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/catalog"
+	"smartpicks-backend/internal/compliance"
 	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/listing"
 	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/odds"
+	"smartpicks-backend/internal/subscriptions"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
 )
 
+var palpiteSortFields = map[string]listing.SortField{
+	"created_at": {Column: "created_at", Cast: "timestamptz"},
+}
+
 // PostPalpite @Summary Criar um novo palpite
 // @Description Cria um novo palpite no sistema
 // @Tags Palpites
@@ -16,6 +34,7 @@ import (
 // @Param palpite body models.CreatePalpiteRequest true "Dados do palpite"
 // @Success 201 {object} map[string]interface{} "Palpite criado com sucesso"
 // @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 409 {object} map[string]string "Evento já iniciado"
 // @Failure 500 {object} map[string]string "Erro interno do servidor"
 // @Router /palpites [post]
 func PostPalpite(w http.ResponseWriter, r *http.Request) {
@@ -31,28 +50,69 @@ func PostPalpite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validações
+	// O autor é o usuário da sessão; apenas admins podem publicar em nome de outro usuário
+	user := auth.CurrentUser(r)
 	if req.UserID == 0 {
-		sendErrorResponse(w, "user_id é obrigatório", http.StatusBadRequest)
+		req.UserID = user.ID
+	}
+	if req.UserID != user.ID && !user.IsAdmin() {
+		sendErrorResponse(w, "Não é permitido criar palpites para outro usuário", http.StatusForbidden)
 		return
 	}
 
+	// Validações
 	if req.ImgURL == "" {
 		sendErrorResponse(w, "img_url é obrigatório", http.StatusBadRequest)
 		return
 	}
 
+	if err := req.PalpiteMarket.Validate(); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Visibilidade != nil && !models.IsValidVisibilidade(*req.Visibilidade) {
+		sendErrorResponse(w, "visibilidade inválida. Use publico, seguidores ou assinantes", http.StatusBadRequest)
+		return
+	}
+	if req.Mercado != nil && req.EventID == nil {
+		sendErrorResponse(w, "event_id é obrigatório quando o mercado é informado", http.StatusBadRequest)
+		return
+	}
+	var event *models.Event
+	if req.EventID != nil {
+		var ok bool
+		if event, ok = ensureEventOpen(w, *req.EventID); !ok {
+			return
+		}
+	}
+
 	palpite := req.ToPalpite()
+	if !validateSchedule(w, &palpite, event) {
+		return
+	}
 
 	// Inserir no banco
 	err = database.DB.QueryRow(`
-		INSERT INTO palpites (user_id, titulo, img_url, link, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO palpites (user_id, titulo, img_url, link, event_id, mercado, selecao, linha, odd, stake,
+			status, visibility, is_draft, publish_at, published_at, reveal_at_kickoff, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING id`,
 		palpite.UserID,
 		palpite.Titulo,
 		palpite.ImgURL,
 		palpite.Link,
+		palpite.EventID,
+		palpite.Mercado,
+		palpite.Selecao,
+		palpite.Linha,
+		palpite.Odd,
+		palpite.Stake,
+		palpite.Status,
+		palpite.Visibilidade,
+		palpite.Rascunho,
+		palpite.PublishAt,
+		palpite.PublishedAt,
+		palpite.RevelarNoInicio,
 		palpite.CreatedAt,
 		palpite.UpdatedAt,
 	).Scan(&palpite.ID)
@@ -62,9 +122,357 @@ func PostPalpite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := palpite.ToResponse()
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionPalpiteCreate,
+		TargetType: audit.TargetPalpite,
+		TargetID:   palpite.ID,
+		Metadata:   map[string]interface{}{"user_id": palpite.UserID, "titulo": palpite.Titulo, "event_id": palpite.EventID},
+	})
+
+	if palpite.IsPublished(time.Now()) {
+		events.Publish(palpitePublished(&palpite))
+	}
+
+	resp := palpiteResponse(r, nil, &palpite)
 	sendSuccessResponse(w, map[string]interface{}{
 		"palpite": resp,
 		"message": "Palpite criado com sucesso",
 	})
 }
+
+// GetPalpites lista palpites (filtros user_id e event_id) com paginação por cursor, mais recentes primeiro
+func GetPalpites(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	page, err := listing.ParseParams(q, palpiteSortFields, "created_at", true, "id")
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	userID, err := optionalInt(q.Get("user_id"))
+	if err != nil {
+		sendErrorResponse(w, "user_id inválido", http.StatusBadRequest)
+		return
+	}
+	eventID, err := optionalInt(q.Get("event_id"))
+	if err != nil {
+		sendErrorResponse(w, "event_id inválido", http.StatusBadRequest)
+		return
+	}
+
+	// Rascunhos e agendados só aparecem quando o autor lista os próprios palpites
+	viewerUser := auth.CurrentUser(r)
+	ownListing := viewerUser != nil && userID == viewerUser.ID
+
+	query := listing.New("SELECT "+models.PalpiteColumns+" FROM palpites").
+		Where("user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)").
+		WhereIf(!ownListing, "published_at <= NOW()").
+		WhereIf(userID != 0, "user_id = ?", userID).
+		WhereIf(eventID != 0, "event_id = ?", eventID)
+	page.Apply(query)
+
+	listSQL, args := query.SQL()
+	rows, err := database.DB.Query(listSQL, args...)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar palpites", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var found []*models.Palpite
+	for rows.Next() {
+		palpite, err := models.ScanPalpite(rows)
+		if err != nil {
+			sendErrorResponse(w, "Erro ao processar palpites", http.StatusInternalServerError)
+			return
+		}
+		found = append(found, palpite)
+	}
+
+	var nextCursor string
+	if page.HasMore(len(found)) {
+		found = found[:page.Limit]
+		last := found[len(found)-1]
+		nextCursor = listing.EncodeCursor(last.CreatedAt.Format(time.RFC3339Nano), last.ID)
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+
+	viewer := subscriptions.NewViewer(viewerUser)
+	palpites := []models.PalpiteResponse{}
+	for _, p := range found {
+		palpites = append(palpites, palpiteResponse(r, viewer, p))
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"palpites":    palpites,
+		"next_cursor": nextCursor,
+	})
+}
+
+// GetPalpite retorna um palpite pelo ID; sem acesso à visibilidade, retorna a prévia bloqueada
+func GetPalpite(w http.ResponseWriter, r *http.Request) {
+	palpite, ok := loadPalpite(w, r)
+	if !ok {
+		return
+	}
+	user := auth.CurrentUser(r)
+	isAuthor := user != nil && (user.ID == palpite.UserID || user.IsAdmin())
+	if !palpite.IsPublished(time.Now()) && !isAuthor {
+		sendErrorResponse(w, "Palpite não encontrado", http.StatusNotFound)
+		return
+	}
+	viewer := subscriptions.NewViewer(user)
+	sendSuccessResponse(w, map[string]interface{}{"palpite": palpiteResponse(r, viewer, palpite)})
+}
+
+// UpdatePalpite edita um palpite (autor ou admin). Palpites de eventos já iniciados não podem
+// ser alterados pelo autor.
+func UpdatePalpite(w http.ResponseWriter, r *http.Request) {
+	palpite, ok := loadOwnPalpite(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdatePalpiteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.CurrentUser(r)
+	if palpite.Status != models.STATUS_PENDENTE && !user.IsAdmin() {
+		sendErrorResponse(w, "Palpites já liquidados não podem ser alterados", http.StatusConflict)
+		return
+	}
+	if palpite.EventID != nil && !user.IsAdmin() {
+		if _, ok := ensureEventOpen(w, *palpite.EventID); !ok {
+			return
+		}
+	}
+	if req.EventID != nil && (palpite.EventID == nil || *req.EventID != *palpite.EventID) {
+		if _, ok := ensureEventOpen(w, *req.EventID); !ok {
+			return
+		}
+	}
+	if req.Visibilidade != nil && !models.IsValidVisibilidade(*req.Visibilidade) {
+		sendErrorResponse(w, "visibilidade inválida. Use publico, seguidores ou assinantes", http.StatusBadRequest)
+		return
+	}
+	if req.ImgURL != nil && *req.ImgURL == "" {
+		sendErrorResponse(w, "img_url não pode ser vazio", http.StatusBadRequest)
+		return
+	}
+
+	before := palpiteAuditFields(palpite)
+	if req.Titulo != nil {
+		palpite.Titulo = req.Titulo
+	}
+	if req.ImgURL != nil {
+		palpite.ImgURL = *req.ImgURL
+	}
+	if req.Link != nil {
+		palpite.Link = req.Link
+	}
+	if req.EventID != nil {
+		palpite.EventID = req.EventID
+	}
+	if req.Visibilidade != nil {
+		palpite.Visibilidade = *req.Visibilidade
+	}
+	palpite.ApplyMarket(req.PalpiteMarket)
+
+	market := models.PalpiteMarket{Mercado: palpite.Mercado, Selecao: palpite.Selecao, Linha: palpite.Linha, Odd: (*odds.Input)(palpite.Odd), Stake: &palpite.Stake}
+	if err := market.Validate(); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if palpite.Mercado != nil && palpite.EventID == nil {
+		sendErrorResponse(w, "event_id é obrigatório quando o mercado é informado", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	wasPublished := palpite.IsPublished(now)
+	palpite.ApplySchedule(req.PalpiteSchedule, now)
+	var event *models.Event
+	if palpite.EventID != nil {
+		var err error
+		if event, err = catalog.GetEvent(*palpite.EventID); err != nil {
+			sendErrorResponse(w, "Evento não encontrado", http.StatusBadRequest)
+			return
+		}
+	}
+	if !validateSchedule(w, palpite, event) {
+		return
+	}
+
+	palpite.UpdatedAt = now
+	_, err := database.DB.Exec(`
+		UPDATE palpites SET titulo = $1, img_url = $2, link = $3, event_id = $4, mercado = $5, selecao = $6,
+			linha = $7, odd = $8, stake = $9, visibility = $10, is_draft = $11, publish_at = $12,
+			published_at = $13, reveal_at_kickoff = $14, updated_at = $15
+		WHERE id = $16`,
+		palpite.Titulo, palpite.ImgURL, palpite.Link, palpite.EventID, palpite.Mercado, palpite.Selecao,
+		palpite.Linha, palpite.Odd, palpite.Stake, palpite.Visibilidade, palpite.Rascunho, palpite.PublishAt,
+		palpite.PublishedAt, palpite.RevelarNoInicio, palpite.UpdatedAt, palpite.ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao atualizar palpite", http.StatusInternalServerError)
+		return
+	}
+
+	after := palpiteAuditFields(palpite)
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionPalpiteUpdate,
+		TargetType: audit.TargetPalpite,
+		TargetID:   palpite.ID,
+		Diff:       audit.Diff(before, after),
+	})
+	if !wasPublished && palpite.IsPublished(now) {
+		events.Publish(palpitePublished(palpite))
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"palpite": palpiteResponse(r, nil, palpite),
+		"message": "Palpite atualizado com sucesso",
+	})
+}
+
+// DeletePalpite remove um palpite. O autor só remove palpites pendentes antes do início do
+// evento, como na edição; admins removem qualquer um
+func DeletePalpite(w http.ResponseWriter, r *http.Request) {
+	palpite, ok := loadOwnPalpite(w, r)
+	if !ok {
+		return
+	}
+
+	user := auth.CurrentUser(r)
+	if palpite.Status != models.STATUS_PENDENTE && !user.IsAdmin() {
+		sendErrorResponse(w, "Palpites já liquidados não podem ser removidos", http.StatusConflict)
+		return
+	}
+	if palpite.EventID != nil && !user.IsAdmin() {
+		if _, ok := ensureEventOpen(w, *palpite.EventID); !ok {
+			return
+		}
+	}
+
+	if _, err := database.DB.Exec("DELETE FROM palpites WHERE id = $1", palpite.ID); err != nil {
+		sendErrorResponse(w, "Erro ao remover palpite", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionPalpiteDelete,
+		TargetType: audit.TargetPalpite,
+		TargetID:   palpite.ID,
+		Metadata:   map[string]interface{}{"user_id": palpite.UserID, "titulo": palpite.Titulo},
+	})
+
+	sendSuccessResponse(w, map[string]string{"message": "Palpite removido com sucesso"})
+}
+
+// palpiteResponse monta a resposta com a odd no formato preferido do usuário da sessão,
+// omitindo-a para quem optou por ocultar odds. Com viewer, palpites cuja visibilidade o usuário
+// não alcança viram prévia bloqueada; viewer nil é usado nas respostas ao próprio autor.
+func palpiteResponse(r *http.Request, viewer *subscriptions.Viewer, p *models.Palpite) models.PalpiteResponse {
+	resp := p.ToResponse()
+	resp.RenderOdds(oddsFormat(r), compliance.HideOdds(r))
+	if viewer != nil && !viewer.CanView(p.UserID, p.Visibilidade) {
+		resp.Lock()
+	}
+	return resp
+}
+
+// palpitePublished é o evento emitido quando o palpite fica visível
+func palpitePublished(p *models.Palpite) events.PalpitePublished {
+	e := events.PalpitePublished{PalpiteID: p.ID, AuthorID: p.UserID}
+	if p.Titulo != nil {
+		e.Titulo = *p.Titulo
+	}
+	return e
+}
+
+// oddsFormat é o formato de odds preferido do usuário da sessão (decimal para visitantes)
+func oddsFormat(r *http.Request) string {
+	if user := auth.CurrentUser(r); user != nil && user.FormatoOdds != "" {
+		return user.FormatoOdds
+	}
+	return odds.FORMATO_DECIMAL
+}
+
+// palpiteAuditFields são os campos editáveis comparados no diff de auditoria
+func palpiteAuditFields(p *models.Palpite) map[string]interface{} {
+	return map[string]interface{}{
+		"titulo": p.Titulo, "img_url": p.ImgURL, "link": p.Link, "event_id": p.EventID,
+		"mercado": p.Mercado, "selecao": p.Selecao, "linha": p.Linha, "odd": p.Odd, "stake": p.Stake,
+		"visibilidade": p.Visibilidade, "rascunho": p.Rascunho, "publish_at": p.PublishAt,
+		"revelar_no_inicio": p.RevelarNoInicio,
+	}
+}
+
+// loadPalpite carrega o palpite do parâmetro {id} da rota, respondendo 400/404 quando necessário
+func loadPalpite(w http.ResponseWriter, r *http.Request) (*models.Palpite, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		sendErrorResponse(w, "ID do palpite inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	palpite, err := models.ScanPalpite(database.DB.QueryRow(
+		"SELECT "+models.PalpiteColumns+" FROM palpites WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		sendErrorResponse(w, "Palpite não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar palpite", http.StatusInternalServerError)
+		return nil, false
+	}
+	return palpite, true
+}
+
+// loadOwnPalpite carrega o palpite e exige que o usuário da sessão seja o autor ou admin
+func loadOwnPalpite(w http.ResponseWriter, r *http.Request) (*models.Palpite, bool) {
+	palpite, ok := loadPalpite(w, r)
+	if !ok {
+		return nil, false
+	}
+	user := auth.CurrentUser(r)
+	if palpite.UserID != user.ID && !user.IsAdmin() {
+		sendErrorResponse(w, "Apenas o autor ou um administrador pode alterar este palpite", http.StatusForbidden)
+		return nil, false
+	}
+	return palpite, true
+}
+
+// ensureEventOpen valida que o evento existe e ainda aceita palpites (antes do início)
+func ensureEventOpen(w http.ResponseWriter, eventID int) (*models.Event, bool) {
+	event, err := catalog.GetEvent(eventID)
+	if errors.Is(err, catalog.ErrNotFound) {
+		sendErrorResponse(w, "Evento não encontrado", http.StatusBadRequest)
+		return nil, false
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar evento", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !event.AcceptsPicks(time.Now()) {
+		sendErrorResponse(w, "O evento já começou ou não aceita mais palpites", http.StatusConflict)
+		return nil, false
+	}
+	return event, true
+}
+
+// validateSchedule exige evento para a revelação automática e, com evento, que a publicação
+// agendada aconteça antes do início
+func validateSchedule(w http.ResponseWriter, p *models.Palpite, event *models.Event) bool {
+	if p.RevelarNoInicio && event == nil {
+		sendErrorResponse(w, "revelar_no_inicio exige event_id", http.StatusBadRequest)
+		return false
+	}
+	if event != nil && p.PublishedAt == nil && p.PublishAt != nil && !p.PublishAt.Before(event.KickoffAt) {
+		sendErrorResponse(w, "publish_at deve ser anterior ao início do evento", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
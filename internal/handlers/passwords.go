@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"smartpicks-backend/internal/passwords"
+)
+
+// GetPasswordPolicy informa as regras de senha para o frontend exibir no cadastro
+func GetPasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	sendSuccessResponse(w, passwords.CurrentPolicy())
+}
+
+// validNewPassword aplica a política à nova senha e responde 400 com a lista de requisitos não
+// atendidos
+func validNewPassword(w http.ResponseWriter, password, email, nome string) bool {
+	err := passwords.Validate(password, email, nome)
+	if err == nil {
+		return true
+	}
+
+	var policyErr *passwords.PolicyError
+	if !errors.As(err, &policyErr) {
+		log.Printf("Erro ao validar senha: %v", err)
+		sendErrorResponse(w, "Erro ao processar password", http.StatusInternalServerError)
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":   "A senha não atende aos requisitos",
+		"problemas": policyErr.Problemas,
+	})
+	return false
+}
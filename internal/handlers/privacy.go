@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/passwords"
+	"smartpicks-backend/internal/privacy"
+)
+
+// ExportMyData devolve tudo o que é armazenado sobre o usuário logado (LGPD, art. 18).
+// Use ?format=zip para receber um arquivo .zip em vez de JSON.
+func ExportMyData(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	export, err := privacy.BuildExport(user)
+	if err != nil {
+		log.Printf("Erro ao exportar dados do usuário %d: %v", user.ID, err)
+		sendErrorResponse(w, "Erro ao exportar dados", http.StatusInternalServerError)
+		return
+	}
+
+	fileName := fmt.Sprintf("smartpicks-dados-%d-%s", user.ID, export.GeradoEm.Format("20060102"))
+
+	if r.URL.Query().Get("format") != "zip" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, fileName))
+		sendSuccessResponse(w, export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, fileName))
+
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("dados.json")
+	if err == nil {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(export)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		log.Printf("Erro ao gerar zip de exportação do usuário %d: %v", user.ID, err)
+	}
+}
+
+// DeleteMyAccount agenda a exclusão da conta; a remoção ocorre após o período de carência
+func DeleteMyAccount(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var requestData struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil || requestData.Password == "" {
+		sendErrorResponse(w, "Confirme a exclusão informando sua senha", http.StatusBadRequest)
+		return
+	}
+
+	var hash string
+	if err := database.DB.QueryRow("SELECT password FROM users WHERE id = $1", user.ID).Scan(&hash); err != nil {
+		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+	if err := passwords.Verify(hash, requestData.Password); err != nil {
+		sendErrorResponse(w, "Senha incorreta", http.StatusUnauthorized)
+		return
+	}
+
+	deletion, err := privacy.RequestDeletion(user, auth.ClientIP(r))
+	if err == privacy.ErrDeletionPending {
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao solicitar exclusão da conta %d: %v", user.ID, err)
+		sendErrorResponse(w, "Erro ao solicitar exclusão da conta", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionDeletionRequest,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   map[string]interface{}{"scheduled_for": deletion.ScheduledFor},
+	})
+
+	w.WriteHeader(http.StatusAccepted)
+	sendSuccessResponse(w, map[string]interface{}{
+		"deletion": deletion,
+		"message":  "Exclusão agendada. Você pode cancelar até " + deletion.ScheduledFor.Format("02/01/2006 15:04"),
+	})
+}
+
+func GetMyAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	deletion, err := privacy.PendingDeletion(user.ID)
+	if err == privacy.ErrNoPendingDeletion {
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar solicitação de exclusão", http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, deletion)
+}
+
+func CancelMyAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	err := privacy.CancelDeletion(user.ID)
+	if err == privacy.ErrNoPendingDeletion {
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao cancelar exclusão", http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]string{
+		"message": "Exclusão da conta cancelada",
+	})
+}
+
+// PurgeDeletedAccounts processa as exclusões vencidas (chamado por admin ou cron)
+func PurgeDeletedAccounts(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	purged, err := privacy.PurgeDueAccounts()
+	if err != nil {
+		log.Printf("Erro ao processar exclusões de conta: %v", err)
+		sendErrorResponse(w, "Erro ao processar exclusões de conta", http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"purged":      purged,
+		"duration_ms": time.Since(start).Milliseconds(),
+		"message":     "Exclusões processadas",
+	})
+}
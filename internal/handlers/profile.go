@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/compliance"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/odds"
+	"smartpicks-backend/internal/passwords"
+	"smartpicks-backend/internal/services"
+
+	"github.com/lib/pq"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+func GetMe(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	sendSuccessResponse(w, user.ToResponse())
+}
+
+// UpdateMe altera parcialmente o perfil do usuário logado.
+// A troca de email só é efetivada após a confirmação pelo link enviado ao novo endereço.
+func UpdateMe(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var req models.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	sets := []string{}
+	args := []interface{}{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if req.Nome != nil {
+		nome := strings.TrimSpace(*req.Nome)
+		if nome == "" {
+			sendErrorResponse(w, "Nome não pode ser vazio", http.StatusBadRequest)
+			return
+		}
+		set("nome", nome)
+	}
+
+	if req.DataNascimento != nil {
+		parsedDate, err := parseBirthDate(*req.DataNascimento)
+		if err != nil {
+			sendErrorResponse(w, "Formato de data inválido. Use YYYY-MM-DD ou DD/MM/YYYY", http.StatusBadRequest)
+			return
+		}
+		if parsedDate.After(time.Now()) {
+			sendErrorResponse(w, "Data de nascimento não pode estar no futuro", http.StatusBadRequest)
+			return
+		}
+		if !compliance.IsOfLegalAge(parsedDate) {
+			sendErrorResponse(w, fmt.Sprintf("A data de nascimento deve indicar idade mínima de %d anos", compliance.MinimumAge()), http.StatusBadRequest)
+			return
+		}
+		set("data_nascimento", parsedDate.Format("2006-01-02"))
+	}
+
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if len([]rune(bio)) > models.BioMaxLen {
+			sendErrorResponse(w, fmt.Sprintf("Bio deve ter no máximo %d caracteres", models.BioMaxLen), http.StatusBadRequest)
+			return
+		}
+		if bio == "" {
+			set("bio", nil)
+		} else {
+			set("bio", bio)
+		}
+	}
+
+	if req.EsportesFavoritos != nil {
+		esportes := models.NormalizeEsportes(*req.EsportesFavoritos)
+		if len(esportes) > models.EsportesFavoritosMax {
+			sendErrorResponse(w, fmt.Sprintf("Informe no máximo %d esportes favoritos", models.EsportesFavoritosMax), http.StatusBadRequest)
+			return
+		}
+		for _, e := range esportes {
+			if len([]rune(e)) > models.EsporteFavoritoMaxLen {
+				sendErrorResponse(w, "Nome de esporte muito longo", http.StatusBadRequest)
+				return
+			}
+		}
+		set("favorite_sports", pq.Array(esportes))
+	}
+
+	if req.RedesSociais != nil {
+		links := map[string]string{}
+		for rede, link := range *req.RedesSociais {
+			rede = strings.ToLower(strings.TrimSpace(rede))
+			if !models.RedesSociaisPermitidas[rede] {
+				sendErrorResponse(w, "Rede social não suportada: "+rede, http.StatusBadRequest)
+				return
+			}
+			if link == "" {
+				continue
+			}
+			if !models.IsValidSocialLink(link) {
+				sendErrorResponse(w, "Link inválido para "+rede, http.StatusBadRequest)
+				return
+			}
+			links[rede] = link
+		}
+		encoded, _ := json.Marshal(links)
+		set("social_links", string(encoded))
+	}
+
+	if req.FormatoOdds != nil {
+		if !odds.IsValidFormat(*req.FormatoOdds) {
+			sendErrorResponse(w, "formato_odds inválido. Use "+strings.Join(odds.ValidFormats, ", "), http.StatusBadRequest)
+			return
+		}
+		set("odds_format", *req.FormatoOdds)
+	}
+
+	var verificationToken string
+	if req.Email != nil {
+		email := strings.TrimSpace(*req.Email)
+		if !models.IsValidEmail(email) {
+			sendErrorResponse(w, "Email inválido", http.StatusBadRequest)
+			return
+		}
+		if !strings.EqualFold(email, user.Email) {
+			if userExists("email", email) {
+				sendErrorResponse(w, "Email já cadastrado", http.StatusConflict)
+				return
+			}
+			token, err := auth.GenerateSecureToken(32)
+			if err != nil {
+				sendErrorResponse(w, "Erro ao gerar verificação de email", http.StatusInternalServerError)
+				return
+			}
+			verificationToken = token
+			set("pending_email", email)
+			set("email_verification_token_hash", auth.HashToken(token))
+			set("email_verification_expires_at", time.Now().Add(emailVerificationTTL))
+		}
+	}
+
+	if len(sets) == 0 {
+		sendErrorResponse(w, "Nenhum campo para atualizar", http.StatusBadRequest)
+		return
+	}
+
+	args = append(args, user.ID)
+	query := fmt.Sprintf("UPDATE users SET %s WHERE id = $%d", strings.Join(sets, ", "), len(args))
+	if _, err := database.DB.Exec(query, args...); err != nil {
+		log.Printf("Erro ao atualizar perfil do usuário %d: %v", user.ID, err)
+		sendErrorResponse(w, "Erro ao atualizar perfil", http.StatusInternalServerError)
+		return
+	}
+
+	updated, err := models.ScanUser(database.DB.QueryRow(`
+		SELECT `+models.UserColumns+`
+		FROM users WHERE id = $1`, user.ID))
+	if err != nil {
+		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionProfileUpdate,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Diff:       audit.Diff(profileFields(user), profileFields(updated)),
+	})
+
+	message := "Perfil atualizado com sucesso"
+	if verificationToken != "" {
+		body := fmt.Sprintf("Olá, %s!\n\nConfirme seu novo email no SmartPicks acessando:\n%s\n\nO link expira em 24 horas.",
+			updated.Nome, services.EmailVerificationLink(verificationToken))
+		if _, err := jobs.Enqueue(jobs.SendEmail{To: *updated.EmailPendente, Subject: "Confirme seu novo email", Body: body}); err != nil {
+			log.Printf("Erro ao enfileirar verificação de email do usuário %d: %v", user.ID, err)
+		}
+		message = "Perfil atualizado. Confirme o novo email pelo link enviado"
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"user":    updated.ToResponse(),
+		"message": message,
+	})
+}
+
+// VerifyEmail confirma a troca de email com o token enviado ao novo endereço
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var requestData struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil || requestData.Token == "" {
+		sendErrorResponse(w, "Token é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE users
+		SET email = pending_email,
+			pending_email = NULL,
+			email_verification_token_hash = NULL,
+			email_verification_expires_at = NULL
+		WHERE email_verification_token_hash = $1
+		  AND email_verification_expires_at > CURRENT_TIMESTAMP
+		  AND pending_email IS NOT NULL`, auth.HashToken(requestData.Token))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			sendErrorResponse(w, "Email já cadastrado", http.StatusConflict)
+			return
+		}
+		sendErrorResponse(w, "Erro ao confirmar email", http.StatusInternalServerError)
+		return
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		sendErrorResponse(w, "Token inválido ou expirado", http.StatusBadRequest)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]string{
+		"message": "Email confirmado com sucesso",
+	})
+}
+
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	if req.SenhaAtual == "" || req.NovaSenha == "" {
+		sendErrorResponse(w, "Senha atual e nova senha são obrigatórias", http.StatusBadRequest)
+		return
+	}
+
+	var hash string
+	if err := database.DB.QueryRow("SELECT password FROM users WHERE id = $1", user.ID).Scan(&hash); err != nil {
+		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+
+	if err := passwords.Verify(hash, req.SenhaAtual); err != nil {
+		sendErrorResponse(w, "Senha atual incorreta", http.StatusUnauthorized)
+		return
+	}
+
+	if req.SenhaAtual == req.NovaSenha {
+		sendErrorResponse(w, "A nova senha deve ser diferente da atual", http.StatusBadRequest)
+		return
+	}
+
+	if !validNewPassword(w, req.NovaSenha, user.Email, user.Nome) {
+		return
+	}
+
+	newHash, err := passwords.Hash(req.NovaSenha)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao processar password", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := database.DB.Exec("UPDATE users SET password = $1 WHERE id = $2", newHash, user.ID); err != nil {
+		sendErrorResponse(w, "Erro ao alterar senha", http.StatusInternalServerError)
+		return
+	}
+
+	// As demais sessões e as chaves de API são encerradas; a sessão atual continua
+	revoked, err := auth.RevokeOtherSessions(user.ID, auth.SessionID(r), auth.REVOGACAO_SENHA)
+	if err != nil {
+		log.Printf("Erro ao encerrar sessões do usuário %d: %v", user.ID, err)
+	}
+	keys, err := auth.RevokeAllAPIKeys(user.ID)
+	if err != nil {
+		log.Printf("Erro ao revogar chaves de API do usuário %d: %v", user.ID, err)
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionPasswordChange,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   map[string]interface{}{"sessoes_encerradas": revoked, "chaves_revogadas": keys},
+	})
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"message":            "Senha alterada com sucesso",
+		"sessoes_encerradas": revoked,
+		"chaves_revogadas":   keys,
+	})
+}
+
+// profileFields são os campos editáveis comparados no diff de auditoria
+func profileFields(u *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"nome":               u.Nome,
+		"data_nascimento":    u.DataNascimento,
+		"bio":                u.Bio,
+		"esportes_favoritos": u.EsportesFavoritos,
+		"redes_sociais":      u.RedesSociais,
+		"email_pendente":     u.EmailPendente,
+		"formato_odds":       u.FormatoOdds,
+	}
+}
+
+// parseBirthDate aceita YYYY-MM-DD ou DD/MM/YYYY, como no cadastro
+func parseBirthDate(value string) (time.Time, error) {
+	parsedDate, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		parsedDate, err = time.Parse("02/01/2006", value)
+	}
+	return parsedDate, err
+}
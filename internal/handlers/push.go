@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/push"
+
+	"github.com/gorilla/mux"
+)
+
+type pushSubscriptionRequest struct {
+	Endpoint string    `json:"endpoint"`
+	Keys     push.Keys `json:"keys"`
+}
+
+// GetVAPIDPublicKey devolve a applicationServerKey usada pelo navegador em pushManager.subscribe
+func GetVAPIDPublicKey(w http.ResponseWriter, r *http.Request) {
+	v, err := push.VAPIDFromEnv()
+	if err != nil {
+		if !errors.Is(err, push.ErrNotConfigured) {
+			log.Printf("Erro na configuração VAPID: %v", err)
+		}
+		sendErrorResponse(w, "Notificações push não estão disponíveis", http.StatusServiceUnavailable)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"public_key": v.PublicKey})
+}
+
+// SavePushSubscription registra o navegador do usuário (corpo = PushSubscription.toJSON())
+func SavePushSubscription(w http.ResponseWriter, r *http.Request) {
+	var req pushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := push.ValidateEndpoint(req.Endpoint); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := push.ValidateKeys(req.Keys); err != nil {
+		sendErrorResponse(w, "keys inválidas: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub, err := push.Save(auth.CurrentUser(r).ID, req.Endpoint, req.Keys, r.UserAgent())
+	if errors.Is(err, push.ErrSubscriptionConflict) {
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao salvar inscrição push: %v", err)
+		sendErrorResponse(w, "Erro ao salvar inscrição", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	sendSuccessResponse(w, map[string]interface{}{
+		"subscription": sub,
+		"message":      "Notificações push ativadas",
+	})
+}
+
+func GetPushSubscriptions(w http.ResponseWriter, r *http.Request) {
+	list, err := push.ListByUser(auth.CurrentUser(r).ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar inscrições", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"subscriptions": list, "total": len(list)})
+}
+
+// DeletePushSubscription desativa o push do navegador: {endpoint} no corpo ou ?endpoint=
+func DeletePushSubscription(w http.ResponseWriter, r *http.Request) {
+	endpoint := r.URL.Query().Get("endpoint")
+	if endpoint == "" {
+		var req pushSubscriptionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		endpoint = req.Endpoint
+	}
+	if endpoint == "" {
+		sendErrorResponse(w, "endpoint é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	if err := push.Delete(auth.CurrentUser(r).ID, endpoint); err != nil {
+		if errors.Is(err, push.ErrSubscriptionNotFound) {
+			sendErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		sendErrorResponse(w, "Erro ao remover inscrição", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]string{"message": "Notificações push desativadas"})
+}
+
+// CreateStubPushSubscription gera uma inscrição apontando para o serviço de push local (PUSH_STUB=true)
+func CreateStubPushSubscription(w http.ResponseWriter, r *http.Request) {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	endpoint, keys, err := push.DefaultStub.NewSubscription(scheme + "://" + r.Host + "/api/push/stub/endpoints")
+	if err != nil {
+		sendErrorResponse(w, "Erro ao gerar inscrição", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	sendSuccessResponse(w, pushSubscriptionRequest{Endpoint: endpoint, Keys: keys})
+}
+
+// ReceiveStubPush faz o papel do serviço de push: confere o VAPID, decifra e guarda a mensagem
+func ReceiveStubPush(w http.ResponseWriter, r *http.Request) {
+	status, err := push.DefaultStub.Receive(mux.Vars(r)["id"], r)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(status)
+}
+
+// ExpireStubPush simula o navegador cancelando a inscrição: os próximos envios recebem 410
+func ExpireStubPush(w http.ResponseWriter, r *http.Request) {
+	if !push.DefaultStub.Expire(mux.Vars(r)["id"]) {
+		sendErrorResponse(w, "Inscrição não encontrada", http.StatusNotFound)
+		return
+	}
+	sendSuccessResponse(w, map[string]string{"message": "Inscrição expirada"})
+}
+
+func GetStubPushMessages(w http.ResponseWriter, r *http.Request) {
+	messages := push.DefaultStub.Messages()
+	if endpoint := r.URL.Query().Get("endpoint"); endpoint != "" {
+		id := endpoint[strings.LastIndex(endpoint, "/")+1:]
+		filtered := []push.StubMessage{}
+		for _, m := range messages {
+			if m.Endpoint == id {
+				filtered = append(filtered, m)
+			}
+		}
+		messages = filtered
+	}
+	sendSuccessResponse(w, map[string]interface{}{"messages": messages, "total": len(messages)})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/compliance"
+	"smartpicks-backend/internal/models"
+)
+
+func GetGamblingSettings(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	settings, err := compliance.LoadSettings(user.ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar configurações de jogo responsável", http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, settings)
+}
+
+// UpdateGamblingSettings altera a preferência de ocultar odds e/ou inicia uma pausa ou autoexclusão.
+// Uma exclusão vigente só pode ser estendida, nunca encurtada ou removida pelo próprio usuário.
+func UpdateGamblingSettings(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var req models.UpdateGamblingSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := compliance.LoadSettings(user.ID)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar configurações de jogo responsável", http.StatusInternalServerError)
+		return
+	}
+
+	if req.OcultarOdds != nil {
+		settings.OcultarOdds = *req.OcultarOdds
+	}
+
+	if req.TipoExclusao != "" {
+		if !models.IsValidTipoExclusao(req.TipoExclusao) {
+			sendErrorResponse(w, "Tipo de exclusão inválido. Use 'pausa' ou 'autoexclusao'", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		var until *time.Time
+		switch req.TipoExclusao {
+		case models.EXCLUSAO_PAUSA:
+			if req.Dias < models.PausaMinDias || req.Dias > models.PausaMaxDias {
+				sendErrorResponse(w, "A pausa deve ter entre 1 e 90 dias", http.StatusBadRequest)
+				return
+			}
+			t := now.AddDate(0, 0, req.Dias)
+			until = &t
+		case models.EXCLUSAO_AUTOEXCLUSAO:
+			if req.Dias != 0 && req.Dias < models.AutoexclusaoMinDias {
+				sendErrorResponse(w, "A autoexclusão deve ter no mínimo 180 dias (ou omita 'dias' para tempo indeterminado)", http.StatusBadRequest)
+				return
+			}
+			if req.Dias > 0 {
+				t := now.AddDate(0, 0, req.Dias)
+				until = &t
+			}
+		}
+
+		if settings.IsExcluded(now) && endsBefore(until, settings.ExcluidoAte) {
+			sendErrorResponse(w, "Já existe uma exclusão vigente que termina depois do período solicitado", http.StatusConflict)
+			return
+		}
+
+		tipo := req.TipoExclusao
+		settings.TipoExclusao = &tipo
+		settings.ExcluidoDesde = &now
+		settings.ExcluidoAte = until
+		settings.MotivoExclusao = req.Motivo
+	}
+
+	if err := compliance.SaveSettings(settings); err != nil {
+		sendErrorResponse(w, "Erro ao salvar configurações de jogo responsável", http.StatusInternalServerError)
+		return
+	}
+	settings.ExclusaoAtiva = settings.IsExcluded(time.Now())
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"settings": settings,
+		"message":  "Configurações de jogo responsável atualizadas",
+	})
+}
+
+func GetActiveExclusions(w http.ResponseWriter, r *http.Request) {
+	exclusions, err := compliance.ListActiveExclusions()
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar exclusões", http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"exclusions": exclusions,
+		"total":      len(exclusions),
+		"message":    "Exclusões ativas listadas com sucesso",
+	})
+}
+
+// endsBefore compara fins de exclusão, em que nil significa tempo indeterminado
+func endsBefore(a, b *time.Time) bool {
+	if b == nil {
+		return a != nil
+	}
+	return a != nil && a.Before(*b)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/results"
+)
+
+// PostResults registra placares finais em lote e liquida os palpites dos eventos.
+// Com dry_run (no corpo ou ?dry_run=true) nada é gravado e a resposta traz a prévia.
+func PostResults(w http.ResponseWriter, r *http.Request) {
+	var req models.ResultsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Results) == 0 {
+		sendErrorResponse(w, "Nenhum resultado informado", http.StatusBadRequest)
+		return
+	}
+
+	applyResults(w, r, req.Results, req.DryRun || isDryRun(r))
+}
+
+// PostEventResult registra o placar final de um evento: {home_score, away_score, status?, dry_run?}
+func PostEventResult(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		models.EventResult
+		DryRun bool `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.EventID = id
+	req.ExternalRef = ""
+
+	applyResults(w, r, []models.EventResult{req.EventResult}, req.DryRun || isDryRun(r))
+}
+
+// SettlePalpites enfileira a liquidação dos palpites pendentes de eventos já encerrados.
+// Com ?dry_run=true a prévia é calculada na hora e nada é gravado.
+func SettlePalpites(w http.ResponseWriter, r *http.Request) {
+	if isDryRun(r) {
+		report, err := results.SettlePending(true)
+		if err != nil {
+			log.Printf("Erro na prévia da liquidação: %v", err)
+			sendErrorResponse(w, "Erro ao liquidar palpites", http.StatusInternalServerError)
+			return
+		}
+		sendSettlementReport(w, report)
+		return
+	}
+
+	jobID, err := jobs.EnqueueWith(database.DB, jobs.SettleEvents{}, jobs.Options{UniqueKey: "settle_events:all"})
+	if err != nil {
+		log.Printf("Erro ao enfileirar liquidação: %v", err)
+		sendErrorResponse(w, "Erro ao liquidar palpites", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:   audit.ActionPalpiteSettle,
+		Metadata: map[string]interface{}{"job_id": jobID},
+	})
+	w.WriteHeader(http.StatusAccepted)
+	sendSettlementReport(w, &models.SettlementReport{Settled: []models.SettlementChange{}, JobID: jobID})
+}
+
+// applyResults grava os placares. Em dry run liquida na transação desfeita para devolver a
+// prévia; caso contrário a liquidação é enfileirada junto com os placares.
+func applyResults(w http.ResponseWriter, r *http.Request, list []models.EventResult, dryRun bool) {
+	var report *models.SettlementReport
+	var err error
+	if dryRun {
+		report, err = results.Apply(list, true)
+	} else {
+		report, err = results.Record(list)
+	}
+	if err != nil {
+		log.Printf("Erro ao aplicar resultados: %v", err)
+		sendErrorResponse(w, "Erro ao aplicar resultados", http.StatusInternalServerError)
+		return
+	}
+
+	if !dryRun {
+		for _, res := range list {
+			if res.EventID == 0 {
+				continue
+			}
+			audit.Record(r, audit.Event{
+				Action:     audit.ActionEventResults,
+				TargetType: audit.TargetEvent,
+				TargetID:   res.EventID,
+				Metadata:   map[string]interface{}{"home_score": res.HomeScore, "away_score": res.AwayScore, "status": res.Status},
+			})
+		}
+		audit.Record(r, audit.Event{
+			Action:   audit.ActionPalpiteSettle,
+			Metadata: map[string]interface{}{"results": report.Results, "job_id": report.JobID},
+		})
+		if report.JobID != 0 {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}
+	sendSettlementReport(w, report)
+}
+
+func sendSettlementReport(w http.ResponseWriter, report *models.SettlementReport) {
+	message := "Palpites liquidados com sucesso"
+	switch {
+	case report.DryRun:
+		message = "Prévia da liquidação (nada foi gravado)"
+	case report.JobID != 0:
+		message = "Liquidação enfileirada"
+	}
+	sendSuccessResponse(w, map[string]interface{}{
+		"report":  report,
+		"message": message,
+	})
+}
+
+func isDryRun(r *http.Request) bool {
+	return r.URL.Query().Get("dry_run") == "true"
+}
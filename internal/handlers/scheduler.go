@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"smartpicks-backend/internal/scheduler"
+)
+
+// RunDueJobs executa as tarefas agendadas vencidas (publicação, revelação, liquidação e
+// exclusões). É idempotente: pode ser chamado pelo cron quantas vezes for preciso.
+func RunDueJobs(w http.ResponseWriter, r *http.Request) {
+	report, err := scheduler.RunDue(r.Context(), time.Now())
+	if err != nil {
+		log.Printf("Erro ao executar tarefas agendadas: %v", err)
+		sendErrorResponse(w, "Erro ao executar tarefas agendadas", http.StatusInternalServerError)
+		return
+	}
+
+	message := "Tarefas agendadas executadas"
+	if report.Skipped {
+		message = "Outra execução já está em andamento"
+	}
+	sendSuccessResponse(w, map[string]interface{}{
+		"report":  report,
+		"message": message,
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/compliance"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/search"
+	"smartpicks-backend/internal/subscriptions"
+)
+
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 50
+	searchMinQueryLen  = 2
+)
+
+// Search busca palpites e tipsters: ?q=texto&type=palpite|tipster&limit=20
+func Search(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len([]rune(q)) < searchMinQueryLen {
+		sendErrorResponse(w, "Informe ao menos 2 caracteres em 'q'", http.StatusBadRequest)
+		return
+	}
+
+	types := map[string]bool{models.SEARCH_PALPITE: true, models.SEARCH_TIPSTER: true}
+	switch t := r.URL.Query().Get("type"); t {
+	case "":
+	case models.SEARCH_PALPITE, models.SEARCH_TIPSTER:
+		types = map[string]bool{t: true}
+	default:
+		sendErrorResponse(w, "type inválido. Use 'palpite' ou 'tipster'", http.StatusBadRequest)
+		return
+	}
+
+	limit := searchDefaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			sendErrorResponse(w, "limit inválido", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, searchMaxLimit)
+	}
+
+	hits, err := search.Search(q, types, limit)
+	if err != nil {
+		log.Printf("Erro na busca %q: %v", q, err)
+		sendErrorResponse(w, "Erro ao realizar busca", http.StatusInternalServerError)
+		return
+	}
+
+	format, hide := oddsFormat(r), compliance.HideOdds(r)
+	viewer := subscriptions.NewViewer(auth.CurrentUser(r))
+	for _, hit := range hits {
+		if hit.Palpite != nil {
+			hit.Palpite.RenderOdds(format, hide)
+			if !viewer.CanView(hit.Palpite.UserID, hit.Palpite.Visibilidade) {
+				hit.Palpite.Lock()
+			}
+		}
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"query":   q,
+		"results": hits,
+		"total":   len(hits),
+	})
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+)
+
+// RefreshSession troca o refresh token por um novo par de tokens. Cada refresh token vale uma
+// única vez: apresentar de novo um token já trocado encerra a sessão.
+func RefreshSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		sendErrorResponse(w, "refresh_token é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	tokens, user, err := auth.Refresh(r, req.RefreshToken)
+	var reuse *auth.ReuseError
+	switch {
+	case errors.As(err, &reuse):
+		audit.Record(r, audit.Event{
+			Action:     audit.ActionRefreshReuse,
+			ActorID:    reuse.UserID,
+			TargetType: audit.TargetUser,
+			TargetID:   reuse.UserID,
+			Metadata:   map[string]interface{}{"sessao_id": reuse.SessionID},
+		})
+		sendErrorResponse(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.Is(err, auth.ErrSuspended):
+		auth.SendSuspendedError(w, user)
+		return
+	case errors.Is(err, auth.ErrInvalidRefreshToken):
+		sendErrorResponse(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		log.Printf("Erro ao renovar sessão: %v", err)
+		sendErrorResponse(w, "Erro ao renovar sessão", http.StatusInternalServerError)
+		return
+	}
+
+	writeAuthResponse(w, user, tokens, http.StatusOK)
+}
+
+// Logout encerra a sessão da requisição; o token de acesso deixa de valer na hora
+func Logout(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	sessionID := auth.SessionID(r)
+	if sessionID == 0 {
+		sendErrorResponse(w, "Token sem sessão associada", http.StatusBadRequest)
+		return
+	}
+	if err := auth.RevokeSession(user.ID, sessionID, auth.REVOGACAO_LOGOUT); err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+		log.Printf("Erro ao encerrar sessão %d: %v", sessionID, err)
+		sendErrorResponse(w, "Erro ao encerrar sessão", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionLogout,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   map[string]interface{}{"sessao_id": sessionID},
+	})
+	sendSuccessResponse(w, map[string]string{"message": "Sessão encerrada"})
+}
+
+// GetMySessions lista os dispositivos conectados à conta
+func GetMySessions(w http.ResponseWriter, r *http.Request) {
+	list, err := auth.ListSessions(auth.CurrentUser(r).ID, auth.SessionID(r))
+	if err != nil {
+		log.Printf("Erro ao listar sessões: %v", err)
+		sendErrorResponse(w, "Erro ao buscar sessões", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"sessions": list, "total": len(list)})
+}
+
+// DeleteMySession encerra a sessão de um dispositivo
+func DeleteMySession(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+	user := auth.CurrentUser(r)
+	err := auth.RevokeSession(user.ID, id, auth.REVOGACAO_USUARIO)
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao encerrar sessão %d: %v", id, err)
+		sendErrorResponse(w, "Erro ao encerrar sessão", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionSessionRevoke,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   map[string]interface{}{"sessao_id": id},
+	})
+	sendSuccessResponse(w, map[string]string{"message": "Sessão encerrada"})
+}
+
+// DeleteMySessions encerra todas as sessões exceto a atual ("sair dos outros dispositivos")
+func DeleteMySessions(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	revoked, err := auth.RevokeOtherSessions(user.ID, auth.SessionID(r), auth.REVOGACAO_USUARIO)
+	if err != nil {
+		log.Printf("Erro ao encerrar sessões do usuário %d: %v", user.ID, err)
+		sendErrorResponse(w, "Erro ao encerrar sessões", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionSessionRevoke,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   map[string]interface{}{"sessoes_encerradas": revoked},
+	})
+	sendSuccessResponse(w, map[string]interface{}{
+		"message":            "Sessões dos outros dispositivos encerradas",
+		"sessoes_encerradas": revoked,
+	})
+}
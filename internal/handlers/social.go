@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/social"
+)
+
+// GetSocialTemplates devolve os templates padrão e as variáveis disponíveis
+func GetSocialTemplates(w http.ResponseWriter, r *http.Request) {
+	sendSuccessResponse(w, map[string]interface{}{
+		"templates": map[string]string{
+			social.PLATAFORMA_TELEGRAM: social.DefaultTelegramTemplate,
+			social.PLATAFORMA_DISCORD:  social.DefaultDiscordTemplate,
+		},
+		"variaveis": []string{
+			"Tipster", "Titulo", "Evento", "Competicao", "Inicio", "Mercado", "Selecao", "Linha", "Odd",
+			"Stake", "Link", "URL", "Imagem", "Bloqueado", "Publico",
+		},
+		"telegram_configurado": social.TelegramBotToken() != "",
+	})
+}
+
+func GetSocialChannels(w http.ResponseWriter, r *http.Request) {
+	list, err := social.ListByUser(auth.CurrentUser(r).ID)
+	if err != nil {
+		log.Printf("Erro ao listar canais: %v", err)
+		sendErrorResponse(w, "Erro ao buscar canais", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"channels": list, "total": len(list)})
+}
+
+func CreateSocialChannel(w http.ResponseWriter, r *http.Request) {
+	var in social.Input
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := in.Validate(true, in.Plataforma); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c, err := social.Create(auth.CurrentUser(r).ID, in)
+	if err != nil {
+		sendSocialError(w, err)
+		return
+	}
+	resp := map[string]interface{}{
+		"channel": c,
+		"message": "Canal cadastrado",
+	}
+	if !addVerification(w, c, resp) {
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	sendSuccessResponse(w, resp)
+}
+
+func UpdateSocialChannel(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadOwnChannel(w, r)
+	if !ok {
+		return
+	}
+	var in social.Input
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := in.Validate(false, c.Plataforma); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := social.Update(c, in)
+	if err != nil {
+		sendSocialError(w, err)
+		return
+	}
+	resp := map[string]interface{}{
+		"channel": updated,
+		"message": "Canal atualizado",
+	}
+	// só o chat novo precisa de código; um canal ainda pendente mantém o código já enviado
+	if c.Verified() && !updated.Verified() && !addVerification(w, updated, resp) {
+		return
+	}
+	sendSuccessResponse(w, resp)
+}
+
+// StartSocialChannelVerification gera um novo código de verificação para o canal do Telegram,
+// invalidando o anterior (código expirado ou perdido)
+func StartSocialChannelVerification(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadOwnChannel(w, r)
+	if !ok {
+		return
+	}
+	if c.Plataforma != social.PLATAFORMA_TELEGRAM || c.Verified() {
+		sendErrorResponse(w, "Canal já verificado", http.StatusConflict)
+		return
+	}
+	resp := map[string]interface{}{"message": "Novo código de verificação gerado"}
+	if addVerification(w, c, resp) {
+		sendSuccessResponse(w, resp)
+	}
+}
+
+// addVerification gera o código dos canais do Telegram ainda não verificados e o inclui na
+// resposta com as instruções para o tipster
+func addVerification(w http.ResponseWriter, c *social.Channel, resp map[string]interface{}) bool {
+	if c.Plataforma != social.PLATAFORMA_TELEGRAM || c.Verified() {
+		return true
+	}
+	v, err := social.StartVerification(c)
+	if err != nil {
+		log.Printf("Erro ao gerar código de verificação do canal %d: %v", c.ID, err)
+		sendErrorResponse(w, "Erro ao gerar código de verificação", http.StatusInternalServerError)
+		return false
+	}
+	resp["verificacao"] = map[string]interface{}{
+		"codigo":    v.Codigo,
+		"comando":   v.Comando,
+		"expira_em": v.ExpiraEm,
+		"instrucoes": "Com o bot já adicionado ao chat, envie \"" + v.Comando + "\" no próprio chat, " +
+			"com uma conta de administrador. O canal só publica depois da verificação.",
+	}
+	return true
+}
+
+// TelegramWebhook recebe os updates do bot (setWebhook com secret_token igual a
+// TELEGRAM_WEBHOOK_SECRET) e conclui a verificação dos canais. Responde sempre 200 aos updates
+// autenticados para o Telegram não reenviá-los.
+func TelegramWebhook(w http.ResponseWriter, r *http.Request) {
+	if !social.ValidWebhookSecret(r.Header.Get("X-Telegram-Bot-Api-Secret-Token")) {
+		http.NotFound(w, r)
+		return
+	}
+	var update social.TelegramUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		sendErrorResponse(w, "Update inválido", http.StatusBadRequest)
+		return
+	}
+	if err := social.HandleTelegramUpdate(r.Context(), update); err != nil {
+		log.Printf("Erro ao processar update do Telegram: %v", err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func DeleteSocialChannel(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadOwnChannel(w, r)
+	if !ok {
+		return
+	}
+	if err := social.Delete(c.ID); err != nil {
+		sendSocialError(w, err)
+		return
+	}
+	sendSuccessResponse(w, map[string]string{"message": "Canal removido"})
+}
+
+// TestSocialChannel envia na hora uma mensagem de exemplo; recusas da plataforma (bot fora do
+// canal, webhook apagado, template com HTML inválido) voltam como 422 com o detalhe
+func TestSocialChannel(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadOwnChannel(w, r)
+	if !ok {
+		return
+	}
+
+	messageID, err := social.SendTest(r.Context(), c)
+	if err != nil {
+		if errors.Is(err, social.ErrNotVerified) {
+			sendSocialError(w, err)
+			return
+		}
+		if errors.Is(err, social.ErrRejected) {
+			sendErrorResponse(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		log.Printf("Erro no envio de teste do canal %d: %v", c.ID, err)
+		sendErrorResponse(w, "A plataforma não respondeu; tente novamente", http.StatusBadGateway)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{
+		"message_id": messageID,
+		"message":    "Mensagem de teste enviada",
+	})
+}
+
+// GetSocialChannelPosts lista as publicações mais recentes do canal, com o erro das que falharam
+func GetSocialChannelPosts(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadOwnChannel(w, r)
+	if !ok {
+		return
+	}
+	list, err := social.ListPosts(c.ID)
+	if err != nil {
+		log.Printf("Erro ao listar publicações do canal %d: %v", c.ID, err)
+		sendErrorResponse(w, "Erro ao buscar publicações", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"posts": list, "total": len(list)})
+}
+
+// loadOwnChannel carrega o canal do parâmetro {id} da rota; canais de outros usuários respondem 404
+func loadOwnChannel(w http.ResponseWriter, r *http.Request) (*social.Channel, bool) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return nil, false
+	}
+	c, err := social.Get(id)
+	if err == nil && c.UserID != auth.CurrentUser(r).ID {
+		err = social.ErrNotFound
+	}
+	if err != nil {
+		sendSocialError(w, err)
+		return nil, false
+	}
+	return c, true
+}
+
+func sendSocialError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, social.ErrNotFound):
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, social.ErrLimit), errors.Is(err, social.ErrNotVerified):
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, social.ErrNotConfigured):
+		sendErrorResponse(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		log.Printf("Erro na publicação social: %v", err)
+		sendErrorResponse(w, "Erro ao processar canal", http.StatusInternalServerError)
+	}
+}
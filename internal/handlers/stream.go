@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/notifications"
+	"smartpicks-backend/internal/stream"
+)
+
+const (
+	// streamHeartbeat mantém a conexão viva através de proxies que encerram conexões ociosas
+	streamHeartbeat = 25 * time.Second
+	// streamPollInterval é usado quando não há LISTEN/NOTIFY (ex.: na Vercel)
+	streamPollInterval = 5 * time.Second
+	// streamMaxPollingDuration encerra a conexão antes do limite da função serverless; o
+	// navegador reconecta sozinho enviando Last-Event-ID
+	streamMaxPollingDuration = 50 * time.Second
+	streamReplayLimit        = 500
+	streamRetryMillis        = 3000
+)
+
+// Stream é o endpoint SSE (GET /api/stream) com os eventos do usuário: palpites novos de quem ele
+// segue, liquidações e total de notificações não lidas. Retoma a partir de Last-Event-ID (header
+// enviado pelo EventSource ao reconectar, ou ?last_event_id=).
+func Stream(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+
+	lastID, resumed, err := lastEventID(r)
+	if err != nil {
+		sendErrorResponse(w, "Last-Event-ID inválido", http.StatusBadRequest)
+		return
+	}
+	if !resumed {
+		if lastID, err = stream.LatestID(ctx, user.ID); err != nil {
+			log.Printf("Erro ao iniciar stream do usuário %d: %v", user.ID, err)
+			sendErrorResponse(w, "Erro ao abrir stream", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	wake, unsubscribe := stream.DefaultHub.Subscribe(user.ID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	rc.SetWriteDeadline(time.Time{})
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis)
+
+	// Conexão nova: estado inicial do contador (sem id, para não alterar o ponto de retomada)
+	if !resumed {
+		if n, err := notifications.UnreadCount(user.ID); err == nil {
+			fmt.Fprintf(w, "event: %s\ndata: {\"unread_count\":%d}\n\n", stream.EVENTO_NOTIFICACOES, n)
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	var poll <-chan time.Time
+	var deadline <-chan time.Time
+	if !stream.DefaultHub.Live() {
+		ticker := time.NewTicker(streamPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+		deadline = time.After(streamMaxPollingDuration)
+	}
+
+	for {
+		if lastID, err = sendStreamEvents(w, rc, r, user.ID, lastID); err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-stream.DefaultHub.Closed():
+			return
+		case <-deadline:
+			return
+		case <-wake:
+		case <-poll:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendStreamEvents escreve os eventos posteriores a lastID e devolve o novo último id
+func sendStreamEvents(w http.ResponseWriter, rc *http.ResponseController, r *http.Request, userID, lastID int) (int, error) {
+	for {
+		list, err := stream.Since(r.Context(), userID, lastID, streamReplayLimit)
+		if err != nil {
+			log.Printf("Erro ao ler eventos do stream do usuário %d: %v", userID, err)
+			return lastID, err
+		}
+		for _, e := range list {
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Tipo, e.Data); err != nil {
+				return lastID, err
+			}
+			lastID = e.ID
+		}
+		if len(list) > 0 {
+			if err := rc.Flush(); err != nil {
+				return lastID, err
+			}
+		}
+		if len(list) < streamReplayLimit {
+			return lastID, nil
+		}
+	}
+}
+
+func lastEventID(r *http.Request) (int, bool, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id < 0 {
+		return 0, false, fmt.Errorf("last event id inválido: %q", v)
+	}
+	return id, true, nil
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/payments"
+	"smartpicks-backend/internal/subscriptions"
+
+	"github.com/gorilla/mux"
+)
+
+const planNomeMaxLen = 100
+
+// GetTipsterPlans lista os planos ativos de um tipster
+func GetTipsterPlans(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+
+	plans, err := subscriptions.ListPlans(id, true)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar planos", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"plans": plans, "total": len(plans)})
+}
+
+// GetMyPlans lista todos os planos do tipster logado, inclusive os desativados
+func GetMyPlans(w http.ResponseWriter, r *http.Request) {
+	plans, err := subscriptions.ListPlans(auth.CurrentUser(r).ID, false)
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar planos", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"plans": plans, "total": len(plans)})
+}
+
+// SaveMyPlan cria (POST /api/me/plans) ou atualiza (PUT /api/me/plans/{id}) um plano do tipster logado
+func SaveMyPlan(w http.ResponseWriter, r *http.Request) {
+	var id int
+	if _, hasID := mux.Vars(r)["id"]; hasID {
+		var ok bool
+		if id, ok = catalogID(w, r); !ok {
+			return
+		}
+	}
+
+	var req models.PlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Nome = strings.TrimSpace(req.Nome)
+	if req.Nome == "" || len([]rune(req.Nome)) > planNomeMaxLen {
+		sendErrorResponse(w, "nome é obrigatório (até 100 caracteres)", http.StatusBadRequest)
+		return
+	}
+	if req.PrecoCentavos <= 0 {
+		sendErrorResponse(w, "preco_centavos deve ser positivo", http.StatusBadRequest)
+		return
+	}
+	if _, ok := models.IntervaloMeses[req.Intervalo]; !ok {
+		sendErrorResponse(w, "intervalo inválido. Use mensal, trimestral ou anual", http.StatusBadRequest)
+		return
+	}
+
+	plan, err := subscriptions.SavePlan(auth.CurrentUser(r).ID, id, req)
+	if errors.Is(err, subscriptions.ErrNotFound) {
+		sendErrorResponse(w, "Plano não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao salvar plano", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"plan": plan, "message": "Plano salvo com sucesso"})
+}
+
+// DeactivateMyPlan desativa um plano; assinaturas existentes seguem até o fim do período
+func DeactivateMyPlan(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+
+	err := subscriptions.DeactivatePlan(auth.CurrentUser(r).ID, id)
+	if errors.Is(err, subscriptions.ErrNotFound) {
+		sendErrorResponse(w, "Plano não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao desativar plano", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]string{"message": "Plano desativado"})
+}
+
+// SubscribePlan assina um plano. A resposta traz checkout_url quando o pagamento precisa ser
+// concluído no provedor; a assinatura é ativada pelo webhook.
+func SubscribePlan(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+
+	provider, err := payments.FromEnv()
+	if err != nil {
+		log.Printf("Assinatura indisponível: %v", err)
+		sendErrorResponse(w, "Assinaturas indisponíveis no momento", http.StatusServiceUnavailable)
+		return
+	}
+
+	user := auth.CurrentUser(r)
+	sub, checkout, err := subscriptions.Subscribe(r.Context(), provider, user, id)
+	switch {
+	case errors.Is(err, subscriptions.ErrNotFound):
+		sendErrorResponse(w, "Plano não encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, subscriptions.ErrPlanInactive), errors.Is(err, subscriptions.ErrOwnPlan):
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, subscriptions.ErrAlreadyActive):
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("Erro ao assinar plano %d: %v", id, err)
+		sendErrorResponse(w, "Erro ao processar assinatura", http.StatusBadGateway)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionSubscriptionCreate,
+		TargetType: audit.TargetSubscription,
+		TargetID:   sub.ID,
+		Metadata:   map[string]interface{}{"plan_id": sub.PlanID, "tipster_id": sub.TipsterID, "provider": sub.Provider},
+	})
+
+	message := "Assinatura ativada"
+	if sub.Status == models.ASSINATURA_PENDENTE {
+		message = "Conclua o pagamento para ativar a assinatura"
+	}
+	sendSuccessResponse(w, map[string]interface{}{
+		"subscription": sub,
+		"checkout_url": checkout.URL,
+		"message":      message,
+	})
+}
+
+// GetMySubscriptions lista as assinaturas do usuário logado (?as=tipster para os assinantes dos seus planos)
+func GetMySubscriptions(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var list []models.Subscription
+	var err error
+	if r.URL.Query().Get("as") == "tipster" {
+		list, err = subscriptions.ListByTipster(user.ID)
+	} else {
+		list, err = subscriptions.ListBySubscriber(user.ID)
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao buscar assinaturas", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"subscriptions": list, "total": len(list)})
+}
+
+// CancelMySubscription cancela a renovação; o acesso segue até current_period_end
+func CancelMySubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := subscriptions.Cancel(r.Context(), auth.CurrentUser(r).ID, id)
+	switch {
+	case errors.Is(err, subscriptions.ErrNotFound):
+		sendErrorResponse(w, "Assinatura não encontrada", http.StatusNotFound)
+		return
+	case errors.Is(err, subscriptions.ErrNotCancellable):
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("Erro ao cancelar assinatura %d: %v", id, err)
+		sendErrorResponse(w, "Erro ao cancelar assinatura", http.StatusBadGateway)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionSubscriptionCancel,
+		TargetType: audit.TargetSubscription,
+		TargetID:   sub.ID,
+	})
+	sendSuccessResponse(w, map[string]interface{}{
+		"subscription": sub,
+		"message":      "Assinatura cancelada. O acesso continua até o fim do período pago",
+	})
+}
+
+// PaymentWebhook recebe as notificações do provedor de pagamento (/api/payments/webhook/{provider})
+func PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	provider, err := payments.ByName(mux.Vars(r)["provider"])
+	if err != nil {
+		sendErrorResponse(w, "Provedor desconhecido", http.StatusNotFound)
+		return
+	}
+
+	event, err := provider.ParseWebhook(r)
+	if err != nil {
+		sendErrorResponse(w, "Webhook inválido", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := subscriptions.HandleWebhook(provider.Name(), event)
+	switch {
+	case errors.Is(err, subscriptions.ErrDuplicateEvent):
+		// o provedor reenvia até receber 2xx; o evento já foi aplicado
+		sendSuccessResponse(w, map[string]interface{}{
+			"subscription_id": sub.ID,
+			"status":          sub.Status,
+			"duplicado":       true,
+		})
+		return
+	case errors.Is(err, subscriptions.ErrNotFound):
+		sendErrorResponse(w, "Assinatura não encontrada", http.StatusNotFound)
+		return
+	case errors.Is(err, payments.ErrInvalidWebhook):
+		sendErrorResponse(w, "Evento não suportado: "+event.Type, http.StatusBadRequest)
+		return
+	case err != nil:
+		log.Printf("Erro ao processar webhook %s (%s): %v", provider.Name(), event.Ref, err)
+		sendErrorResponse(w, "Erro ao processar webhook", http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"subscription_id": sub.ID,
+		"status":          sub.Status,
+	})
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/passwords"
+	"smartpicks-backend/internal/twofactor"
+)
+
+// LoginTwoFactor é o segundo passo do login: o desafio devolvido pelo primeiro passo e o código
+// do aplicativo (ou um código de recuperação). Cada desafio aceita até 5 tentativas.
+func LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		sendErrorResponse(w, "challenge_token e code são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := twofactor.ChallengeUser(req.ChallengeToken)
+	if err != nil {
+		sendTwoFactorError(w, err)
+		return
+	}
+	user, err := models.ScanUser(database.DB.QueryRow(`
+		SELECT `+models.UserColumns+`
+		FROM users WHERE id = $1 AND deleted_at IS NULL`, userID))
+	if err != nil {
+		sendTwoFactorError(w, twofactor.ErrInvalidChallenge)
+		return
+	}
+	if !loginAllowed(w, r, user, user.Email) {
+		return
+	}
+
+	recovery, err := twofactor.Verify(user.ID, req.Code)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrInvalidCode) {
+			recordLoginFailure(r, user.Email, user.ID, "codigo_2fa_incorreto")
+		}
+		sendTwoFactorError(w, err)
+		return
+	}
+	if err := twofactor.ConsumeChallenge(req.ChallengeToken); err != nil {
+		log.Printf("Erro ao encerrar desafio de 2FA: %v", err)
+	}
+
+	segundoFator := "totp"
+	if recovery {
+		segundoFator = "codigo_recuperacao"
+		audit.Record(r, audit.Event{
+			Action:     audit.ActionRecoveryCodeUse,
+			ActorID:    user.ID,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+		})
+	}
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionLoginSuccess,
+		ActorID:    user.ID,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Metadata:   map[string]interface{}{"segundo_fator": segundoFator},
+	})
+
+	sendAuthResponse(w, r, user, http.StatusOK)
+}
+
+// GetTwoFactorStatus informa se o 2FA está ativo, se é obrigatório e quantos códigos de
+// recuperação restam
+func GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := twofactor.GetStatus(auth.CurrentUser(r))
+	if err != nil {
+		sendTwoFactorError(w, err)
+		return
+	}
+	sendSuccessResponse(w, status)
+}
+
+// SetupTwoFactor gera o segredo; o frontend exibe a otpauth_uri como QR code
+func SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	secret, uri, err := twofactor.Setup(auth.CurrentUser(r))
+	if err != nil {
+		sendTwoFactorError(w, err)
+		return
+	}
+	sendSuccessResponse(w, map[string]string{
+		"secret":      secret,
+		"otpauth_uri": uri,
+		"message":     "Escaneie o QR code no aplicativo autenticador e confirme com um código",
+	})
+}
+
+// EnableTwoFactor ativa o 2FA com o primeiro código do aplicativo e devolve os códigos de
+// recuperação, exibidos apenas nesta resposta
+func EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		sendErrorResponse(w, "code é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := twofactor.Enable(user.ID, req.Code)
+	if err != nil {
+		sendTwoFactorError(w, err)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionTwoFactorEnable,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+	})
+	sendSuccessResponse(w, map[string]interface{}{
+		"recovery_codes": codes,
+		"message":        "Verificação em duas etapas ativada. Guarde os códigos de recuperação em local seguro",
+	})
+}
+
+// DisableTwoFactor exige a senha e um código (do aplicativo ou de recuperação)
+func DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	var req struct {
+		Senha string `json:"senha"`
+		Code  string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Senha == "" || req.Code == "" {
+		sendErrorResponse(w, "Senha e code são obrigatórios", http.StatusBadRequest)
+		return
+	}
+	if !user.TwoFactorEnabled() {
+		sendTwoFactorError(w, twofactor.ErrNotEnabled)
+		return
+	}
+	if auth.TwoFactorRequired(user) {
+		sendTwoFactorError(w, twofactor.ErrRequired)
+		return
+	}
+	if !checkPassword(w, user.ID, req.Senha) {
+		return
+	}
+	if _, err := twofactor.Verify(user.ID, req.Code); err != nil {
+		sendTwoFactorError(w, err)
+		return
+	}
+
+	if err := twofactor.Disable(user); err != nil {
+		sendTwoFactorError(w, err)
+		return
+	}
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionTwoFactorDisable,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+	})
+	sendSuccessResponse(w, map[string]string{"message": "Verificação em duas etapas desativada"})
+}
+
+// RegenerateRecoveryCodes troca todos os códigos de recuperação; exige um código do aplicativo
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		sendErrorResponse(w, "code é obrigatório", http.StatusBadRequest)
+		return
+	}
+	if !user.TwoFactorEnabled() {
+		sendTwoFactorError(w, twofactor.ErrNotEnabled)
+		return
+	}
+	if _, err := twofactor.Verify(user.ID, req.Code); err != nil {
+		sendTwoFactorError(w, err)
+		return
+	}
+
+	codes, err := twofactor.RegenerateRecoveryCodes(user.ID)
+	if err != nil {
+		sendTwoFactorError(w, err)
+		return
+	}
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionRecoveryCodes,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+	})
+	sendSuccessResponse(w, map[string]interface{}{
+		"recovery_codes": codes,
+		"message":        "Novos códigos gerados; os anteriores deixaram de valer",
+	})
+}
+
+// AdminResetTwoFactor desativa o 2FA de quem perdeu o aparelho e os códigos de recuperação.
+// As sessões do usuário são encerradas.
+func AdminResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	admin := auth.CurrentUser(r)
+
+	target, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ModerationReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Motivo == "" {
+		sendErrorResponse(w, "O motivo é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	if err := twofactor.Reset(target.ID); err != nil {
+		sendTwoFactorError(w, err)
+		return
+	}
+	if _, err := database.DB.Exec(
+		"UPDATE users SET tokens_valid_after = CURRENT_TIMESTAMP WHERE id = $1", target.ID); err != nil {
+		log.Printf("Erro ao encerrar sessões do usuário %d: %v", target.ID, err)
+	}
+
+	recordAdminAction(r, admin.ID, target.ID, models.ACAO_RESET_2FA, optionalReason(req.Motivo), nil)
+
+	sendModeratedUser(w, admin, target.ID, "Verificação em duas etapas desativada")
+}
+
+// checkPassword confere a senha atual do usuário, respondendo 401 quando incorreta
+func checkPassword(w http.ResponseWriter, userID int, password string) bool {
+	var hash string
+	err := database.DB.QueryRow("SELECT password FROM users WHERE id = $1", userID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
+		return false
+	}
+	if err != nil {
+		sendErrorResponse(w, "Erro ao verificar senha", http.StatusInternalServerError)
+		return false
+	}
+	if passwords.Verify(hash, password) != nil {
+		sendErrorResponse(w, "Senha incorreta", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func sendTwoFactorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidChallenge), errors.Is(err, twofactor.ErrInvalidCode):
+		sendErrorResponse(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, twofactor.ErrAlreadyEnabled):
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, twofactor.ErrNotEnabled), errors.Is(err, twofactor.ErrNotSetUp):
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, twofactor.ErrRequired):
+		sendErrorResponse(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, twofactor.ErrLocked):
+		w.Header().Set("Retry-After", strconv.Itoa(int(twofactor.Lockout.Seconds())))
+		sendErrorResponse(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		log.Printf("Erro na verificação em duas etapas: %v", err)
+		sendErrorResponse(w, "Erro ao processar verificação em duas etapas", http.StatusInternalServerError)
+	}
+}
@@ -3,11 +3,15 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/jobs"
 	"smartpicks-backend/internal/services"
 )
 
@@ -83,6 +87,20 @@ func UploadImageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Registrar o arquivo para exportação e exclusão de dados (LGPD)
+	var uploadID int
+	err = database.DB.QueryRow(`
+		INSERT INTO uploaded_files (user_id, s3_key, url, content_type, size_bytes, original_name)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		auth.CurrentUser(r).ID, newFileName, imageURL, contentType, handler.Size, handler.Filename).Scan(&uploadID)
+	if err != nil {
+		log.Printf("Erro ao registrar upload %s: %v", newFileName, err)
+	} else if _, err := jobs.Enqueue(jobs.ProcessImage{UploadID: uploadID}); err != nil {
+		// As dimensões são apenas informativas: o upload continua válido
+		log.Printf("Erro ao enfileirar processamento do upload %d: %v", uploadID, err)
+	}
+
 	// Resposta com a URL pública do S3
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
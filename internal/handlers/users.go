@@ -2,69 +2,60 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"smartpicks-backend/internal/auth"
 	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/listing"
 	"smartpicks-backend/internal/models"
 )
 
+// userSortFields são as opções de ordenação aceitas pelas listagens de usuários
+var userSortFields = map[string]listing.SortField{
+	"created_at": {Column: "created_at", Cast: "timestamptz"},
+	"nome":       {Column: "nome", Cast: "text"},
+	"email":      {Column: "email", Cast: "text"},
+}
+
+// GetAllUsers lista usuários com paginação por cursor (limit, cursor), busca por prefixo de
+// nome/email (q; email só para admins), filtros (perfil, created_from, created_to, has_avatar) e ordenação (sort, order).
+// O total sem paginação é retornado no header X-Total-Count.
 func GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	rows, err := database.DB.Query(`
-		SELECT id, nome, email, cpf,
-			   TO_CHAR(data_nascimento, 'YYYY-MM-DD') as data_nascimento,
-			   perfil, COALESCE(avatar, '') as avatar,
-			   created_at,
-			   updated_at
-		FROM users
-		ORDER BY created_at DESC`)
-	if err != nil {
-		sendErrorResponse(w, "Erro ao buscar usuários", http.StatusInternalServerError)
+	perfil := r.URL.Query().Get("perfil")
+	if perfil != "" && !models.IsValidPerfil(perfil) {
+		sendErrorResponse(w, "Perfil inválido. Use 'admin' ou 'user'", http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
-
-	var users []models.UserResponse
-	for rows.Next() {
-		var user models.User
-		err := rows.Scan(&user.ID, &user.Nome, &user.Email, &user.CPF,
-			&user.DataNascimento, &user.Perfil, &user.Avatar,
-			&user.CreatedAt, &user.UpdatedAt)
-		if err != nil {
-			sendErrorResponse(w, "Erro ao processar dados dos usuários", http.StatusInternalServerError)
-			return
-		}
-
-		users = append(users, user.ToResponse())
-	}
 
-	sendSuccessResponse(w, map[string]interface{}{
-		"users":   users,
-		"total":   len(users),
-		"message": "Usuários listados com sucesso",
-	})
+	listUsers(w, r, perfil, "Usuários listados com sucesso")
 }
 
+// CheckUserPermissions devolve o usuário do email informado. Quem não é admin só consulta o
+// próprio email, para a rota não revelar quais emails estão cadastrados.
 func CheckUserPermissions(w http.ResponseWriter, r *http.Request) {
+	viewer := auth.CurrentUser(r)
 
 	email := r.URL.Query().Get("email")
 	if email == "" {
 		sendErrorResponse(w, "Email é obrigatório", http.StatusBadRequest)
 		return
 	}
+	if !viewer.IsAdmin() && !strings.EqualFold(email, viewer.Email) {
+		sendErrorResponse(w, "Apenas administradores consultam outros usuários", http.StatusForbidden)
+		return
+	}
 
-	var user models.User
-	err := database.DB.QueryRow(`
-		SELECT id, nome, email, cpf,
-			   TO_CHAR(data_nascimento, 'YYYY-MM-DD') as data_nascimento,
-			   perfil, COALESCE(avatar, '') as avatar, created_at, updated_at 
-		FROM users WHERE email = $1`, email).
-		Scan(&user.ID, &user.Nome, &user.Email, &user.CPF,
-			&user.DataNascimento, &user.Perfil, &user.Avatar, &user.CreatedAt, &user.UpdatedAt)
+	user, err := models.ScanUser(database.DB.QueryRow(`
+		SELECT `+models.UserColumns+`
+		FROM users WHERE email = $1 AND deleted_at IS NULL`, email))
 	if err != nil {
 		sendErrorResponse(w, "Usuário não encontrado", http.StatusNotFound)
 		return
 	}
 
-	sendSuccessResponse(w, user.ToResponse())
+	sendSuccessResponse(w, user.ToResponseFor(viewer))
 }
 
 func GetUsersByProfile(w http.ResponseWriter, r *http.Request) {
@@ -79,37 +70,125 @@ func GetUsersByProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := database.DB.Query(`
-		SELECT id, nome, email, cpf,
-			   TO_CHAR(data_nascimento, 'YYYY-MM-DD') as data_nascimento,
-			   perfil, COALESCE(avatar, '') as avatar, created_at, updated_at
-		FROM users 
-		WHERE perfil = $1
-		ORDER BY created_at DESC`, profile)
+	listUsers(w, r, profile, "Usuários encontrados com sucesso")
+}
+
+// listUsers aplica filtros, ordenação e paginação comuns às listagens de usuários
+func listUsers(w http.ResponseWriter, r *http.Request, perfil string, message string) {
+	viewer := auth.CurrentUser(r)
+	q := r.URL.Query()
+
+	page, err := listing.ParseParams(q, userSortFields, "created_at", true, "id")
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdFrom, err := optionalTime(q.Get("created_from"))
+	if err != nil {
+		sendErrorResponse(w, "created_from inválido. Use YYYY-MM-DD ou RFC3339", http.StatusBadRequest)
+		return
+	}
+	createdTo, err := optionalTime(q.Get("created_to"))
+	if err != nil {
+		sendErrorResponse(w, "created_to inválido. Use YYYY-MM-DD ou RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	query := listing.New("SELECT "+models.UserColumns+" FROM users").
+		Where("deleted_at IS NULL").
+		WhereIf(perfil != "", "perfil = ?", perfil)
+
+	// Email é dado pessoal: só admins buscam e ordenam por ele
+	admin := viewer != nil && viewer.IsAdmin()
+	if page.Sort == "email" && !admin {
+		sendErrorResponse(w, "Ordenação por email restrita a administradores", http.StatusForbidden)
+		return
+	}
+	if search := strings.TrimSpace(q.Get("q")); search != "" {
+		pattern := listing.PrefixPattern(search)
+		if admin {
+			query.Where("nome ILIKE ? OR email ILIKE ?", pattern, pattern)
+		} else {
+			query.Where("nome ILIKE ?", pattern)
+		}
+	}
+	if createdFrom != nil {
+		query.Where("created_at >= ?", *createdFrom)
+	}
+	if createdTo != nil {
+		query.Where("created_at < ?", *createdTo)
+	}
+	switch q.Get("has_avatar") {
+	case "":
+	case "true":
+		query.Where("avatar IS NOT NULL AND avatar <> ''")
+	case "false":
+		query.Where("avatar IS NULL OR avatar = ''")
+	default:
+		sendErrorResponse(w, "has_avatar deve ser true ou false", http.StatusBadRequest)
+		return
+	}
+
+	countSQL, countArgs := query.CountSQL()
+	var total int
+	if err := database.DB.QueryRow(countSQL, countArgs...).Scan(&total); err != nil {
+		sendErrorResponse(w, "Erro ao buscar usuários", http.StatusInternalServerError)
+		return
+	}
+
+	page.Apply(query)
+	listSQL, listArgs := query.SQL()
+	rows, err := database.DB.Query(listSQL, listArgs...)
 	if err != nil {
-		sendErrorResponse(w, "Erro ao buscar usuários por perfil", http.StatusInternalServerError)
+		sendErrorResponse(w, "Erro ao buscar usuários", http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
 
-	var users []models.UserResponse
+	var found []*models.User
 	for rows.Next() {
-		var user models.User
-
-		err := rows.Scan(&user.ID, &user.Nome, &user.Email, &user.CPF,
-			&user.DataNascimento, &user.Perfil, &user.Avatar, &user.CreatedAt, &user.UpdatedAt)
+		user, err := models.ScanUser(rows)
 		if err != nil {
 			sendErrorResponse(w, "Erro ao processar dados dos usuários", http.StatusInternalServerError)
 			return
 		}
+		found = append(found, user)
+	}
 
-		users = append(users, user.ToResponse())
+	var nextCursor string
+	if page.HasMore(len(found)) {
+		found = found[:page.Limit]
+		last := found[len(found)-1]
+		nextCursor = listing.EncodeCursor(userSortValue(last, page.Sort), last.ID)
+		w.Header().Set("X-Next-Cursor", nextCursor)
 	}
 
-	sendSuccessResponse(w, map[string]interface{}{
-		"users":   users,
-		"total":   len(users),
-		"profile": profile,
-		"message": "Usuários encontrados com sucesso",
-	})
+	users := []models.UserResponse{}
+	for _, user := range found {
+		users = append(users, user.ToResponseFor(viewer))
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	response := map[string]interface{}{
+		"users":       users,
+		"total":       total,
+		"next_cursor": nextCursor,
+		"message":     message,
+	}
+	if perfil != "" {
+		response["profile"] = perfil
+	}
+	sendSuccessResponse(w, response)
+}
+
+func userSortValue(u *models.User, sort string) string {
+	switch sort {
+	case "nome":
+		return u.Nome
+	case "email":
+		return u.Email
+	default:
+		return u.CreatedAt.Format(time.RFC3339Nano)
+	}
 }
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/listing"
+	"smartpicks-backend/internal/webhooks"
+
+	"github.com/gorilla/mux"
+)
+
+var deliverySortFields = map[string]listing.SortField{
+	"created_at": {Column: "created_at", Cast: "timestamptz"},
+}
+
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	list, err := webhooks.ListByUser(auth.CurrentUser(r).ID)
+	if err != nil {
+		log.Printf("Erro ao listar webhooks: %v", err)
+		sendErrorResponse(w, "Erro ao buscar webhooks", http.StatusInternalServerError)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"webhooks": list, "total": len(list)})
+}
+
+// CreateWebhook cadastra um webhook; o segredo das assinaturas só é devolvido nesta resposta
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var in webhooks.Input
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := in.Validate(true); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wh, secret, err := webhooks.Create(auth.CurrentUser(r).ID, in)
+	if err != nil {
+		sendWebhookError(w, err)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionWebhookCreate,
+		TargetType: audit.TargetWebhook,
+		TargetID:   wh.ID,
+		Metadata:   map[string]interface{}{"url": wh.URL, "eventos": wh.Eventos},
+	})
+	w.WriteHeader(http.StatusCreated)
+	sendSuccessResponse(w, map[string]interface{}{
+		"webhook": wh,
+		"secret":  secret,
+		"message": "Webhook criado. Guarde o secret: ele não será exibido novamente",
+	})
+}
+
+func GetWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, ok := loadOwnWebhook(w, r)
+	if !ok {
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"webhook": wh})
+}
+
+func UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, ok := loadOwnWebhook(w, r)
+	if !ok {
+		return
+	}
+	var in webhooks.Input
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		sendErrorResponse(w, "Requisição inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := in.Validate(false); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := webhooks.Update(wh.ID, in)
+	if err != nil {
+		sendWebhookError(w, err)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionWebhookUpdate,
+		TargetType: audit.TargetWebhook,
+		TargetID:   wh.ID,
+		Diff:       audit.Diff(webhookAuditFields(wh), webhookAuditFields(updated)),
+	})
+	sendSuccessResponse(w, map[string]interface{}{
+		"webhook": updated,
+		"message": "Webhook atualizado",
+	})
+}
+
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, ok := loadOwnWebhook(w, r)
+	if !ok {
+		return
+	}
+	if err := webhooks.Delete(wh.ID); err != nil {
+		sendWebhookError(w, err)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionWebhookDelete,
+		TargetType: audit.TargetWebhook,
+		TargetID:   wh.ID,
+		Metadata:   map[string]interface{}{"url": wh.URL},
+	})
+	sendSuccessResponse(w, map[string]string{"message": "Webhook removido"})
+}
+
+// RotateWebhookSecret gera um novo segredo; o anterior deixa de valer imediatamente
+func RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	wh, ok := loadOwnWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	updated, secret, err := webhooks.RotateSecret(wh.ID)
+	if err != nil {
+		sendWebhookError(w, err)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionWebhookRotate,
+		TargetType: audit.TargetWebhook,
+		TargetID:   wh.ID,
+	})
+	sendSuccessResponse(w, map[string]interface{}{
+		"webhook": updated,
+		"secret":  secret,
+		"message": "Secret atualizado. Guarde o novo secret: ele não será exibido novamente",
+	})
+}
+
+// PingWebhook enfileira uma entrega de teste (evento ping)
+func PingWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, ok := loadOwnWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := webhooks.Ping(wh)
+	if err != nil {
+		sendWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	sendSuccessResponse(w, map[string]interface{}{
+		"delivery": d,
+		"message":  "Ping enfileirado",
+	})
+}
+
+// GetWebhookDeliveries lista o histórico de entregas (mais recentes primeiro) com filtros
+// status e evento
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	wh, ok := loadOwnWebhook(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+
+	page, err := listing.ParseParams(q, deliverySortFields, "created_at", true, "id")
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f := webhooks.DeliveryFilter{Status: q.Get("status"), Evento: q.Get("evento")}
+	if f.Status != "" && !slices.Contains(webhooks.ValidDeliveryStatus, f.Status) {
+		sendErrorResponse(w, "status inválido. Use "+strings.Join(webhooks.ValidDeliveryStatus, ", "), http.StatusBadRequest)
+		return
+	}
+
+	list, hasMore, err := webhooks.ListDeliveries(wh.ID, f, page)
+	if err != nil {
+		log.Printf("Erro ao listar entregas do webhook %d: %v", wh.ID, err)
+		sendErrorResponse(w, "Erro ao buscar entregas", http.StatusInternalServerError)
+		return
+	}
+
+	var nextCursor string
+	if hasMore {
+		last := list[len(list)-1]
+		nextCursor = listing.EncodeCursor(last.CreatedAt.Format(time.RFC3339Nano), last.ID)
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+
+	sendSuccessResponse(w, map[string]interface{}{
+		"deliveries":  list,
+		"next_cursor": nextCursor,
+	})
+}
+
+func GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	wh, ok := loadOwnWebhook(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryID(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := webhooks.GetDelivery(wh.ID, deliveryID)
+	if err != nil {
+		sendWebhookError(w, err)
+		return
+	}
+	sendSuccessResponse(w, map[string]interface{}{"delivery": d})
+}
+
+// RedeliverWebhook reenvia uma entrega do histórico como uma nova entrega, com o mesmo corpo
+func RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, ok := loadOwnWebhook(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryID(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := webhooks.Redeliver(wh, deliveryID)
+	if err != nil {
+		sendWebhookError(w, err)
+		return
+	}
+
+	audit.Record(r, audit.Event{
+		Action:     audit.ActionWebhookRedeliver,
+		TargetType: audit.TargetWebhook,
+		TargetID:   wh.ID,
+		Metadata:   map[string]interface{}{"delivery_id": deliveryID, "nova_delivery_id": d.ID, "evento": d.Evento},
+	})
+	w.WriteHeader(http.StatusAccepted)
+	sendSuccessResponse(w, map[string]interface{}{
+		"delivery": d,
+		"message":  "Reenvio enfileirado",
+	})
+}
+
+// webhookAuditFields são os campos comparados no diff de auditoria; do segredo vai só o prefixo
+func webhookAuditFields(wh *webhooks.Webhook) map[string]interface{} {
+	return map[string]interface{}{
+		"url": wh.URL, "descricao": wh.Descricao, "eventos": wh.Eventos, "ativo": wh.Ativo,
+		"secret": wh.SecretPrefix,
+	}
+}
+
+// loadOwnWebhook carrega o webhook do parâmetro {id} da rota. Webhooks de outros usuários
+// respondem 404 (exceto para admins), sem revelar que existem.
+func loadOwnWebhook(w http.ResponseWriter, r *http.Request) (*webhooks.Webhook, bool) {
+	id, ok := catalogID(w, r)
+	if !ok {
+		return nil, false
+	}
+	wh, err := webhooks.Get(id)
+	if err != nil {
+		sendWebhookError(w, err)
+		return nil, false
+	}
+	user := auth.CurrentUser(r)
+	if wh.UserID != user.ID && !user.IsAdmin() {
+		sendWebhookError(w, webhooks.ErrNotFound)
+		return nil, false
+	}
+	return wh, true
+}
+
+func deliveryID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["delivery_id"])
+	if err != nil || id <= 0 {
+		sendErrorResponse(w, "ID da entrega inválido", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func sendWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, webhooks.ErrNotFound), errors.Is(err, webhooks.ErrDeliveryNotFound):
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, webhooks.ErrInactive), errors.Is(err, webhooks.ErrLimit):
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Erro nos webhooks: %v", err)
+		sendErrorResponse(w, "Erro ao processar webhook", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,142 @@
+// Package jobs implementa a fila de tarefas em segundo plano sobre a tabela jobs do Postgres.
+// Handlers HTTP enfileiram payloads tipados; workers (main.go) ou o cron da Vercel os executam.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status de um job
+const (
+	STATUS_PENDENTE   = "pendente"
+	STATUS_EXECUTANDO = "executando"
+	STATUS_CONCLUIDO  = "concluido"
+	STATUS_MORTO      = "morto" // esgotou as tentativas (dead-letter)
+)
+
+var ValidStatus = []string{STATUS_PENDENTE, STATUS_EXECUTANDO, STATUS_CONCLUIDO, STATUS_MORTO}
+
+func IsValidStatus(s string) bool {
+	for _, v := range ValidStatus {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrNotFound     = errors.New("job não encontrado")
+	ErrNotRetryable = errors.New("apenas jobs mortos ou concluídos podem ser reenfileirados")
+	// ErrLeaseLost indica que o job expirou o lease e foi reservado por outro worker, que passa a
+	// responder pelo resultado
+	ErrLeaseLost = errors.New("reserva do job perdida para outro worker")
+)
+
+// Job é uma linha da tabela jobs
+type Job struct {
+	ID            int             `json:"id"`
+	Tipo          string          `json:"tipo"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Tentativas    int             `json:"tentativas"`
+	MaxTentativas int             `json:"max_tentativas"`
+	UniqueKey     *string         `json:"unique_key,omitempty"`
+	RunAt         time.Time       `json:"run_at"`
+	LockedBy      *string         `json:"locked_by,omitempty"`
+	UltimoErro    *string         `json:"ultimo_erro,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+}
+
+// Payload é o corpo tipado de um job; JobType identifica o handler que o executa
+type Payload interface {
+	JobType() string
+}
+
+// Options ajustam o enfileiramento. UniqueKey evita duplicatas enquanto houver um job
+// pendente ou em execução com a mesma chave.
+type Options struct {
+	RunAt         time.Time
+	MaxTentativas int
+	UniqueKey     string
+}
+
+const DefaultMaxTentativas = 5
+
+// Handler executa o payload bruto de um job
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+var (
+	mu       sync.RWMutex
+	handlers = map[string]Handler{}
+)
+
+// Register associa o handler tipado ao tipo do payload T. Payloads que não decodificam
+// vão direto para dead-letter, pois nenhuma nova tentativa resolveria.
+func Register[T Payload](handle func(ctx context.Context, p T) error) {
+	var zero T
+	mu.Lock()
+	defer mu.Unlock()
+	handlers[zero.JobType()] = func(ctx context.Context, raw json.RawMessage) error {
+		var p T
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return Permanent(fmt.Errorf("payload inválido: %w", err))
+		}
+		return handle(ctx, p)
+	}
+}
+
+func handlerFor(tipo string) (Handler, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	h, ok := handlers[tipo]
+	return h, ok
+}
+
+// permanentError marca falhas que não devem ser tentadas novamente
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent faz o job ir direto para dead-letter, sem novas tentativas
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// retryAfterError pede uma espera mínima antes da próxima tentativa
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e retryAfterError) Error() string { return e.err.Error() }
+func (e retryAfterError) Unwrap() error { return e.err }
+
+// RetryAfter faz a próxima tentativa esperar ao menos d, quando o serviço externo informa quando
+// aceitará de novo (ex.: 429 com retry_after). Se o backoff for maior, vale o backoff.
+func RetryAfter(err error, d time.Duration) error {
+	return retryAfterError{err: err, after: d}
+}
+
+// retryDelay é a espera antes da tentativa seguinte à tentativa attempt
+func retryDelay(err error, attempt int) time.Duration {
+	d := Backoff(attempt)
+	var ra retryAfterError
+	if errors.As(err, &ra) && ra.after > d {
+		d = ra.after
+	}
+	return d
+}
@@ -0,0 +1,24 @@
+package jobs
+
+// SendEmail envia um email transacional
+type SendEmail struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+func (SendEmail) JobType() string { return "send_email" }
+
+// ProcessImage lê um arquivo enviado ao S3 e grava suas dimensões em uploaded_files
+type ProcessImage struct {
+	UploadID int `json:"upload_id"`
+}
+
+func (ProcessImage) JobType() string { return "process_image" }
+
+// SettleEvents liquida os palpites pendentes dos eventos informados (vazio = todos os encerrados)
+type SettleEvents struct {
+	EventIDs []int `json:"event_ids,omitempty"`
+}
+
+func (SettleEvents) JobType() string { return "settle_events" }
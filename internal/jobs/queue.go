@@ -0,0 +1,362 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/listing"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// lease é o tempo após o qual um job em execução é considerado abandonado (worker caiu
+	// ou a função serverless expirou) e volta a ser elegível
+	lease = 10 * time.Minute
+	// jobTimeout limita a duração de uma execução
+	jobTimeout = 5 * time.Minute
+
+	backoffBase = 30 * time.Second
+	backoffMax  = time.Hour
+)
+
+const jobColumns = `id, type, payload, status, attempts, max_attempts, unique_key, run_at, locked_by,
+	last_error, created_at, updated_at, completed_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func scanJob(row rowScanner) (*Job, error) {
+	var j Job
+	var payload []byte
+	err := row.Scan(&j.ID, &j.Tipo, &payload, &j.Status, &j.Tentativas, &j.MaxTentativas, &j.UniqueKey,
+		&j.RunAt, &j.LockedBy, &j.UltimoErro, &j.CreatedAt, &j.UpdatedAt, &j.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+	return &j, nil
+}
+
+// Enqueue grava o job para execução imediata
+func Enqueue(p Payload) (int, error) {
+	return EnqueueWith(database.DB, p, Options{})
+}
+
+// EnqueueTx grava o job na transação: ele só fica visível aos workers se a transação for confirmada
+func EnqueueTx(tx *sql.Tx, p Payload, opts Options) (int, error) {
+	return EnqueueWith(tx, p, opts)
+}
+
+// EnqueueWith grava o job com as opções informadas. Com UniqueKey, se já houver um job ativo com a
+// mesma chave, devolve o id existente em vez de criar outro.
+func EnqueueWith(db queryRower, p Payload, opts Options) (int, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return 0, err
+	}
+	if opts.MaxTentativas <= 0 {
+		opts.MaxTentativas = DefaultMaxTentativas
+	}
+	var runAt, uniqueKey interface{}
+	if !opts.RunAt.IsZero() {
+		runAt = opts.RunAt
+	}
+	if opts.UniqueKey != "" {
+		uniqueKey = opts.UniqueKey
+	}
+
+	var id int
+	err = db.QueryRow(`
+		INSERT INTO jobs (type, payload, run_at, max_attempts, unique_key)
+		VALUES ($1, $2, COALESCE($3::timestamptz, NOW()), $4, $5)
+		ON CONFLICT (unique_key) WHERE status IN ('pendente', 'executando') DO NOTHING
+		RETURNING id`, p.JobType(), payload, runAt, opts.MaxTentativas, uniqueKey).Scan(&id)
+	if err == sql.ErrNoRows {
+		err = db.QueryRow(`
+			SELECT id FROM jobs WHERE unique_key = $1 AND status IN ('pendente', 'executando')`,
+			opts.UniqueKey).Scan(&id)
+	}
+	return id, err
+}
+
+// claim reserva o próximo job elegível. FOR UPDATE SKIP LOCKED permite vários workers
+// concorrentes sem que dois peguem o mesmo job. Jobs abandonados que já usaram todas as tentativas
+// vão para dead-letter em vez de rodar de novo: um job que derruba o worker não entra em loop.
+func claim(worker string) (*Job, error) {
+	leaseInterval := fmt.Sprintf("%d seconds", int(lease.Seconds()))
+	if _, err := database.DB.Exec(`
+		UPDATE jobs SET status = 'morto', locked_at = NULL, updated_at = NOW(),
+			last_error = 'lease expirado na última tentativa (worker interrompido durante a execução)'
+		WHERE status = 'executando' AND locked_at < NOW() - $1::interval AND attempts >= max_attempts`,
+		leaseInterval); err != nil {
+		return nil, err
+	}
+
+	job, err := scanJob(database.DB.QueryRow(`
+		UPDATE jobs SET status = 'executando', attempts = attempts + 1, locked_at = NOW(),
+			locked_by = $1, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'pendente' AND run_at <= NOW())
+				OR (status = 'executando' AND locked_at < NOW() - $2::interval AND attempts < max_attempts)
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns, worker, leaseInterval))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// Backoff é a espera antes da tentativa seguinte: exponencial a partir de 30s, até 1h, com jitter
+func Backoff(attempt int) time.Duration {
+	attempt = max(attempt, 1)
+	d := backoffMax
+	if attempt <= 12 {
+		d = min(backoffBase<<(attempt-1), backoffMax)
+	}
+	return d + rand.N(d/10+1)
+}
+
+// complete e fail só alteram o job enquanto a reserva é do worker: se o lease expirou e outro
+// worker o pegou, devolvem ErrLeaseLost
+func complete(job *Job, worker string) error {
+	result, err := database.DB.Exec(`
+		UPDATE jobs SET status = 'concluido', completed_at = NOW(), locked_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'executando' AND locked_by = $2`, job.ID, worker)
+	if err != nil {
+		return err
+	}
+	return checkLease(result)
+}
+
+// fail agenda uma nova tentativa ou, esgotadas as tentativas, move o job para dead-letter.
+// Devolve o novo status.
+func fail(job *Job, worker string, jobErr error) (string, error) {
+	status := STATUS_PENDENTE
+	if isPermanent(jobErr) || job.Tentativas >= job.MaxTentativas {
+		status = STATUS_MORTO
+	}
+	result, err := database.DB.Exec(`
+		UPDATE jobs SET status = $1, last_error = $2, run_at = $3, locked_at = NULL, updated_at = NOW()
+		WHERE id = $4 AND status = 'executando' AND locked_by = $5`,
+		status, jobErr.Error(), time.Now().Add(retryDelay(jobErr, job.Tentativas)), job.ID, worker)
+	if err != nil {
+		return status, err
+	}
+	return status, checkLease(result)
+}
+
+func checkLease(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// execute roda o handler do job com timeout, convertendo panics em erro
+func execute(ctx context.Context, job *Job) (err error) {
+	handler, ok := handlerFor(job.Tipo)
+	if !ok {
+		return Permanent(fmt.Errorf("tipo de job desconhecido: %s", job.Tipo))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return handler(ctx, job.Payload)
+}
+
+// RunOne reserva e executa um job. Devolve o job processado (nil se a fila estiver vazia) e
+// o status final dele.
+func RunOne(ctx context.Context, worker string) (*Job, string, error) {
+	job, err := claim(worker)
+	if err != nil || job == nil {
+		return nil, "", err
+	}
+
+	if jobErr := execute(ctx, job); jobErr != nil {
+		status, err := fail(job, worker, jobErr)
+		switch {
+		case errors.Is(err, ErrLeaseLost):
+			log.Printf("Job %d (%s) falhou depois de perder a reserva; o resultado fica com o outro worker: %v", job.ID, job.Tipo, jobErr)
+			return job, STATUS_EXECUTANDO, nil
+		case status == STATUS_MORTO:
+			log.Printf("Job %d (%s) movido para dead-letter após %d tentativas: %v", job.ID, job.Tipo, job.Tentativas, jobErr)
+		default:
+			log.Printf("Job %d (%s) falhou na tentativa %d: %v", job.ID, job.Tipo, job.Tentativas, jobErr)
+		}
+		return job, status, err
+	}
+	err = complete(job, worker)
+	if errors.Is(err, ErrLeaseLost) {
+		log.Printf("Job %d (%s) concluído depois de perder a reserva; o resultado fica com o outro worker", job.ID, job.Tipo)
+		return job, STATUS_EXECUTANDO, nil
+	}
+	return job, STATUS_CONCLUIDO, err
+}
+
+// DrainReport resume uma execução de Drain
+type DrainReport struct {
+	Processados int  `json:"processados"`
+	Concluidos  int  `json:"concluidos"`
+	Reagendados int  `json:"reagendados"`
+	Mortos      int  `json:"mortos"`
+	Restantes   bool `json:"restantes"`
+}
+
+// Drain executa jobs em sequência até esvaziar a fila, atingir max ou estourar o orçamento de
+// tempo. É a variante usada pelo cron em modo serverless.
+func Drain(ctx context.Context, worker string, max int, budget time.Duration) (*DrainReport, error) {
+	report := &DrainReport{}
+	deadline := time.Now().Add(budget)
+	for report.Processados < max {
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			report.Restantes = true
+			return report, nil
+		}
+		job, status, err := RunOne(ctx, worker)
+		if err != nil {
+			return report, err
+		}
+		if job == nil {
+			return report, nil
+		}
+		report.Processados++
+		switch status {
+		case STATUS_CONCLUIDO:
+			report.Concluidos++
+		case STATUS_MORTO:
+			report.Mortos++
+		case STATUS_EXECUTANDO:
+			// reserva perdida: outro worker conclui o job
+		default:
+			report.Reagendados++
+		}
+	}
+	report.Restantes = true
+	return report, nil
+}
+
+// Filter são os filtros da listagem de jobs
+type Filter struct {
+	Status string
+	Tipo   string
+}
+
+// List lista jobs, mais recentes primeiro, com paginação por cursor
+func List(f Filter, page *listing.Params) ([]Job, bool, error) {
+	query := listing.New("SELECT "+jobColumns+" FROM jobs").
+		WhereIf(f.Status != "", "status = ?", f.Status).
+		WhereIf(f.Tipo != "", "type = ?", f.Tipo)
+	page.Apply(query)
+
+	q, args := query.SQL()
+	rows, err := database.DB.Query(q, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	list := []Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		list = append(list, *j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := page.HasMore(len(list))
+	if hasMore {
+		list = list[:page.Limit]
+	}
+	return list, hasMore, nil
+}
+
+// Counts devolve a quantidade de jobs por status
+func Counts() (map[string]int, error) {
+	counts := map[string]int{}
+	for _, s := range ValidStatus {
+		counts[s] = 0
+	}
+	rows, err := database.DB.Query("SELECT status, COUNT(*) FROM jobs GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+func Get(id int) (*Job, error) {
+	job, err := scanJob(database.DB.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return job, err
+}
+
+// Retry devolve um job da dead-letter (ou já concluído) à fila, zerando as tentativas
+func Retry(id int) (*Job, error) {
+	job, err := scanJob(database.DB.QueryRow(`
+		UPDATE jobs SET status = 'pendente', attempts = 0, run_at = NOW(), last_error = NULL,
+			completed_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status IN ('morto', 'concluido')
+		RETURNING `+jobColumns, id))
+	if err == sql.ErrNoRows {
+		if _, getErr := Get(id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrNotRetryable
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		// Já existe um job ativo com a mesma unique_key
+		return nil, ErrNotRetryable
+	}
+	return job, err
+}
+
+// PurgeFinished remove jobs concluídos há mais de retention
+func PurgeFinished(retention time.Duration) (int, error) {
+	res, err := database.DB.Exec(`
+		DELETE FROM jobs WHERE status = 'concluido' AND completed_at < $1`, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
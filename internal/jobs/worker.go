@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Pool executa jobs continuamente com vários workers (modo servidor, iniciado em main.go)
+type Pool struct {
+	Workers      int
+	PollInterval time.Duration
+}
+
+func NewPool(workers int) *Pool {
+	return &Pool{Workers: workers, PollInterval: 2 * time.Second}
+}
+
+// Run bloqueia até ctx ser cancelado e os workers terminarem o job em andamento
+func (p *Pool) Run(ctx context.Context) {
+	host, _ := os.Hostname()
+	var wg sync.WaitGroup
+	for i := 1; i <= p.Workers; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			p.work(ctx, name)
+		}(fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i))
+	}
+	log.Printf("Fila de jobs: %d workers iniciados", p.Workers)
+	wg.Wait()
+	log.Println("Fila de jobs: workers encerrados")
+}
+
+// WorkerName gera um nome único por execução avulsa (ex.: cada chamada do cron). O nome é o dono
+// do lease em locked_by: duas execuções sobrepostas com o mesmo nome concluiriam os jobs uma da outra.
+func WorkerName(prefix string) string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return prefix + "-" + hex.EncodeToString(b)
+}
+
+func (p *Pool) work(ctx context.Context, name string) {
+	for ctx.Err() == nil {
+		// O job em andamento não herda o cancelamento: termina antes de o worker parar
+		job, _, err := RunOne(context.WithoutCancel(ctx), name)
+		if err != nil {
+			log.Printf("Worker %s: erro na fila de jobs: %v", name, err)
+		}
+		if job != nil && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package listing reúne o query builder e a paginação por cursor usados pelos endpoints de listagem.
+package listing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder monta um SELECT com filtros opcionais usando placeholders "?" convertidos para $n
+type Builder struct {
+	base   string
+	conds  []string
+	args   []interface{}
+	order  []string
+	limit  int
+	offset int
+}
+
+// New recebe a parte fixa da consulta, ex.: "SELECT id, nome FROM users"
+func New(base string) *Builder {
+	return &Builder{base: base}
+}
+
+// Where adiciona uma condição combinada com AND. Cada "?" da condição recebe um dos args, em ordem.
+func (b *Builder) Where(cond string, args ...interface{}) *Builder {
+	for _, arg := range args {
+		b.args = append(b.args, arg)
+		cond = strings.Replace(cond, "?", fmt.Sprintf("$%d", len(b.args)), 1)
+	}
+	b.conds = append(b.conds, "("+cond+")")
+	return b
+}
+
+// WhereIf adiciona a condição apenas quando ok é verdadeiro
+func (b *Builder) WhereIf(ok bool, cond string, args ...interface{}) *Builder {
+	if ok {
+		b.Where(cond, args...)
+	}
+	return b
+}
+
+func (b *Builder) OrderBy(clauses ...string) *Builder {
+	b.order = append(b.order, clauses...)
+	return b
+}
+
+func (b *Builder) Limit(n int) *Builder {
+	b.limit = n
+	return b
+}
+
+func (b *Builder) Offset(n int) *Builder {
+	b.offset = n
+	return b
+}
+
+// SQL retorna a consulta completa e seus argumentos
+func (b *Builder) SQL() (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString(b.base)
+	sb.WriteString(b.where())
+	if len(b.order) > 0 {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(b.order, ", "))
+	}
+
+	args := append([]interface{}{}, b.args...)
+	if b.limit > 0 {
+		args = append(args, b.limit)
+		sb.WriteString(fmt.Sprintf(" LIMIT $%d", len(args)))
+	}
+	if b.offset > 0 {
+		args = append(args, b.offset)
+		sb.WriteString(fmt.Sprintf(" OFFSET $%d", len(args)))
+	}
+	return sb.String(), args
+}
+
+// CountSQL retorna um COUNT(*) com os filtros atuais, sem ordenação nem paginação
+func (b *Builder) CountSQL() (string, []interface{}) {
+	return "SELECT COUNT(*) FROM (" + b.base + b.where() + ") AS counted", append([]interface{}{}, b.args...)
+}
+
+func (b *Builder) where() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// PrefixPattern escapa os curingas do LIKE e monta o padrão de busca por prefixo
+func PrefixPattern(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s) + "%"
+}
@@ -0,0 +1,131 @@
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var (
+	ErrInvalidLimit  = errors.New("limit inválido")
+	ErrInvalidSort   = errors.New("sort inválido")
+	ErrInvalidCursor = errors.New("cursor inválido")
+)
+
+// SortField mapeia uma opção de ordenação da API para a coluna e o tipo usado na comparação do cursor.
+// A coluna não pode ser nula, e o desempate é sempre feito pela coluna id.
+type SortField struct {
+	Column string
+	Cast   string // ex.: "timestamptz", "text"
+}
+
+// Cursor aponta para a última linha da página anterior (valor da ordenação + id)
+type Cursor struct {
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Params são os parâmetros de paginação por cursor lidos da query string:
+// limit, cursor, sort e order (asc|desc)
+type Params struct {
+	Limit  int
+	Sort   string
+	Desc   bool
+	Cursor *Cursor
+	field  SortField
+	idCol  string
+}
+
+// ParseParams valida limit/cursor/sort/order contra as opções de ordenação permitidas.
+// idColumn é a coluna de desempate (ex.: "id" ou "u.id").
+func ParseParams(q url.Values, fields map[string]SortField, defaultSort string, defaultDesc bool, idColumn string) (*Params, error) {
+	p := &Params{Limit: DefaultLimit, Sort: defaultSort, Desc: defaultDesc, idCol: idColumn}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return nil, ErrInvalidLimit
+		}
+		p.Limit = min(limit, MaxLimit)
+	}
+
+	if v := q.Get("sort"); v != "" {
+		p.Sort = v
+	}
+	field, ok := fields[p.Sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: use %s", ErrInvalidSort, strings.Join(sortedKeys(fields), ", "))
+	}
+	p.field = field
+
+	switch strings.ToLower(q.Get("order")) {
+	case "":
+	case "asc":
+		p.Desc = false
+	case "desc":
+		p.Desc = true
+	default:
+		return nil, fmt.Errorf("%w: order deve ser asc ou desc", ErrInvalidSort)
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		var c Cursor
+		if err := json.Unmarshal(raw, &c); err != nil || c.ID <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		p.Cursor = &c
+	}
+
+	return p, nil
+}
+
+// Apply adiciona ao builder a condição do cursor, a ordenação e o limite (com uma linha extra
+// para detectar se há próxima página)
+func (p *Params) Apply(b *Builder) {
+	dir, cmp := "ASC", ">"
+	if p.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if p.Cursor != nil {
+		b.Where(fmt.Sprintf("(%s, %s) %s (?::%s, ?)", p.field.Column, p.idCol, cmp, p.field.Cast),
+			p.Cursor.Value, p.Cursor.ID)
+	}
+
+	b.OrderBy(p.field.Column+" "+dir, p.idCol+" "+dir)
+	b.Limit(p.Limit + 1)
+}
+
+// HasMore indica se foram lidas mais linhas que o limite (a linha extra deve ser descartada)
+func (p *Params) HasMore(rows int) bool {
+	return rows > p.Limit
+}
+
+// EncodeCursor gera o cursor opaco para a próxima página
+func EncodeCursor(value string, id int) string {
+	raw, _ := json.Marshal(Cursor{Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func sortedKeys(fields map[string]SortField) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEvent struct {
+	ID             int64           `json:"id"`
+	Action         string          `json:"action"`
+	ActorID        *int            `json:"actor_id,omitempty"`
+	ImpersonatorID *int            `json:"impersonator_id,omitempty"`
+	TargetType     *string         `json:"target_type,omitempty"`
+	TargetID       *int            `json:"target_id,omitempty"`
+	IP             *string         `json:"ip,omitempty"`
+	UserAgent      *string         `json:"user_agent,omitempty"`
+	Diff           json.RawMessage `json:"diff,omitempty"`
+	Metadata       json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
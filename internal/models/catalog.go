@@ -0,0 +1,128 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	EVENTO_AGENDADO  = "agendado"
+	EVENTO_AO_VIVO   = "ao_vivo"
+	EVENTO_ENCERRADO = "encerrado"
+	EVENTO_ADIADO    = "adiado"
+	EVENTO_CANCELADO = "cancelado"
+)
+
+var ValidStatusEvento = []string{EVENTO_AGENDADO, EVENTO_AO_VIVO, EVENTO_ENCERRADO, EVENTO_ADIADO, EVENTO_CANCELADO}
+
+type Sport struct {
+	ID        int       `json:"id"`
+	Nome      string    `json:"nome"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Competition struct {
+	ID        int       `json:"id"`
+	SportID   int       `json:"sport_id"`
+	Nome      string    `json:"nome"`
+	Slug      string    `json:"slug"`
+	Pais      *string   `json:"pais,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Team struct {
+	ID        int       `json:"id"`
+	SportID   int       `json:"sport_id"`
+	Nome      string    `json:"nome"`
+	Slug      string    `json:"slug"`
+	Pais      *string   `json:"pais,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Event é uma partida (fixture) do catálogo. Nome é gerado como "Mandante x Visitante".
+type Event struct {
+	ID            int       `json:"id"`
+	CompetitionID int       `json:"competition_id"`
+	HomeTeamID    int       `json:"home_team_id"`
+	AwayTeamID    int       `json:"away_team_id"`
+	Nome          string    `json:"nome"`
+	KickoffAt     time.Time `json:"kickoff_at"`
+	Status        string    `json:"status"`
+	ExternalRef   *string   `json:"external_ref,omitempty"`
+	HomeScore     *int      `json:"home_score,omitempty"`
+	AwayScore     *int      `json:"away_score,omitempty"`
+	Competicao    string    `json:"competicao"`
+	Mandante      string    `json:"mandante"`
+	Visitante     string    `json:"visitante"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type CatalogItemRequest struct {
+	SportID int     `json:"sport_id,omitempty"`
+	Nome    string  `json:"nome"`
+	Slug    string  `json:"slug,omitempty"`
+	Pais    *string `json:"pais,omitempty"`
+}
+
+type EventRequest struct {
+	CompetitionID int       `json:"competition_id"`
+	HomeTeamID    int       `json:"home_team_id"`
+	AwayTeamID    int       `json:"away_team_id"`
+	KickoffAt     time.Time `json:"kickoff_at"`
+	Status        string    `json:"status,omitempty"`
+	ExternalRef   *string   `json:"external_ref,omitempty"`
+}
+
+// CatalogImportRow é uma linha da importação em lote (JSON ou CSV), referenciando tudo por nome
+type CatalogImportRow struct {
+	Esporte     string `json:"esporte"`
+	Competicao  string `json:"competicao"`
+	Pais        string `json:"pais,omitempty"`
+	Mandante    string `json:"mandante"`
+	Visitante   string `json:"visitante"`
+	Inicio      string `json:"inicio"`
+	ExternalRef string `json:"ref,omitempty"`
+}
+
+// AcceptsPicks indica se ainda é possível publicar palpites para o evento (antes do início)
+func (e *Event) AcceptsPicks(now time.Time) bool {
+	if e.Status != EVENTO_AGENDADO && e.Status != EVENTO_ADIADO {
+		return false
+	}
+	return now.Before(e.KickoffAt)
+}
+
+func IsValidStatusEvento(status string) bool {
+	for _, s := range ValidStatusEvento {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Slugify gera um identificador em minúsculas, sem acentos e separado por hífens ("São Paulo" -> "sao-paulo")
+func Slugify(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	plain, _, _ := transform.String(t, s)
+
+	var b strings.Builder
+	lastDash := true
+	for _, r := range strings.ToLower(plain) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const MaxComentarioLen = 1000
+
+// Comment é um comentário em um palpite
+type Comment struct {
+	ID          int       `json:"id"`
+	PalpiteID   int       `json:"palpite_id"`
+	UserID      int       `json:"user_id"`
+	AutorNome   string    `json:"autor_nome"`
+	AutorAvatar *string   `json:"autor_avatar,omitempty"`
+	Texto       string    `json:"texto"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CommentRequest struct {
+	Texto string `json:"texto"`
+}
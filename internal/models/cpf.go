@@ -0,0 +1,59 @@
+package models
+
+import "strings"
+
+// NormalizeCPF remove pontuação e espaços, mantendo apenas os dígitos do CPF
+func NormalizeCPF(cpf string) string {
+	var b strings.Builder
+	for _, c := range cpf {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// IsValidCPF valida o tamanho e os dígitos verificadores do CPF (aceita com ou sem pontuação)
+func IsValidCPF(cpf string) bool {
+	digits := NormalizeCPF(cpf)
+	if len(digits) != 11 {
+		return false
+	}
+
+	// Sequências repetidas (000.000.000-00, 111.111.111-11...) passam no cálculo mas são inválidas
+	repeated := true
+	for i := 1; i < 11; i++ {
+		if digits[i] != digits[0] {
+			repeated = false
+			break
+		}
+	}
+	if repeated {
+		return false
+	}
+
+	return cpfCheckDigit(digits[:9]) == digits[9] && cpfCheckDigit(digits[:10]) == digits[10]
+}
+
+func cpfCheckDigit(base string) byte {
+	sum := 0
+	weight := len(base) + 1
+	for i := 0; i < len(base); i++ {
+		sum += int(base[i]-'0') * weight
+		weight--
+	}
+	rest := (sum * 10) % 11
+	if rest == 10 {
+		rest = 0
+	}
+	return byte('0' + rest)
+}
+
+// MaskCPF oculta o CPF mantendo apenas os dígitos verificadores (***.***.***-12)
+func MaskCPF(cpf string) string {
+	digits := NormalizeCPF(cpf)
+	if len(digits) < 2 {
+		return "***.***.***-**"
+	}
+	return "***.***.***-" + digits[len(digits)-2:]
+}
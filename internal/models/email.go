@@ -0,0 +1,10 @@
+package models
+
+import "net/mail"
+
+// IsValidEmail aceita apenas o endereço puro (usuario@dominio): formas como "Nome <a@b.com>"
+// ou com espaços ao redor, que o net/mail também entende, são recusadas
+func IsValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
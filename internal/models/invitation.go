@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type AdminInvitation struct {
+	ID         int        `json:"id"`
+	Email      string     `json:"email"`
+	Perfil     string     `json:"perfil"`
+	InvitedBy  *int       `json:"invited_by"` // nil se a conta de quem convidou foi removida
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy *int       `json:"accepted_by,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateInvitationRequest struct {
+	Email  string `json:"email"`
+	Perfil string `json:"perfil,omitempty"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// IsPending indica se o convite ainda pode ser aceito
+func (i *AdminInvitation) IsPending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"smartpicks-backend/internal/odds"
+)
+
+// Mercados suportados pela liquidação automática
+const (
+	MERCADO_1X2        = "1x2"
+	MERCADO_OVER_UNDER = "over_under"
+	MERCADO_AMBAS      = "ambas_marcam"
+	MERCADO_HANDICAP   = "handicap"
+)
+
+// Seleções por mercado: 1x2 e handicap usam casa/empate/fora; over_under usa over/under;
+// ambas_marcam usa sim/nao
+const (
+	SELECAO_CASA   = "casa"
+	SELECAO_EMPATE = "empate"
+	SELECAO_FORA   = "fora"
+	SELECAO_OVER   = "over"
+	SELECAO_UNDER  = "under"
+	SELECAO_SIM    = "sim"
+	SELECAO_NAO    = "nao"
+)
+
+// Status de liquidação do palpite
+const (
+	STATUS_PENDENTE   = "pendente"
+	STATUS_GREEN      = "green"
+	STATUS_RED        = "red"
+	STATUS_MEIO_GREEN = "meio_green"
+	STATUS_MEIO_RED   = "meio_red"
+	STATUS_ANULADO    = "anulado"
+)
+
+// DefaultStake é a stake (em unidades) usada quando o palpite não informa uma
+const DefaultStake = 1.0
+
+var selecoesPorMercado = map[string][]string{
+	MERCADO_1X2:        {SELECAO_CASA, SELECAO_EMPATE, SELECAO_FORA},
+	MERCADO_OVER_UNDER: {SELECAO_OVER, SELECAO_UNDER},
+	MERCADO_AMBAS:      {SELECAO_SIM, SELECAO_NAO},
+	MERCADO_HANDICAP:   {SELECAO_CASA, SELECAO_FORA},
+}
+
+// EventResult é o placar final de um evento
+type EventResult struct {
+	EventID     int        `json:"event_id,omitempty"`
+	ExternalRef string     `json:"ref,omitempty"`
+	HomeScore   int        `json:"home_score"`
+	AwayScore   int        `json:"away_score"`
+	Status      string     `json:"status,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// ResultsRequest é o corpo de POST /api/admin/results
+type ResultsRequest struct {
+	Results []EventResult `json:"results"`
+	DryRun  bool          `json:"dry_run"`
+}
+
+// SettlementChange descreve a liquidação (aplicada ou simulada) de um palpite
+type SettlementChange struct {
+	PalpiteID int     `json:"palpite_id"`
+	UserID    int     `json:"user_id"`
+	EventID   int     `json:"event_id"`
+	Mercado   string  `json:"mercado,omitempty"`
+	Selecao   string  `json:"selecao,omitempty"`
+	Linha     float64 `json:"linha,omitempty"`
+	Status    string  `json:"status"`
+	Lucro     float64 `json:"lucro"`
+}
+
+// SettlementReport resume uma execução do liquidante
+type SettlementReport struct {
+	DryRun  bool               `json:"dry_run"`
+	Results int                `json:"results"`
+	Settled []SettlementChange `json:"settled"`
+	Skipped int                `json:"skipped"`
+	Errors  []string           `json:"errors,omitempty"`
+	JobID   int                `json:"job_id,omitempty"` // liquidação enfileirada (ver jobs.SettleEvents)
+}
+
+// Validate confere se mercado, seleção, linha, odd e stake formam uma aposta liquidável.
+// Palpites sem mercado são aceitos e não entram na liquidação automática.
+func (m PalpiteMarket) Validate() error {
+	if m.Mercado == nil {
+		if m.Selecao != nil || m.Linha != nil {
+			return errors.New("selecao e linha exigem mercado")
+		}
+		return nil
+	}
+
+	selecoes, ok := selecoesPorMercado[*m.Mercado]
+	if !ok {
+		return errors.New("mercado inválido. Use 1x2, over_under, ambas_marcam ou handicap")
+	}
+	if m.Selecao == nil || !contains(selecoes, *m.Selecao) {
+		return errors.New("selecao inválida para o mercado " + *m.Mercado)
+	}
+
+	switch *m.Mercado {
+	case MERCADO_OVER_UNDER, MERCADO_HANDICAP:
+		if m.Linha == nil || !isQuarterLine(*m.Linha) {
+			return errors.New("linha obrigatória em múltiplos de 0.25 para " + *m.Mercado)
+		}
+		if *m.Mercado == MERCADO_OVER_UNDER && *m.Linha <= 0 {
+			return errors.New("linha de over_under deve ser positiva")
+		}
+	default:
+		if m.Linha != nil {
+			return errors.New("linha não se aplica ao mercado " + *m.Mercado)
+		}
+	}
+
+	if m.Odd != nil && *m.Odd <= 1 {
+		return odds.ErrInvalid
+	}
+	if m.Stake != nil && *m.Stake <= 0 {
+		return errors.New("stake deve ser positiva")
+	}
+	return nil
+}
+
+func isQuarterLine(line float64) bool {
+	q := line * 4
+	return math.Abs(q-math.Round(q)) < 1e-9
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+const (
+	SUSPENSAO_TEMPORARIA = "suspensao"
+	SUSPENSAO_BANIMENTO  = "banimento"
+)
+
+// Ações registradas no histórico de moderação (admin_actions)
+const (
+	ACAO_ALTERAR_PERFIL = "alterar_perfil"
+	ACAO_SUSPENDER      = "suspender"
+	ACAO_BANIR          = "banir"
+	ACAO_REATIVAR       = "reativar"
+	ACAO_RESET_SENHA    = "forcar_reset_senha"
+	ACAO_PERSONIFICAR   = "personificar"
+	ACAO_EXCLUIR        = "excluir"
+	ACAO_RESET_2FA      = "resetar_2fa"
+)
+
+type UserSuspension struct {
+	Tipo   string     `json:"tipo"`
+	Ate    *time.Time `json:"ate,omitempty"`
+	Motivo *string    `json:"motivo,omitempty"`
+	Ativa  bool       `json:"ativa"`
+}
+
+type AdminAction struct {
+	ID           int                    `json:"id"`
+	AdminID      *int                   `json:"admin_id"` // nil se a conta do admin foi removida
+	TargetUserID int                    `json:"target_user_id"`
+	Acao         string                 `json:"acao"`
+	Motivo       *string                `json:"motivo,omitempty"`
+	Detalhes     map[string]interface{} `json:"detalhes,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+type ChangePerfilRequest struct {
+	Perfil string `json:"perfil"`
+}
+
+type SuspendUserRequest struct {
+	Tipo   string `json:"tipo"`
+	Motivo string `json:"motivo"`
+	Dias   int    `json:"dias,omitempty"`
+}
+
+type ModerationReasonRequest struct {
+	Motivo string `json:"motivo"`
+}
+
+type ResetPasswordRequest struct {
+	Token     string `json:"token"`
+	NovaSenha string `json:"nova_senha"`
+}
+
+// IsSuspended indica se há suspensão vigente; banimentos não expiram
+func (u *User) IsSuspended(now time.Time) bool {
+	if u.SuspensaoTipo == nil {
+		return false
+	}
+	return u.SuspensoAte == nil || now.Before(*u.SuspensoAte)
+}
+
+// IsDeleted indica se a conta foi excluída (soft delete) por um administrador
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
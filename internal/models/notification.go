@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Tipos de notificação
+const (
+	NOTIFICACAO_NOVO_PALPITE      = "novo_palpite"      // tipster seguido publicou um palpite
+	NOTIFICACAO_COMENTARIO        = "comentario"        // comentaram no seu palpite
+	NOTIFICACAO_PALPITE_LIQUIDADO = "palpite_liquidado" // seu palpite foi liquidado
+	NOTIFICACAO_NOVO_SEGUIDOR     = "novo_seguidor"
+)
+
+// Notification é um aviso da central de notificações do usuário
+type Notification struct {
+	ID        int             `json:"id"`
+	UserID    int             `json:"user_id"`
+	Tipo      string          `json:"tipo"`
+	AtorID    *int            `json:"ator_id,omitempty"`
+	AtorNome  *string         `json:"ator_nome,omitempty"`
+	PalpiteID *int            `json:"palpite_id,omitempty"`
+	Mensagem  string          `json:"mensagem"`
+	Dados     json.RawMessage `json:"dados,omitempty"`
+	Lida      bool            `json:"lida"`
+	ReadAt    *time.Time      `json:"read_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
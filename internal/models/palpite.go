@@ -1,32 +1,124 @@
 package models
 
-import "time"
+import (
+	"math"
+	"time"
+
+	"smartpicks-backend/internal/odds"
+)
 
 type Palpite struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Titulo    *string   `json:"titulo,omitempty"`
-	ImgURL    string    `json:"img_url"`
-	Link      *string   `json:"link,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Titulo    *string    `json:"titulo,omitempty"`
+	ImgURL    string     `json:"img_url"`
+	Link      *string    `json:"link,omitempty"`
+	EventID   *int       `json:"event_id,omitempty"`
+	Mercado   *string    `json:"mercado,omitempty"`
+	Selecao   *string    `json:"selecao,omitempty"`
+	Linha     *float64   `json:"linha,omitempty"`
+	Odd       *float64   `json:"odd,omitempty"`
+	Stake     float64    `json:"stake"`
+	Status    string     `json:"status"`
+	Lucro     *float64   `json:"lucro,omitempty"`
+	SettledAt *time.Time `json:"settled_at,omitempty"`
+	// Visibilidade restringe quem vê o conteúdo completo (ver VISIBILIDADE_*)
+	Visibilidade string `json:"visibilidade"`
+	// Rascunhos e palpites agendados (PublishAt no futuro) só aparecem para o autor até PublishedAt
+	Rascunho        bool       `json:"rascunho"`
+	PublishAt       *time.Time `json:"publish_at,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	RevelarNoInicio bool       `json:"revelar_no_inicio"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// PalpiteColumns são as colunas lidas por ScanPalpite, na mesma ordem
+const PalpiteColumns = `id, user_id, titulo, img_url, link, event_id, mercado, selecao, linha, odd, stake,
+	status, lucro, settled_at, visibility, is_draft, publish_at, published_at, reveal_at_kickoff,
+	created_at, updated_at`
+
+// ScanPalpite lê uma linha selecionada com PalpiteColumns; extra recebe colunas adicionais
+func ScanPalpite(row RowScanner, extra ...interface{}) (*Palpite, error) {
+	var p Palpite
+	dest := []interface{}{&p.ID, &p.UserID, &p.Titulo, &p.ImgURL, &p.Link, &p.EventID, &p.Mercado, &p.Selecao,
+		&p.Linha, &p.Odd, &p.Stake, &p.Status, &p.Lucro, &p.SettledAt, &p.Visibilidade,
+		&p.Rascunho, &p.PublishAt, &p.PublishedAt, &p.RevelarNoInicio, &p.CreatedAt, &p.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &p, nil
 }
 
 type CreatePalpiteRequest struct {
-	UserID int     `json:"user_id" binding:"required"`
-	Titulo *string `json:"titulo,omitempty"`
-	ImgURL string  `json:"img_url" binding:"required"`
-	Link   *string `json:"link,omitempty"`
+	UserID       int     `json:"user_id" binding:"required"`
+	Titulo       *string `json:"titulo,omitempty"`
+	ImgURL       string  `json:"img_url" binding:"required"`
+	Link         *string `json:"link,omitempty"`
+	EventID      *int    `json:"event_id,omitempty"`
+	Visibilidade *string `json:"visibilidade,omitempty"`
+	PalpiteSchedule
+	PalpiteMarket
+}
+
+// PalpiteSchedule controla a publicação: rascunho, publicação agendada e revelação automática
+// do conteúdo restrito no início do evento
+type PalpiteSchedule struct {
+	Rascunho        *bool      `json:"rascunho,omitempty"`
+	PublishAt       *time.Time `json:"publish_at,omitempty"`
+	RevelarNoInicio *bool      `json:"revelar_no_inicio,omitempty"`
+}
+
+// PalpiteMarket descreve a aposta sugerida, usada na liquidação automática (ver Validate).
+// A odd pode ser enviada em qualquer formato e é gravada como decimal.
+type PalpiteMarket struct {
+	Mercado *string     `json:"mercado,omitempty"`
+	Selecao *string     `json:"selecao,omitempty"`
+	Linha   *float64    `json:"linha,omitempty"`
+	Odd     *odds.Input `json:"odd,omitempty"`
+	Stake   *float64    `json:"stake,omitempty"`
+}
+
+// UpdatePalpiteRequest é o corpo de PUT /api/palpites/{id}; campos ausentes não são alterados
+type UpdatePalpiteRequest struct {
+	Titulo       *string `json:"titulo,omitempty"`
+	ImgURL       *string `json:"img_url,omitempty"`
+	Link         *string `json:"link,omitempty"`
+	EventID      *int    `json:"event_id,omitempty"`
+	Visibilidade *string `json:"visibilidade,omitempty"`
+	PalpiteSchedule
+	PalpiteMarket
 }
 
 type PalpiteResponse struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Titulo    *string   `json:"titulo,omitempty"`
-	ImgURL    string    `json:"img_url"`
-	Link      *string   `json:"link,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              int        `json:"id"`
+	UserID          int        `json:"user_id"`
+	Titulo          *string    `json:"titulo,omitempty"`
+	ImgURL          string     `json:"img_url"`
+	Link            *string    `json:"link,omitempty"`
+	EventID         *int       `json:"event_id,omitempty"`
+	Mercado         *string    `json:"mercado,omitempty"`
+	Selecao         *string    `json:"selecao,omitempty"`
+	Linha           *float64   `json:"linha,omitempty"`
+	Odd             *float64   `json:"odd,omitempty"`
+	Stake           float64    `json:"stake"`
+	Status          string     `json:"status"`
+	Lucro           *float64   `json:"lucro,omitempty"`
+	SettledAt       *time.Time `json:"settled_at,omitempty"`
+	Visibilidade    string     `json:"visibilidade"`
+	Rascunho        bool       `json:"rascunho,omitempty"`
+	PublishAt       *time.Time `json:"publish_at,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	RevelarNoInicio bool       `json:"revelar_no_inicio,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Bloqueado indica uma prévia sem o conteúdo do palpite, para quem não tem acesso à visibilidade
+	Bloqueado bool `json:"bloqueado,omitempty"`
+
+	// OddFormatada é a odd no formato preferido do usuário (formato_odds do perfil)
+	OddFormatada           *string  `json:"odd_formatada,omitempty"`
+	ProbabilidadeImplicita *float64 `json:"probabilidade_implicita,omitempty"`
 }
 
 type UploadResponse struct {
@@ -36,24 +128,134 @@ type UploadResponse struct {
 
 func (p *Palpite) ToResponse() PalpiteResponse {
 	return PalpiteResponse{
-		ID:        p.ID,
-		UserID:    p.UserID,
-		Titulo:    p.Titulo,
-		ImgURL:    p.ImgURL,
-		Link:      p.Link,
-		CreatedAt: p.CreatedAt,
-		UpdatedAt: p.UpdatedAt,
+		ID:              p.ID,
+		UserID:          p.UserID,
+		Titulo:          p.Titulo,
+		ImgURL:          p.ImgURL,
+		Link:            p.Link,
+		EventID:         p.EventID,
+		Mercado:         p.Mercado,
+		Selecao:         p.Selecao,
+		Linha:           p.Linha,
+		Odd:             p.Odd,
+		Stake:           p.Stake,
+		Status:          p.Status,
+		Lucro:           p.Lucro,
+		SettledAt:       p.SettledAt,
+		Visibilidade:    p.Visibilidade,
+		Rascunho:        p.Rascunho,
+		PublishAt:       p.PublishAt,
+		PublishedAt:     p.PublishedAt,
+		RevelarNoInicio: p.RevelarNoInicio,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
 	}
 }
 
+// RenderOdds preenche odd_formatada e probabilidade_implicita; com hide (jogo responsável)
+// a odd é removida da resposta
+func (resp *PalpiteResponse) RenderOdds(format string, hide bool) {
+	if hide {
+		resp.Odd = nil
+		resp.OddFormatada = nil
+		resp.ProbabilidadeImplicita = nil
+		return
+	}
+	if resp.Odd == nil {
+		return
+	}
+	formatted := odds.Format(*resp.Odd, format)
+	probability := math.Round(odds.ImpliedProbability(*resp.Odd)*10000) / 10000
+	resp.OddFormatada = &formatted
+	resp.ProbabilidadeImplicita = &probability
+}
+
+// Lock transforma a resposta em prévia bloqueada: mantém título, evento e status, mas remove
+// a imagem, o link e tudo que revela a aposta (mercado, seleção, linha, odd e lucro)
+func (resp *PalpiteResponse) Lock() {
+	resp.Bloqueado = true
+	resp.ImgURL = ""
+	resp.Link = nil
+	resp.Mercado = nil
+	resp.Selecao = nil
+	resp.Linha = nil
+	resp.Odd = nil
+	resp.OddFormatada = nil
+	resp.ProbabilidadeImplicita = nil
+	resp.Lucro = nil
+}
+
 func (req *CreatePalpiteRequest) ToPalpite() Palpite {
 	now := time.Now()
-	return Palpite{
-		UserID:    req.UserID,
-		Titulo:    req.Titulo,
-		ImgURL:    req.ImgURL,
-		Link:      req.Link,
-		CreatedAt: now,
-		UpdatedAt: now,
+	p := Palpite{
+		UserID:       req.UserID,
+		Titulo:       req.Titulo,
+		ImgURL:       req.ImgURL,
+		Link:         req.Link,
+		EventID:      req.EventID,
+		Status:       STATUS_PENDENTE,
+		Stake:        DefaultStake,
+		Visibilidade: VISIBILIDADE_PUBLICO,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if req.Visibilidade != nil {
+		p.Visibilidade = *req.Visibilidade
+	}
+	p.ApplyMarket(req.PalpiteMarket)
+	p.ApplySchedule(req.PalpiteSchedule, now)
+	return p
+}
+
+// ApplySchedule aplica rascunho/agendamento e recalcula PublishedAt: rascunhos não são publicados,
+// agendamentos futuros aguardam o scheduler e os demais são publicados em now. Palpites já
+// publicados não voltam a ser rascunho nem mudam de data.
+func (p *Palpite) ApplySchedule(s PalpiteSchedule, now time.Time) {
+	if s.RevelarNoInicio != nil {
+		p.RevelarNoInicio = *s.RevelarNoInicio
+	}
+	if p.IsPublished(now) {
+		return
+	}
+
+	if s.Rascunho != nil {
+		p.Rascunho = *s.Rascunho
+	}
+	if s.PublishAt != nil {
+		p.PublishAt = s.PublishAt
+	}
+
+	switch {
+	case p.Rascunho:
+		p.PublishedAt = nil
+	case p.PublishAt != nil && p.PublishAt.After(now):
+		p.PublishedAt = nil
+	default:
+		p.PublishedAt = &now
+	}
+}
+
+// IsPublished indica se o palpite já está visível para outros usuários
+func (p *Palpite) IsPublished(now time.Time) bool {
+	return p.PublishedAt != nil && !p.PublishedAt.After(now)
+}
+
+// ApplyMarket copia para o palpite os campos de mercado informados
+func (p *Palpite) ApplyMarket(m PalpiteMarket) {
+	if m.Mercado != nil {
+		p.Mercado = m.Mercado
+	}
+	if m.Selecao != nil {
+		p.Selecao = m.Selecao
+	}
+	if m.Linha != nil {
+		p.Linha = m.Linha
+	}
+	if m.Odd != nil {
+		odd := float64(*m.Odd)
+		p.Odd = &odd
+	}
+	if m.Stake != nil {
+		p.Stake = *m.Stake
 	}
 }
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	DELECAO_PENDENTE  = "pendente"
+	DELECAO_CANCELADA = "cancelada"
+	DELECAO_CONCLUIDA = "concluida"
+)
+
+// DeletionRequest é o registro (auditoria LGPD) de uma solicitação de exclusão de conta.
+// Não guarda dados pessoais além do hash do email, pois sobrevive à remoção do usuário.
+type DeletionRequest struct {
+	ID                int        `json:"id"`
+	UserID            int        `json:"user_id"`
+	Status            string     `json:"status"`
+	RequestedAt       time.Time  `json:"requested_at"`
+	ScheduledFor      time.Time  `json:"scheduled_for"`
+	CancelledAt       *time.Time `json:"cancelled_at,omitempty"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+	PalpitesRemovidos int        `json:"palpites_removidos"`
+	ArquivosRemovidos int        `json:"arquivos_removidos"`
+}
+
+// DataExport reúne tudo o que é armazenado sobre o usuário (direito de acesso/portabilidade da LGPD)
+type DataExport struct {
+	GeradoEm            time.Time         `json:"gerado_em"`
+	Usuario             UserResponse      `json:"usuario"`
+	Palpites            []PalpiteResponse `json:"palpites"`
+	Arquivos            []UploadedFile    `json:"arquivos"`
+	JogoResponsavel     *GamblingSettings `json:"jogo_responsavel,omitempty"`
+	SolicitacoesDelecao []DeletionRequest `json:"solicitacoes_delecao"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+)
+
+const (
+	BioMaxLen             = 500
+	EsportesFavoritosMax  = 10
+	EsporteFavoritoMaxLen = 50
+)
+
+// RedesSociaisPermitidas são as chaves aceitas em redes_sociais
+var RedesSociaisPermitidas = map[string]bool{
+	"instagram": true,
+	"x":         true,
+	"telegram":  true,
+	"youtube":   true,
+	"tiktok":    true,
+	"discord":   true,
+	"site":      true,
+}
+
+// UpdateProfileRequest é o corpo de PATCH /api/me; campos ausentes não são alterados
+type UpdateProfileRequest struct {
+	Nome              *string            `json:"nome,omitempty"`
+	Email             *string            `json:"email,omitempty"`
+	DataNascimento    *string            `json:"data_nascimento,omitempty"`
+	Bio               *string            `json:"bio,omitempty"`
+	EsportesFavoritos *[]string          `json:"esportes_favoritos,omitempty"`
+	RedesSociais      *map[string]string `json:"redes_sociais,omitempty"`
+	FormatoOdds       *string            `json:"formato_odds,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	SenhaAtual string `json:"senha_atual"`
+	NovaSenha  string `json:"nova_senha"`
+}
+
+// NormalizeEsportes remove espaços e duplicados, preservando a ordem informada
+func NormalizeEsportes(esportes []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, e := range esportes {
+		e = strings.TrimSpace(e)
+		key := strings.ToLower(e)
+		if e == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, e)
+	}
+	return result
+}
+
+// IsValidSocialLink aceita apenas URLs http(s) absolutas
+func IsValidSocialLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+const (
+	EXCLUSAO_PAUSA        = "pausa"        // cool-off temporário
+	EXCLUSAO_AUTOEXCLUSAO = "autoexclusao" // autoexclusão longa ou por tempo indeterminado
+)
+
+const (
+	PausaMinDias        = 1
+	PausaMaxDias        = 90
+	AutoexclusaoMinDias = 180
+)
+
+type GamblingSettings struct {
+	UserID         int        `json:"user_id"`
+	OcultarOdds    bool       `json:"ocultar_odds"`
+	TipoExclusao   *string    `json:"tipo_exclusao,omitempty"`
+	ExcluidoDesde  *time.Time `json:"excluido_desde,omitempty"`
+	ExcluidoAte    *time.Time `json:"excluido_ate,omitempty"`
+	MotivoExclusao *string    `json:"motivo_exclusao,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ExclusaoAtiva  bool       `json:"exclusao_ativa"`
+}
+
+type UpdateGamblingSettingsRequest struct {
+	OcultarOdds  *bool   `json:"ocultar_odds,omitempty"`
+	TipoExclusao string  `json:"tipo_exclusao,omitempty"`
+	Dias         int     `json:"dias,omitempty"`
+	Motivo       *string `json:"motivo,omitempty"`
+}
+
+type ExclusionResponse struct {
+	UserID        int        `json:"user_id"`
+	Nome          string     `json:"nome"`
+	Email         string     `json:"email"`
+	TipoExclusao  string     `json:"tipo_exclusao"`
+	ExcluidoDesde *time.Time `json:"excluido_desde,omitempty"`
+	ExcluidoAte   *time.Time `json:"excluido_ate,omitempty"`
+	Motivo        *string    `json:"motivo,omitempty"`
+}
+
+// IsExcluded indica se há uma pausa/autoexclusão vigente (ExcluidoAte nulo = tempo indeterminado)
+func (s *GamblingSettings) IsExcluded(now time.Time) bool {
+	if s.TipoExclusao == nil {
+		return false
+	}
+	return s.ExcluidoAte == nil || now.Before(*s.ExcluidoAte)
+}
+
+func IsValidTipoExclusao(tipo string) bool {
+	return tipo == EXCLUSAO_PAUSA || tipo == EXCLUSAO_AUTOEXCLUSAO
+}
@@ -0,0 +1,23 @@
+package models
+
+const (
+	SEARCH_PALPITE = "palpite"
+	SEARCH_TIPSTER = "tipster"
+)
+
+// TipsterSummary é o perfil público de um tipster exibido em buscas
+type TipsterSummary struct {
+	ID            int     `json:"id"`
+	Nome          string  `json:"nome"`
+	Avatar        *string `json:"avatar,omitempty"`
+	Bio           *string `json:"bio,omitempty"`
+	TotalPalpites int     `json:"total_palpites"`
+}
+
+// SearchHit é um resultado da busca; apenas o campo correspondente ao tipo é preenchido
+type SearchHit struct {
+	Type    string           `json:"type"`
+	Rank    float64          `json:"rank"`
+	Palpite *PalpiteResponse `json:"palpite,omitempty"`
+	Tipster *TipsterSummary  `json:"tipster,omitempty"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Visibilidade do palpite: público, apenas seguidores do tipster ou apenas assinantes
+const (
+	VISIBILIDADE_PUBLICO    = "publico"
+	VISIBILIDADE_SEGUIDORES = "seguidores"
+	VISIBILIDADE_ASSINANTES = "assinantes"
+)
+
+var ValidVisibilidades = []string{VISIBILIDADE_PUBLICO, VISIBILIDADE_SEGUIDORES, VISIBILIDADE_ASSINANTES}
+
+// Intervalos de cobrança dos planos
+const (
+	INTERVALO_MENSAL     = "mensal"
+	INTERVALO_TRIMESTRAL = "trimestral"
+	INTERVALO_ANUAL      = "anual"
+)
+
+// IntervaloMeses é a duração de cada período de cobrança, em meses
+var IntervaloMeses = map[string]int{
+	INTERVALO_MENSAL:     1,
+	INTERVALO_TRIMESTRAL: 3,
+	INTERVALO_ANUAL:      12,
+}
+
+// Status da assinatura. Assinaturas canceladas continuam valendo até o fim do período pago.
+const (
+	ASSINATURA_PENDENTE  = "pendente"
+	ASSINATURA_ATIVA     = "ativa"
+	ASSINATURA_CANCELADA = "cancelada"
+	ASSINATURA_EXPIRADA  = "expirada"
+)
+
+// SubscriptionPlan é um plano de assinatura oferecido por um tipster
+type SubscriptionPlan struct {
+	ID            int       `json:"id"`
+	TipsterID     int       `json:"tipster_id"`
+	Nome          string    `json:"nome"`
+	Descricao     *string   `json:"descricao,omitempty"`
+	PrecoCentavos int       `json:"preco_centavos"`
+	Moeda         string    `json:"moeda"`
+	Intervalo     string    `json:"intervalo"`
+	Ativo         bool      `json:"ativo"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type PlanRequest struct {
+	Nome          string  `json:"nome"`
+	Descricao     *string `json:"descricao,omitempty"`
+	PrecoCentavos int     `json:"preco_centavos"`
+	Intervalo     string  `json:"intervalo"`
+}
+
+type Subscription struct {
+	ID               int        `json:"id"`
+	PlanID           int        `json:"plan_id"`
+	SubscriberID     int        `json:"subscriber_id"`
+	TipsterID        int        `json:"tipster_id"`
+	Status           string     `json:"status"`
+	Provider         string     `json:"provider"`
+	ProviderRef      *string    `json:"provider_ref,omitempty"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end,omitempty"`
+	CanceledAt       *time.Time `json:"canceled_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func IsValidVisibilidade(v string) bool {
+	return contains(ValidVisibilidades, v)
+}
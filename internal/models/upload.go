@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// UploadedFile registra um arquivo enviado ao S3 e quem o enviou
+type UploadedFile struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	Key          string    `json:"key"`
+	URL          string    `json:"url"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	OriginalName string    `json:"original_name"`
+	CreatedAt    time.Time `json:"created_at"`
+}
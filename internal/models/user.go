@@ -1,6 +1,13 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
 
 const (
 	PERFIL_ADMIN = "admin"
@@ -10,16 +17,59 @@ const (
 var ValidPerfis = []string{PERFIL_ADMIN, PERFIL_USER}
 
 type User struct {
-	ID             int       `json:"id"`
-	Nome           string    `json:"nome"`
-	Email          string    `json:"email"`
-	Password       string    `json:"password,omitempty"`
-	CPF            string    `json:"cpf"`
-	DataNascimento string    `json:"data_nascimento"`
-	Perfil         string    `json:"perfil"`
-	Avatar         *string   `json:"avatar,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID                int               `json:"id"`
+	Nome              string            `json:"nome"`
+	Email             string            `json:"email"`
+	Password          string            `json:"password,omitempty"`
+	CPF               string            `json:"cpf"`
+	DataNascimento    string            `json:"data_nascimento"`
+	Perfil            string            `json:"perfil"`
+	Avatar            *string           `json:"avatar,omitempty"`
+	Bio               *string           `json:"bio,omitempty"`
+	EsportesFavoritos []string          `json:"esportes_favoritos,omitempty"`
+	RedesSociais      map[string]string `json:"redes_sociais,omitempty"`
+	EmailPendente     *string           `json:"-"`
+	SuspensaoTipo     *string           `json:"-"`
+	SuspensoAte       *time.Time        `json:"-"`
+	SuspensaoMotivo   *string           `json:"-"`
+	DeletedAt         *time.Time        `json:"-"`
+	MustResetPassword bool              `json:"-"`
+	TokensValidAfter  *time.Time        `json:"-"`
+	TOTPEnabledAt     *time.Time        `json:"-"`
+	FormatoOdds       string            `json:"formato_odds"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+// UserColumns são as colunas lidas por ScanUser, na mesma ordem
+const UserColumns = `id, nome, email, cpf,
+	TO_CHAR(data_nascimento, 'YYYY-MM-DD') as data_nascimento,
+	perfil, COALESCE(avatar, '') as avatar, bio, favorite_sports, social_links,
+	pending_email, suspension_type, suspended_until, suspension_reason,
+	deleted_at, must_reset_password, tokens_valid_after, totp_enabled_at, odds_format, created_at, updated_at`
+
+// RowScanner é satisfeito por *sql.Row e *sql.Rows
+type RowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ScanUser lê uma linha selecionada com UserColumns; extra recebe colunas adicionais após as padrão
+func ScanUser(row RowScanner, extra ...interface{}) (*User, error) {
+	var u User
+	var socialLinks []byte
+	dest := []interface{}{&u.ID, &u.Nome, &u.Email, &u.CPF, &u.DataNascimento,
+		&u.Perfil, &u.Avatar, &u.Bio, pq.Array(&u.EsportesFavoritos), &socialLinks,
+		&u.EmailPendente, &u.SuspensaoTipo, &u.SuspensoAte, &u.SuspensaoMotivo,
+		&u.DeletedAt, &u.MustResetPassword, &u.TokensValidAfter, &u.TOTPEnabledAt, &u.FormatoOdds, &u.CreatedAt, &u.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if len(socialLinks) > 0 {
+		if err := json.Unmarshal(socialLinks, &u.RedesSociais); err != nil {
+			return nil, err
+		}
+	}
+	return &u, nil
 }
 
 type UserLogin struct {
@@ -28,16 +78,31 @@ type UserLogin struct {
 }
 
 type UserResponse struct {
-	ID             int       `json:"id"`
-	Nome           string    `json:"nome"`
-	Email          string    `json:"email"`
-	CPF            string    `json:"cpf"`
-	DataNascimento string    `json:"data_nascimento"`
-	Perfil         string    `json:"perfil"`
-	Avatar         *string   `json:"avatar,omitempty"`
-	IsAdmin        bool      `json:"is_admin"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID                int               `json:"id"`
+	Nome              string            `json:"nome"`
+	Email             string            `json:"email"`
+	CPF               string            `json:"cpf"`
+	DataNascimento    string            `json:"data_nascimento"`
+	Perfil            string            `json:"perfil"`
+	Avatar            *string           `json:"avatar,omitempty"`
+	Bio               *string           `json:"bio,omitempty"`
+	EsportesFavoritos []string          `json:"esportes_favoritos,omitempty"`
+	RedesSociais      map[string]string `json:"redes_sociais,omitempty"`
+	EmailPendente     *string           `json:"email_pendente,omitempty"`
+	Suspensao         *UserSuspension   `json:"suspensao,omitempty"`
+	FormatoOdds       string            `json:"formato_odds"`
+	IsAdmin           bool              `json:"is_admin"`
+	DoisFatores       bool              `json:"dois_fatores"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+// AuthResponse é a resposta de login/cadastro: dados do usuário, o token de acesso e o refresh token
+type AuthResponse struct {
+	UserResponse
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in"`
 }
 
 func IsValidPerfil(perfil string) bool {
@@ -53,17 +118,77 @@ func (u *User) IsAdmin() bool {
 	return u.Perfil == PERFIL_ADMIN
 }
 
+// TwoFactorEnabled indica se o login exige o código do aplicativo autenticador
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:             u.ID,
-		Nome:           u.Nome,
-		Email:          u.Email,
-		CPF:            u.CPF,
-		DataNascimento: u.DataNascimento,
-		Perfil:         u.Perfil,
-		Avatar:         u.Avatar,
-		IsAdmin:        u.IsAdmin(),
-		CreatedAt:      u.CreatedAt,
-		UpdatedAt:      u.UpdatedAt,
+		ID:                u.ID,
+		Nome:              u.Nome,
+		Email:             u.Email,
+		CPF:               u.CPF,
+		DataNascimento:    u.DataNascimento,
+		Perfil:            u.Perfil,
+		Avatar:            u.Avatar,
+		Bio:               u.Bio,
+		EsportesFavoritos: u.EsportesFavoritos,
+		RedesSociais:      u.RedesSociais,
+		EmailPendente:     u.EmailPendente,
+		FormatoOdds:       u.FormatoOdds,
+		IsAdmin:           u.IsAdmin(),
+		DoisFatores:       u.TwoFactorEnabled(),
+		CreatedAt:         u.CreatedAt,
+		UpdatedAt:         u.UpdatedAt,
+	}
+}
+
+// CanViewPrivateData indica se o viewer pode ver os dados sensíveis (CPF, email, nascimento) deste usuário:
+// apenas o próprio dono da conta e administradores
+func (u *User) CanViewPrivateData(viewer *User) bool {
+	if viewer == nil {
+		return false
+	}
+	return viewer.ID == u.ID || viewer.IsAdmin()
+}
+
+// ToResponseFor monta a resposta do usuário mascarando CPF, email e data de nascimento para quem
+// não é o dono nem admin
+func (u *User) ToResponseFor(viewer *User) UserResponse {
+	resp := u.ToResponse()
+	if !u.CanViewPrivateData(viewer) {
+		resp.CPF = MaskCPF(u.CPF)
+		resp.Email = MaskEmail(u.Email)
+		resp.DataNascimento = MaskBirthDate(u.DataNascimento)
+		resp.EmailPendente = nil
+		resp.DoisFatores = false
+	}
+	if viewer != nil && viewer.IsAdmin() && u.SuspensaoTipo != nil {
+		resp.Suspensao = &UserSuspension{
+			Tipo:   *u.SuspensaoTipo,
+			Ate:    u.SuspensoAte,
+			Motivo: u.SuspensaoMotivo,
+			Ativa:  u.IsSuspended(time.Now()),
+		}
+	}
+	return resp
+}
+
+// MaskEmail oculta o email mantendo a primeira letra e o domínio (j***@gmail.com)
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	first, _ := utf8.DecodeRuneInString(local)
+	return string(first) + "***@" + domain
+}
+
+// MaskBirthDate oculta a data de nascimento por inteiro, inclusive o ano
+func MaskBirthDate(date string) string {
+	if date == "" {
+		return ""
 	}
+	return "****-**-**"
 }
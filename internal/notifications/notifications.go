@@ -0,0 +1,199 @@
+// Package notifications mantém a central de notificações. As notificações são criadas pelos
+// assinantes do barramento de eventos (ver subscribers.go), nunca diretamente pelos handlers.
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"smartpicks-backend/internal/compliance"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/listing"
+	"smartpicks-backend/internal/models"
+)
+
+var ErrNotFound = errors.New("notificação não encontrada")
+
+// notificationFrom traz o nome do ator junto da notificação
+const (
+	notificationColumns = `n.id, n.user_id, n.type, n.actor_id, a.nome, n.palpite_id, n.message, n.data, n.read_at, n.created_at`
+	notificationFrom    = `FROM notifications n LEFT JOIN users a ON a.id = n.actor_id`
+)
+
+// Notice é uma notificação a criar. DedupeKey evita duplicar a mesma notificação quando o
+// evento é entregue mais de uma vez.
+type Notice struct {
+	Tipo      string
+	AtorID    *int
+	PalpiteID *int
+	Mensagem  string
+	Dados     interface{}
+	DedupeKey string
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	var n models.Notification
+	var data []byte
+	err := row.Scan(&n.ID, &n.UserID, &n.Tipo, &n.AtorID, &n.AtorNome, &n.PalpiteID, &n.Mensagem, &data,
+		&n.ReadAt, &n.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 && string(data) != "null" {
+		n.Dados = data
+	}
+	n.Lida = n.ReadAt != nil
+	return &n, nil
+}
+
+// Notify cria a notificação para um usuário; devolve falso se ela já existia
+func Notify(ctx context.Context, userID int, n Notice) (bool, error) {
+	created, err := insert(ctx, `
+		INSERT INTO notifications (user_id, type, actor_id, palpite_id, message, data, dedupe_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+		RETURNING id, user_id`, userID, n)
+	return created > 0, err
+}
+
+// NotifyFollowers cria a notificação para todos os seguidores de followedID em um único INSERT.
+// Seguidores em pausa ou autoexclusão não recebem.
+func NotifyFollowers(ctx context.Context, followedID int, n Notice) (int, error) {
+	return insert(ctx, `
+		INSERT INTO notifications (user_id, type, actor_id, palpite_id, message, data, dedupe_key)
+		SELECT f.follower_id, $2::varchar, $3::int, $4::int, $5::text, $6::jsonb, $7::varchar
+		FROM user_follows f
+		JOIN users u ON u.id = f.follower_id AND u.deleted_at IS NULL
+		WHERE f.followed_id = $1 AND `+compliance.NotExcluded("f.follower_id")+`
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+		RETURNING id, user_id`, followedID, n)
+}
+
+// insert grava as notificações e publica NotificationCreated para cada uma na mesma transação
+func insert(ctx context.Context, query string, userID int, n Notice) (int, error) {
+	data, err := json.Marshal(n.Dados)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, userID, n.Tipo, n.AtorID, n.PalpiteID, n.Mensagem, data, n.DedupeKey)
+	if err != nil {
+		return 0, err
+	}
+	var created []events.NotificationCreated
+	for rows.Next() {
+		var e events.NotificationCreated
+		if err := rows.Scan(&e.NotificationID, &e.UserID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		created = append(created, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, e := range created {
+		if err := events.PublishTx(tx, e); err != nil {
+			return 0, err
+		}
+	}
+	return len(created), tx.Commit()
+}
+
+func Get(id int) (*models.Notification, error) {
+	n, err := scanNotification(database.DB.QueryRow(
+		"SELECT "+notificationColumns+" "+notificationFrom+" WHERE n.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return n, err
+}
+
+// List lista as notificações do usuário, mais recentes primeiro
+func List(userID int, unreadOnly bool, page *listing.Params) ([]models.Notification, bool, error) {
+	query := listing.New("SELECT "+notificationColumns+" "+notificationFrom).
+		Where("n.user_id = ?", userID).
+		WhereIf(unreadOnly, "n.read_at IS NULL")
+	page.Apply(query)
+
+	q, args := query.SQL()
+	rows, err := database.DB.Query(q, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	list := []models.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		list = append(list, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := page.HasMore(len(list))
+	if hasMore {
+		list = list[:page.Limit]
+	}
+	return list, hasMore, nil
+}
+
+func UnreadCount(userID int) (int, error) {
+	var n int
+	err := database.DB.QueryRow(
+		"SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL", userID).Scan(&n)
+	return n, err
+}
+
+// MarkRead marca uma notificação do usuário como lida (idempotente)
+func MarkRead(userID, id int) (*models.Notification, error) {
+	result, err := database.DB.Exec(`
+		UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND read_at IS NULL`, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if changed, _ := result.RowsAffected(); changed > 0 {
+		events.Publish(events.NotificationsRead{UserID: userID})
+	}
+	n, err := scanNotification(database.DB.QueryRow(
+		"SELECT "+notificationColumns+" "+notificationFrom+" WHERE n.id = $1 AND n.user_id = $2", id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return n, err
+}
+
+// MarkAllRead marca todas as notificações não lidas do usuário e devolve quantas foram marcadas
+func MarkAllRead(userID int) (int, error) {
+	result, err := database.DB.Exec(`
+		UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if n > 0 {
+		events.Publish(events.NotificationsRead{UserID: userID})
+	}
+	return int(n), err
+}
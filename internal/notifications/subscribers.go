@@ -0,0 +1,105 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"unicode/utf8"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/models"
+)
+
+// trechoLen é o tamanho do trecho do comentário guardado na notificação
+const trechoLen = 120
+
+// Register inscreve a central de notificações nos eventos de domínio
+func Register() {
+	events.Subscribe("notifications", onPalpitePublished)
+	events.Subscribe("notifications", onCommentCreated)
+	events.Subscribe("notifications", onPalpiteSettled)
+	events.Subscribe("notifications", onUserFollowed)
+}
+
+func onPalpitePublished(ctx context.Context, e events.PalpitePublished) error {
+	nome, err := userName(ctx, e.AuthorID)
+	if err != nil {
+		return err
+	}
+	msg := nome + " publicou um novo palpite"
+	if e.Titulo != "" {
+		msg += ": " + e.Titulo
+	}
+	_, err = NotifyFollowers(ctx, e.AuthorID, Notice{
+		Tipo:      models.NOTIFICACAO_NOVO_PALPITE,
+		AtorID:    &e.AuthorID,
+		PalpiteID: &e.PalpiteID,
+		Mensagem:  msg,
+		DedupeKey: fmt.Sprintf("palpite:%d", e.PalpiteID),
+	})
+	return err
+}
+
+func onCommentCreated(ctx context.Context, e events.CommentCreated) error {
+	if e.AuthorID == e.OwnerID {
+		return nil
+	}
+	nome, err := userName(ctx, e.AuthorID)
+	if err != nil {
+		return err
+	}
+	_, err = Notify(ctx, e.OwnerID, Notice{
+		Tipo:      models.NOTIFICACAO_COMENTARIO,
+		AtorID:    &e.AuthorID,
+		PalpiteID: &e.PalpiteID,
+		Mensagem:  nome + " comentou no seu palpite",
+		Dados:     map[string]interface{}{"comment_id": e.CommentID, "trecho": truncate(e.Texto, trechoLen)},
+		DedupeKey: fmt.Sprintf("comentario:%d", e.CommentID),
+	})
+	return err
+}
+
+// onPalpiteSettled avisa o autor; a chave inclui o status para que uma correção de placar
+// que muda o resultado gere um novo aviso
+func onPalpiteSettled(ctx context.Context, e events.PalpiteSettled) error {
+	_, err := Notify(ctx, e.UserID, Notice{
+		Tipo:      models.NOTIFICACAO_PALPITE_LIQUIDADO,
+		PalpiteID: &e.PalpiteID,
+		Mensagem:  fmt.Sprintf("Seu palpite foi liquidado: %s (%+.2f u)", e.Status, e.Lucro),
+		Dados:     map[string]interface{}{"status": e.Status, "lucro": e.Lucro},
+		DedupeKey: fmt.Sprintf("liquidado:%d:%s", e.PalpiteID, e.Status),
+	})
+	return err
+}
+
+func onUserFollowed(ctx context.Context, e events.UserFollowed) error {
+	nome, err := userName(ctx, e.FollowerID)
+	if err != nil {
+		return err
+	}
+	_, err = Notify(ctx, e.FollowedID, Notice{
+		Tipo:      models.NOTIFICACAO_NOVO_SEGUIDOR,
+		AtorID:    &e.FollowerID,
+		Mensagem:  nome + " começou a seguir você",
+		DedupeKey: fmt.Sprintf("seguidor:%d", e.FollowerID),
+	})
+	return err
+}
+
+// userName é o nome do ator; contas já removidas aparecem como "Alguém"
+func userName(ctx context.Context, id int) (string, error) {
+	var nome string
+	err := database.DB.QueryRowContext(ctx, "SELECT nome FROM users WHERE id = $1", id).Scan(&nome)
+	if err == sql.ErrNoRows {
+		return "Alguém", nil
+	}
+	return nome, err
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
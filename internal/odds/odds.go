@@ -0,0 +1,192 @@
+// Package odds converte odds entre os formatos decimal, fracionário e americano e calcula
+// probabilidade implícita e overround. Internamente as odds são sempre decimais.
+package odds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Formatos aceitos na entrada e na preferência de exibição do usuário
+const (
+	FORMATO_DECIMAL     = "decimal"
+	FORMATO_FRACIONARIO = "fracionaria"
+	FORMATO_AMERICANO   = "americana"
+)
+
+var ValidFormats = []string{FORMATO_DECIMAL, FORMATO_FRACIONARIO, FORMATO_AMERICANO}
+
+// maxDenominator limita o denominador na conversão para fração (ex.: 1.91 -> 10/11)
+const maxDenominator = 100
+
+// MaxDecimal é a maior odd decimal aceita (999/1, +99900)
+const MaxDecimal = 1000
+
+var (
+	ErrInvalid    = errors.New("odd inválida: use decimal (2.50), fracionária (3/2) ou americana (+150)")
+	ErrOutOfRange = fmt.Errorf("odd fora do intervalo aceito: deve ser maior que 1 e no máximo %d", MaxDecimal)
+)
+
+func IsValidFormat(format string) bool {
+	for _, f := range ValidFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse detecta o formato e devolve a odd decimal: "3/2" é fracionária, valores com sinal
+// ("+150", "-200") são americanos e os demais são decimais ("2.5" ou "2,5")
+func Parse(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.Contains(s, "/"):
+		return ParseAs(s, FORMATO_FRACIONARIO)
+	case strings.HasPrefix(s, "+"), strings.HasPrefix(s, "-"):
+		return ParseAs(s, FORMATO_AMERICANO)
+	}
+	return ParseAs(s, FORMATO_DECIMAL)
+}
+
+// ParseAs interpreta s no formato informado e devolve a odd decimal, arredondada e entre 1
+// (exclusivo) e MaxDecimal
+func ParseAs(s, format string) (float64, error) {
+	s = strings.TrimSpace(s)
+	var decimal float64
+
+	switch format {
+	case FORMATO_DECIMAL:
+		v, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+		if err != nil {
+			return 0, ErrInvalid
+		}
+		decimal = v
+
+	case FORMATO_FRACIONARIO:
+		num, den, ok := strings.Cut(s, "/")
+		n, err1 := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		d, err2 := strconv.ParseFloat(strings.TrimSpace(den), 64)
+		if !ok || err1 != nil || err2 != nil || n <= 0 || d <= 0 {
+			return 0, ErrInvalid
+		}
+		decimal = 1 + n/d
+
+	case FORMATO_AMERICANO:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.Abs(v) < 100 {
+			return 0, ErrInvalid
+		}
+		if v > 0 {
+			decimal = 1 + v/100
+		} else {
+			decimal = 1 + 100/-v
+		}
+
+	default:
+		return 0, fmt.Errorf("formato de odd desconhecido: %s", format)
+	}
+
+	// "NaN" e "Inf" passam pelo ParseFloat; o intervalo é conferido depois do arredondamento:
+	// 1/10000 ou -1000000 virariam odd 1.000, e Round(1e308) estoura para +Inf
+	if math.IsNaN(decimal) || math.IsInf(decimal, 0) {
+		return 0, ErrInvalid
+	}
+	decimal = Round(decimal)
+	if math.IsInf(decimal, 0) || decimal <= 1 || decimal > MaxDecimal {
+		return 0, ErrOutOfRange
+	}
+	return decimal, nil
+}
+
+// Format exibe a odd decimal no formato informado (decimal quando o formato é vazio ou desconhecido)
+func Format(decimal float64, format string) string {
+	switch format {
+	case FORMATO_FRACIONARIO:
+		num, den := Fraction(decimal)
+		return fmt.Sprintf("%d/%d", num, den)
+	case FORMATO_AMERICANO:
+		if decimal >= 2 {
+			return fmt.Sprintf("+%d", int(math.Round((decimal-1)*100)))
+		}
+		return fmt.Sprintf("-%d", int(math.Round(100/(decimal-1))))
+	}
+	return strconv.FormatFloat(decimal, 'f', 2, 64)
+}
+
+// Fraction aproxima a odd decimal pela fração de menor denominador que a reproduz com duas casas;
+// odds muito baixas viram 1/n
+func Fraction(decimal float64) (int, int) {
+	profit := decimal - 1
+	bestNum, bestDen, bestDiff := 0, 1, math.Inf(1)
+	for den := 1; den <= maxDenominator; den++ {
+		num := int(math.Round(profit * float64(den)))
+		if num <= 0 {
+			continue
+		}
+		diff := math.Abs(float64(num)/float64(den) - profit)
+		if diff < 0.005 {
+			return num, den
+		}
+		if diff < bestDiff {
+			bestNum, bestDen, bestDiff = num, den, diff
+		}
+	}
+	// Lucro menor que 1/(2*maxDenominator) (odds abaixo de ~1.005) não tem numerador inteiro
+	// positivo em nenhum denominador: 1.004 vira 1/250, e não 1/1
+	if bestNum == 0 && profit > 0 {
+		return 1, int(math.Round(1 / profit))
+	}
+	return bestNum, bestDen
+}
+
+// ImpliedProbability é a probabilidade (0 a 1) embutida na odd decimal
+func ImpliedProbability(decimal float64) float64 {
+	if decimal <= 0 {
+		return 0
+	}
+	return 1 / decimal
+}
+
+// Overround é a margem da casa em um mercado completo: soma das probabilidades implícitas menos 1
+// (ex.: 1.91/1.91 -> 0.047)
+func Overround(decimals ...float64) float64 {
+	var total float64
+	for _, d := range decimals {
+		total += ImpliedProbability(d)
+	}
+	return total - 1
+}
+
+// Round normaliza a odd decimal para três casas, a precisão gravada no banco
+func Round(decimal float64) float64 {
+	return math.Round(decimal*1000) / 1000
+}
+
+// Input aceita a odd no JSON como número (sempre decimal: -200 é recusado, não lido como
+// americana) ou texto em qualquer formato suportado
+type Input float64
+
+func (in *Input) UnmarshalJSON(data []byte) error {
+	var text string
+	parse := Parse
+	if err := json.Unmarshal(data, &text); err != nil {
+		var number float64
+		if err := json.Unmarshal(data, &number); err != nil {
+			return ErrInvalid
+		}
+		text = strconv.FormatFloat(number, 'f', -1, 64)
+		parse = func(s string) (float64, error) { return ParseAs(s, FORMATO_DECIMAL) }
+	}
+
+	decimal, err := parse(text)
+	if err != nil {
+		return err
+	}
+	*in = Input(decimal)
+	return nil
+}
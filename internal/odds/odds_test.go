@@ -0,0 +1,202 @@
+package odds
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr error
+	}{
+		// decimal
+		{"2.5", 2.5, nil},
+		{"2,50", 2.5, nil},
+		{" 1.91 ", 1.91, nil},
+		{"1.0006", 1.001, nil},
+		{"1000", 1000, nil},
+		{"1000.0004", 1000, nil},
+		{"1", 0, ErrOutOfRange},
+		{"1.0004", 0, ErrOutOfRange},
+		{"0.5", 0, ErrOutOfRange},
+		{"1000.001", 0, ErrOutOfRange},
+		{"1e308", 0, ErrOutOfRange},
+		{"NaN", 0, ErrInvalid},
+		{"Inf", 0, ErrInvalid},
+		{"abc", 0, ErrInvalid},
+		{"", 0, ErrInvalid},
+
+		// fracionária
+		{"3/2", 2.5, nil},
+		{"10/11", 1.909, nil},
+		{"999/1", 1000, nil},
+		{"1/1000", 1.001, nil},
+		{"1/10000", 0, ErrOutOfRange},
+		{"1000/1", 0, ErrOutOfRange},
+		{"0/1", 0, ErrInvalid},
+		{"3/0", 0, ErrInvalid},
+		{"-3/2", 0, ErrInvalid},
+		{"3/", 0, ErrInvalid},
+
+		// americana
+		{"+150", 2.5, nil},
+		{"+100", 2, nil},
+		{"-100", 2, nil},
+		{"-200", 1.5, nil},
+		{"-110", 1.909, nil},
+		{"+99900", 1000, nil},
+		{"+100000", 0, ErrOutOfRange},
+		{"-1000000", 0, ErrOutOfRange},
+		{"+99", 0, ErrInvalid},
+		{"-50", 0, ErrInvalid},
+		{"+1e308", 0, ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse(%q) = %v, %v; want erro %v", tt.in, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseAs(t *testing.T) {
+	tests := []struct {
+		in, format string
+		want       float64
+		ok         bool
+	}{
+		{"150", FORMATO_AMERICANO, 2.5, true},
+		{"2.5", FORMATO_DECIMAL, 2.5, true},
+		{"-200", FORMATO_DECIMAL, 0, false},
+		{"2.5", FORMATO_FRACIONARIO, 0, false},
+		{"3/2", FORMATO_DECIMAL, 0, false},
+		{"2.5", "europeia", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseAs(tt.in, tt.format)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseAs(%q, %q) = %v, %v; want %v (ok=%v)", tt.in, tt.format, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		decimal float64
+		format  string
+		want    string
+	}{
+		{2.5, FORMATO_DECIMAL, "2.50"},
+		{1.909, "", "1.91"},
+		{2.5, "europeia", "2.50"},
+
+		{2.5, FORMATO_FRACIONARIO, "3/2"},
+		{1.5, FORMATO_FRACIONARIO, "1/2"},
+		{2, FORMATO_FRACIONARIO, "1/1"},
+		{1.91, FORMATO_FRACIONARIO, "10/11"},
+		{1000, FORMATO_FRACIONARIO, "999/1"},
+		{1.004, FORMATO_FRACIONARIO, "1/250"},
+		{1.001, FORMATO_FRACIONARIO, "1/1000"},
+
+		{2.5, FORMATO_AMERICANO, "+150"},
+		{2, FORMATO_AMERICANO, "+100"},
+		{1.5, FORMATO_AMERICANO, "-200"},
+		{1.909, FORMATO_AMERICANO, "-110"},
+		{1000, FORMATO_AMERICANO, "+99900"},
+		{1.001, FORMATO_AMERICANO, "-100000"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.decimal, tt.format); got != tt.want {
+			t.Errorf("Format(%v, %q) = %q, want %q", tt.decimal, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestFraction(t *testing.T) {
+	tests := []struct {
+		decimal  float64
+		num, den int
+	}{
+		{2.5, 3, 2},
+		{1.333, 1, 3},
+		{4.333, 10, 3},
+		{1.1, 1, 10},
+		// a primeira fração que reproduz a odd com duas casas, não a exata
+		{1.01, 1, 67},
+		// abaixo de 1.005 nenhum denominador até maxDenominator tem numerador positivo
+		{1.005, 1, 200},
+		{1.004, 1, 250},
+		{1.002, 1, 500},
+	}
+	for _, tt := range tests {
+		if num, den := Fraction(tt.decimal); num != tt.num || den != tt.den {
+			t.Errorf("Fraction(%v) = %d/%d, want %d/%d", tt.decimal, num, den, tt.num, tt.den)
+		}
+	}
+}
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	// A americana volta à mesma odd; decimal e fracionária são exibidas com duas casas
+	for _, d := range []float64{1.2, 1.5, 1.909, 2, 2.5, 3.75, 10, 1000} {
+		for _, format := range ValidFormats {
+			back, err := ParseAs(Format(d, format), format)
+			if err != nil {
+				t.Errorf("%v em %s (%q): %v", d, format, Format(d, format), err)
+				continue
+			}
+			tolerance := 0.005
+			if format == FORMATO_AMERICANO {
+				tolerance = 0.001
+			}
+			if diff := back - d; diff > tolerance || diff < -tolerance {
+				t.Errorf("%v em %s (%q) voltou como %v", d, format, Format(d, format), back)
+			}
+		}
+	}
+}
+
+func TestInputUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want float64
+		ok   bool
+	}{
+		{`2.5`, 2.5, true},
+		{`"2,50"`, 2.5, true},
+		{`"3/2"`, 2.5, true},
+		{`"+150"`, 2.5, true},
+		{`"-200"`, 1.5, true},
+		// números no JSON são sempre decimais
+		{`-200`, 0, false},
+		{`150`, 150, true},
+		{`1`, 0, false},
+		{`1e308`, 0, false},
+		{`true`, 0, false},
+		{`"abc"`, 0, false},
+	}
+	for _, tt := range tests {
+		var in Input
+		err := json.Unmarshal([]byte(tt.json), &in)
+		if (err == nil) != tt.ok || float64(in) != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, %v; want %v (ok=%v)", tt.json, float64(in), err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestOverround(t *testing.T) {
+	if got := Round(Overround(1.91, 1.91)); got != 0.047 {
+		t.Errorf("Overround(1.91, 1.91) = %v, want 0.047", got)
+	}
+	if got := Round(Overround(2, 2)); got != 0 {
+		t.Errorf("Overround(2, 2) = %v, want 0", got)
+	}
+}
@@ -0,0 +1,35 @@
+package oidc
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// PROVEDOR_FIREBASE identifica as identidades vindas do Firebase Authentication
+const PROVEDOR_FIREBASE = "firebase"
+
+// firebaseJWKSURL são as chaves públicas que assinam os ID tokens do Firebase
+var firebaseJWKSURL = "https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com"
+
+// FirebaseProjectID lê FIREBASE_PROJECT_ID; vazio desabilita o login com Firebase
+func FirebaseProjectID() string {
+	return os.Getenv("FIREBASE_PROJECT_ID")
+}
+
+// VerifyFirebaseToken valida um ID token emitido pelo Firebase Authentication do projeto
+// (o frontend o obtém com getIdToken() após signInWithPopup)
+func VerifyFirebaseToken(ctx context.Context, idToken string) (*Identity, error) {
+	projectID := FirebaseProjectID()
+	if projectID == "" {
+		return nil, ErrNotConfigured
+	}
+	claims, err := verify(ctx, idToken, firebaseJWKSURL, expectations{
+		issuer:   "https://securetoken.google.com/" + projectID,
+		audience: projectID,
+	}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return newIdentity(PROVEDOR_FIREBASE, claims), nil
+}
@@ -0,0 +1,173 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+)
+
+// ErrInvalidState é um state desconhecido, expirado ou já usado
+var ErrInvalidState = errors.New("login expirado ou já utilizado; tente novamente")
+
+// stateTTL é o tempo que o usuário tem para concluir o login no provedor
+const stateTTL = 10 * time.Minute
+
+// Begin inicia o login no provedor: gera state, nonce e o code_verifier do PKCE, guarda os
+// três no banco (o login pode terminar em outra instância) e devolve a URL de autorização
+func Begin(ctx context.Context, p *Provider) (string, error) {
+	m, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := auth.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := auth.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := auth.GenerateSecureToken(48)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := database.DB.ExecContext(ctx, `
+		INSERT INTO oidc_login_states (state_hash, provider, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		auth.HashToken(state), p.Name, nonce, verifier, time.Now().Add(stateTTL)); err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {RedirectURL()},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(m.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return m.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Complete consome o state (uso único), troca o code pelos tokens no provedor e valida o ID token
+func Complete(ctx context.Context, state, code string) (*Identity, error) {
+	var providerName, nonce, verifier string
+	err := database.DB.QueryRowContext(ctx, `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING provider, nonce, code_verifier`, auth.HashToken(state)).Scan(&providerName, &nonce, &verifier)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidState
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	m, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := p.exchange(ctx, m.TokenEndpoint, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := verify(ctx, idToken, m.JWKSURI, expectations{issuer: p.Issuer, audience: p.ClientID, nonce: nonce}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return newIdentity(p.Name, claims), nil
+}
+
+// ExchangeError é uma recusa do endpoint de token (code inválido, expirado ou já usado)
+type ExchangeError struct {
+	Code        string
+	Description string
+}
+
+func (e *ExchangeError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("provedor recusou o code: %s (%s)", e.Code, e.Description)
+	}
+	return "provedor recusou o code: " + e.Code
+}
+
+func (p *Provider) exchange(ctx context.Context, tokenEndpoint, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {RedirectURL()},
+		"client_id":     {p.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.clientSecret != "" {
+		form.Set("client_secret", p.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("endpoint de token do provedor %s: %w", p.Name, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	json.Unmarshal(raw, &body)
+
+	switch {
+	case resp.StatusCode == http.StatusOK && body.IDToken != "":
+		return body.IDToken, nil
+	case resp.StatusCode == http.StatusOK:
+		return "", fmt.Errorf("provedor %s não devolveu id_token (o escopo openid está configurado?)", p.Name)
+	case body.Error != "" && resp.StatusCode < 500:
+		return "", &ExchangeError{Code: body.Error, Description: body.ErrorDescription}
+	default:
+		return "", fmt.Errorf("endpoint de token do provedor %s respondeu %d", p.Name, resp.StatusCode)
+	}
+}
+
+// PurgeStates remove states de logins que não foram concluídos
+func PurgeStates(now time.Time) (int, error) {
+	result, err := database.DB.Exec("DELETE FROM oidc_login_states WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,130 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/database"
+)
+
+var (
+	ErrEmailNotVerified = errors.New("o provedor não confirmou o email desta conta")
+	ErrNoAccount        = errors.New("nenhuma conta SmartPicks com este email")
+	ErrNotFound         = errors.New("vínculo não encontrado")
+)
+
+// Identity é uma conta de um provedor externo, já validada
+type Identity struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	Provider      string     `json:"provedor"`
+	Subject       string     `json:"-"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"-"`
+	Nome          string     `json:"-"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastLoginAt   *time.Time `json:"last_login_at,omitempty"`
+}
+
+func newIdentity(provider string, c *Claims) *Identity {
+	return &Identity{
+		Provider:      provider,
+		Subject:       c.Subject,
+		Email:         strings.TrimSpace(c.Email),
+		EmailVerified: bool(c.EmailVerified),
+		Nome:          c.Name,
+	}
+}
+
+// Resolve encontra o usuário da identidade. Identidades já vinculadas entram direto; as novas
+// são vinculadas ao usuário com o mesmo email, desde que o provedor o tenha confirmado.
+// linked indica um vínculo novo: as sessões anteriores do usuário são encerradas, para que
+// quem cadastrou o email antes do dono verdadeiro não continue com acesso à conta.
+func Resolve(ctx context.Context, id *Identity) (userID int, linked bool, err error) {
+	err = database.DB.QueryRowContext(ctx, `
+		UPDATE user_identities SET last_login_at = NOW(), email = NULLIF($3, '')
+		WHERE provider = $1 AND subject = $2
+		RETURNING user_id`, id.Provider, id.Subject, id.Email).Scan(&userID)
+	if err == nil {
+		return userID, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	if id.Email == "" || !id.EmailVerified {
+		return 0, false, ErrEmailNotVerified
+	}
+	err = database.DB.QueryRowContext(ctx,
+		"SELECT id FROM users WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL", id.Email).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, false, ErrNoAccount
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	// Logins simultâneos com a mesma identidade: o primeiro vincula, o outro só entra
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (provider, subject) DO NOTHING`, userID, id.Provider, id.Subject, id.Email)
+	if err != nil {
+		return 0, false, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return userID, false, tx.Commit()
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE users SET tokens_valid_after = NOW() WHERE id = $1", userID); err != nil {
+		return 0, false, err
+	}
+	return userID, true, tx.Commit()
+}
+
+const identityColumns = `id, user_id, provider, COALESCE(email, ''), created_at, last_login_at`
+
+func scanIdentity(row interface{ Scan(...interface{}) error }) (*Identity, error) {
+	var i Identity
+	err := row.Scan(&i.ID, &i.UserID, &i.Provider, &i.Email, &i.CreatedAt, &i.LastLoginAt)
+	return &i, err
+}
+
+// ListByUser devolve as contas externas vinculadas ao usuário
+func ListByUser(userID int) ([]Identity, error) {
+	rows, err := database.DB.Query(
+		"SELECT "+identityColumns+" FROM user_identities WHERE user_id = $1 ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Identity{}
+	for rows.Next() {
+		i, err := scanIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *i)
+	}
+	return list, rows.Err()
+}
+
+// Unlink remove o vínculo; o usuário continua entrando com email e senha
+func Unlink(userID, id int) (*Identity, error) {
+	i, err := scanIdentity(database.DB.QueryRow(
+		"DELETE FROM user_identities WHERE id = $1 AND user_id = $2 RETURNING "+identityColumns, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return i, err
+}
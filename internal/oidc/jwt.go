@@ -0,0 +1,281 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken cobre qualquer falha na validação do ID token (assinatura, emissor,
+// audiência, expiração, nonce)
+var ErrInvalidToken = errors.New("ID token inválido")
+
+const (
+	// clockSkew tolera pequenas diferenças de relógio com o provedor
+	clockSkew = time.Minute
+	// jwksTTL é por quanto tempo as chaves ficam em cache; um kid desconhecido força a
+	// releitura, no máximo uma vez por jwksMinRefresh (rotação de chaves do provedor)
+	jwksTTL        = time.Hour
+	jwksMinRefresh = time.Minute
+)
+
+// Claims são as claims do ID token usadas no login
+type Claims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	AuthorizedBy  string   `json:"azp"`
+	ExpiresAt     int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	AuthTime      int64    `json:"auth_time"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified boolish  `json:"email_verified"`
+	Name          string   `json:"name"`
+}
+
+// audience aceita "aud" como string ou lista
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(v string) bool {
+	for _, s := range a {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// boolish aceita true e "true": alguns provedores enviam email_verified como string
+type boolish bool
+
+func (b *boolish) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = boolish(s == "true")
+	return nil
+}
+
+// expectations são as regras de validação das claims
+type expectations struct {
+	issuer   string
+	audience string
+	nonce    string // vazio no Firebase, que não usa nonce
+}
+
+// verify confere a assinatura do JWT com as chaves de jwksURL e valida as claims.
+// Só RS256 e ES256 são aceitos; "none" e algoritmos simétricos são recusados.
+func verify(ctx context.Context, token, jwksURL string, exp expectations, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := keys.get(ctx, jwksURL, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" || rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) != nil {
+			return nil, ErrInvalidToken
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != "ES256" || len(signature) != 64 {
+			return nil, ErrInvalidToken
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return nil, ErrInvalidToken
+		}
+	default:
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := claims.validate(exp, now); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func (c *Claims) validate(exp expectations, now time.Time) error {
+	switch {
+	case c.Issuer != exp.issuer:
+		return fmt.Errorf("%w: emissor %q", ErrInvalidToken, c.Issuer)
+	case !c.Audience.contains(exp.audience):
+		return fmt.Errorf("%w: audiência incorreta", ErrInvalidToken)
+	case len(c.Audience) > 1 && c.AuthorizedBy != exp.audience:
+		return fmt.Errorf("%w: azp incorreto", ErrInvalidToken)
+	case c.Subject == "" || len(c.Subject) > 255:
+		return fmt.Errorf("%w: sub ausente", ErrInvalidToken)
+	case now.After(time.Unix(c.ExpiresAt, 0).Add(clockSkew)):
+		return fmt.Errorf("%w: expirado", ErrInvalidToken)
+	case c.IssuedAt != 0 && time.Unix(c.IssuedAt, 0).After(now.Add(clockSkew)):
+		return fmt.Errorf("%w: emitido no futuro", ErrInvalidToken)
+	case c.AuthTime != 0 && time.Unix(c.AuthTime, 0).After(now.Add(clockSkew)):
+		return fmt.Errorf("%w: auth_time no futuro", ErrInvalidToken)
+	case exp.nonce != "" && c.Nonce != exp.nonce:
+		return fmt.Errorf("%w: nonce incorreto", ErrInvalidToken)
+	}
+	return nil
+}
+
+func decodeSegment(seg string, dest interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+// keySet guarda em memória as chaves públicas de cada jwks_uri
+type keySet struct {
+	sync.Mutex
+	sets map[string]*cachedKeys
+}
+
+type cachedKeys struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+var keys = &keySet{sets: map[string]*cachedKeys{}}
+
+func (ks *keySet) get(ctx context.Context, jwksURL, kid string) (crypto.PublicKey, error) {
+	ks.Lock()
+	cached := ks.sets[jwksURL]
+	ks.Unlock()
+
+	if cached != nil {
+		age := time.Since(cached.fetchedAt)
+		if key, ok := cached.keys[kid]; ok && age < jwksTTL {
+			return key, nil
+		}
+		if age < jwksMinRefresh {
+			return nil, fmt.Errorf("%w: chave %q desconhecida", ErrInvalidToken, kid)
+		}
+	}
+
+	fetched, err := fetchKeys(ctx, jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	ks.Lock()
+	ks.sets[jwksURL] = fetched
+	ks.Unlock()
+
+	key, ok := fetched.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: chave %q desconhecida", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func fetchKeys(ctx context.Context, jwksURL string) (*cachedKeys, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, jwksURL, &doc); err != nil {
+		return nil, fmt.Errorf("chaves do provedor: %w", err)
+	}
+
+	set := &cachedKeys{keys: map[string]crypto.PublicKey{}, fetchedAt: time.Now()}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			set.keys[k.Kid] = key
+		}
+	}
+	return set, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) > 4 {
+			return nil, errors.New("expoente RSA inválido")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("chave RSA curta demais")
+		}
+		return key, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("curva %q não suportada", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("ponto fora da curva")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("tipo de chave %q não suportado", k.Kty)
+	}
+}
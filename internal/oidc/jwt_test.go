@@ -0,0 +1,237 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://accounts.example.test"
+	testClientID = "smartpicks-web"
+	testNonce    = "n-0S6_WzA2Mj"
+)
+
+// testProvider publica um JWKS com uma chave RSA e uma EC e assina tokens com elas
+type testProvider struct {
+	rsaKey  *rsa.PrivateKey
+	ecKey   *ecdsa.PrivateKey
+	jwksURL string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding
+	doc := map[string][]jwk{"keys": {
+		{Kty: "RSA", Kid: "rsa-1", Use: "sig", N: b64.EncodeToString(rsaKey.N.Bytes()), E: b64.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{Kty: "EC", Kid: "ec-1", Crv: "P-256", X: b64.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))), Y: b64.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(srv.Close)
+	return &testProvider{rsaKey: rsaKey, ecKey: ecKey, jwksURL: srv.URL}
+}
+
+// sign monta o JWT; alg decide a assinatura e kid a chave anunciada no cabeçalho
+func (p *testProvider) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	b64 := base64.RawURLEncoding
+	input := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch alg {
+	case "RS256":
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, p.rsaKey, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, p.ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "HS256":
+		// confusão de algoritmo: usa a chave pública RSA como segredo HMAC
+		mac := hmac.New(sha256.New, p.rsaKey.N.Bytes())
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case "none":
+	}
+	return input + "." + b64.EncodeToString(sig)
+}
+
+func validClaims(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   testIssuer,
+		"sub":   "1234567890",
+		"aud":   testClientID,
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"nonce": testNonce,
+		"email": "tipster@example.test",
+	}
+}
+
+func TestVerify(t *testing.T) {
+	p := newTestProvider(t)
+	now := time.Now()
+	exp := expectations{issuer: testIssuer, audience: testClientID, nonce: testNonce}
+
+	with := func(changes map[string]interface{}) map[string]interface{} {
+		c := validClaims(now)
+		for k, v := range changes {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token func() string
+		exp   expectations
+		ok    bool
+	}{
+		{"RS256 válido", func() string { return p.sign(t, "RS256", "rsa-1", validClaims(now)) }, exp, true},
+		{"ES256 válido", func() string { return p.sign(t, "ES256", "ec-1", validClaims(now)) }, exp, true},
+
+		// alg
+		{"alg none", func() string { return p.sign(t, "none", "rsa-1", validClaims(now)) }, exp, false},
+		{"alg HS256 com a chave pública", func() string { return p.sign(t, "HS256", "rsa-1", validClaims(now)) }, exp, false},
+		{"alg ES256 com chave RSA", func() string {
+			tok := p.sign(t, "ES256", "ec-1", validClaims(now))
+			header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "rsa-1"})
+			return base64.RawURLEncoding.EncodeToString(header) + tok[strings.Index(tok, "."):]
+		}, exp, false},
+		{"alg RS256 com chave EC", func() string {
+			tok := p.sign(t, "RS256", "rsa-1", validClaims(now))
+			header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "ec-1"})
+			return base64.RawURLEncoding.EncodeToString(header) + tok[strings.Index(tok, "."):]
+		}, exp, false},
+		{"kid desconhecido", func() string { return p.sign(t, "RS256", "rsa-2", validClaims(now)) }, exp, false},
+		{"payload alterado", func() string {
+			tok := p.sign(t, "RS256", "rsa-1", validClaims(now))
+			forged, _ := json.Marshal(with(map[string]interface{}{"sub": "admin"}))
+			head, sig := tok[:strings.Index(tok, ".")], tok[strings.LastIndex(tok, "."):]
+			return head + "." + base64.RawURLEncoding.EncodeToString(forged) + sig
+		}, exp, false},
+		{"malformado", func() string { return "abc.def" }, exp, false},
+
+		// iss e aud
+		{"emissor incorreto", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"iss": "https://evil.example.test"}))
+		}, exp, false},
+		{"audiência incorreta", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"aud": "outro-app"}))
+		}, exp, false},
+		{"audiência em lista", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"aud": []string{testClientID, "outro-app"}, "azp": testClientID}))
+		}, exp, true},
+		{"audiência em lista sem azp", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"aud": []string{testClientID, "outro-app"}}))
+		}, exp, false},
+		{"audiência em lista com azp de outro app", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"aud": []string{testClientID, "outro-app"}, "azp": "outro-app"}))
+		}, exp, false},
+		{"sub ausente", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"sub": nil}))
+		}, exp, false},
+
+		// exp e iat
+		{"expirado", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"exp": now.Add(-2 * clockSkew).Unix()}))
+		}, exp, false},
+		{"expirado dentro da tolerância", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"exp": now.Add(-clockSkew / 2).Unix()}))
+		}, exp, true},
+		{"sem exp", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"exp": nil}))
+		}, exp, false},
+		{"emitido no futuro", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"iat": now.Add(2 * clockSkew).Unix()}))
+		}, exp, false},
+
+		// nonce
+		{"nonce incorreto", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"nonce": "outro"}))
+		}, exp, false},
+		{"nonce ausente", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"nonce": nil}))
+		}, exp, false},
+		{"sem nonce quando não exigido", func() string {
+			return p.sign(t, "RS256", "rsa-1", with(map[string]interface{}{"nonce": nil}))
+		}, expectations{issuer: testIssuer, audience: testClientID}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verify(context.Background(), tt.token(), p.jwksURL, tt.exp, now)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("verify: %v", err)
+				}
+				if claims.Subject != "1234567890" {
+					t.Errorf("sub = %q", claims.Subject)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("verify = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestBoolishAndAudience(t *testing.T) {
+	tests := []struct {
+		raw      string
+		verified bool
+		aud      []string
+	}{
+		{`{"email_verified":true,"aud":"a"}`, true, []string{"a"}},
+		{`{"email_verified":"true","aud":["a","b"]}`, true, []string{"a", "b"}},
+		{`{"email_verified":false,"aud":"a"}`, false, []string{"a"}},
+		{`{"email_verified":"false","aud":"a"}`, false, []string{"a"}},
+	}
+	for _, tt := range tests {
+		var c Claims
+		if err := json.Unmarshal([]byte(tt.raw), &c); err != nil {
+			t.Fatalf("%s: %v", tt.raw, err)
+		}
+		if bool(c.EmailVerified) != tt.verified {
+			t.Errorf("%s: email_verified = %v", tt.raw, c.EmailVerified)
+		}
+		if len(c.Audience) != len(tt.aud) || !c.Audience.contains(tt.aud[0]) {
+			t.Errorf("%s: aud = %v", tt.raw, c.Audience)
+		}
+	}
+}
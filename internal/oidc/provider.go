@@ -0,0 +1,159 @@
+// Package oidc implementa o login social: fluxo authorization code com PKCE em provedores
+// OpenID Connect (Google e outros) e a verificação de ID tokens do Firebase Authentication.
+// Em ambos os casos a identidade é vinculada a um usuário existente e a sessão é a nossa.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"smartpicks-backend/internal/services"
+)
+
+var (
+	ErrUnknownProvider = errors.New("provedor de login não configurado")
+	ErrNotConfigured   = errors.New("login com Firebase não está configurado no servidor")
+)
+
+// issuers conhecidos, usados quando OIDC_<NOME>_ISSUER não é informado
+var knownIssuers = map[string]string{
+	"google": "https://accounts.google.com",
+}
+
+// Provider é um provedor OpenID Connect configurado por variáveis de ambiente:
+// OIDC_PROVIDERS lista os nomes e, para cada um, OIDC_<NOME>_ISSUER, OIDC_<NOME>_CLIENT_ID,
+// OIDC_<NOME>_CLIENT_SECRET (opcional em clientes públicos) e OIDC_<NOME>_SCOPES.
+type Provider struct {
+	Name         string `json:"nome"`
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"-"`
+	clientSecret string
+	Scopes       []string `json:"-"`
+}
+
+// Providers devolve os provedores configurados, na ordem de OIDC_PROVIDERS
+func Providers() []*Provider {
+	var list []*Provider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		if p, err := GetProvider(strings.TrimSpace(name)); err == nil {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// GetProvider lê a configuração do provedor; provedores fora de OIDC_PROVIDERS ou sem
+// issuer e client id não existem
+func GetProvider(name string) (*Provider, error) {
+	name = strings.ToLower(name)
+	if name == "" || !listed(name) {
+		return nil, ErrUnknownProvider
+	}
+	prefix := "OIDC_" + strings.ToUpper(name) + "_"
+	p := &Provider{
+		Name:         name,
+		Issuer:       strings.TrimRight(os.Getenv(prefix+"ISSUER"), "/"),
+		ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+		clientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+		Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+	}
+	if p.Issuer == "" {
+		p.Issuer = knownIssuers[name]
+	}
+	if len(p.Scopes) == 0 {
+		p.Scopes = []string{"openid", "email", "profile"}
+	}
+	if p.Issuer == "" || p.ClientID == "" {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+func listed(name string) bool {
+	for _, n := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		if strings.EqualFold(strings.TrimSpace(n), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedirectURL é a página do frontend que recebe o code e o state do provedor e os envia
+// para POST /api/auth/oidc/callback. Precisa estar cadastrada no provedor.
+func RedirectURL() string {
+	if u := os.Getenv("OIDC_REDIRECT_URL"); u != "" {
+		return u
+	}
+	return services.FrontendURL() + "/login/callback"
+}
+
+// metadata é o documento de descoberta (/.well-known/openid-configuration)
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// discoveryTTL é por quanto tempo o documento de descoberta fica em cache
+const discoveryTTL = time.Hour
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+var discovery = struct {
+	sync.Mutex
+	docs map[string]cachedMetadata
+}{docs: map[string]cachedMetadata{}}
+
+type cachedMetadata struct {
+	metadata
+	fetchedAt time.Time
+}
+
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	discovery.Lock()
+	cached, ok := discovery.docs[p.Issuer]
+	discovery.Unlock()
+	if ok && time.Since(cached.fetchedAt) < discoveryTTL {
+		return &cached.metadata, nil
+	}
+
+	var m metadata
+	if err := getJSON(ctx, p.Issuer+"/.well-known/openid-configuration", &m); err != nil {
+		return nil, fmt.Errorf("descoberta do provedor %s: %w", p.Name, err)
+	}
+	if m.Issuer != p.Issuer {
+		return nil, fmt.Errorf("descoberta do provedor %s: issuer %q diferente do configurado", p.Name, m.Issuer)
+	}
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, fmt.Errorf("descoberta do provedor %s: documento incompleto", p.Name)
+	}
+
+	discovery.Lock()
+	discovery.docs[p.Issuer] = cachedMetadata{metadata: m, fetchedAt: time.Now()}
+	discovery.Unlock()
+	return &m, nil
+}
+
+func getJSON(ctx context.Context, endpoint string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s respondeu %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
@@ -0,0 +1,97 @@
+package passwords
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// breachedList é a lista embutida: SHA-1 (hex maiúsculo) de senhas comuns em vazamentos, uma
+// por linha, no formato dos arquivos do Have I Been Pwned ("HASH" ou "HASH:ocorrências").
+// BREACHED_PASSWORDS_FILE aponta para um arquivo maior no mesmo formato.
+//
+//go:embed breached_sha1.txt
+var breachedList []byte
+
+// prefixLen é o tamanho do prefixo do hash usado na consulta, como na API de faixas do HIBP:
+// a busca parte dos 5 primeiros caracteres e compara apenas os sufixos daquela faixa
+const prefixLen = 5
+
+// rangeIndex agrupa os sufixos (ordenados) pelo prefixo do hash
+type rangeIndex map[string][]string
+
+var (
+	indexOnce sync.Once
+	index     rangeIndex
+	indexErr  error
+)
+
+// Breached indica se a senha está na lista de senhas vazadas
+func Breached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := Range(hash[:prefixLen])
+	if err != nil {
+		return false, err
+	}
+	i := sort.SearchStrings(suffixes, hash[prefixLen:])
+	return i < len(suffixes) && suffixes[i] == hash[prefixLen:], nil
+}
+
+// Range devolve os sufixos dos hashes vazados que começam com o prefixo. Só o prefixo sai da
+// função, o que permite trocar a lista local por um serviço de faixas sem expor a senha.
+func Range(prefix string) ([]string, error) {
+	indexOnce.Do(func() {
+		var r io.Reader = bytes.NewReader(breachedList)
+		if path := os.Getenv("BREACHED_PASSWORDS_FILE"); path != "" {
+			f, err := os.Open(path)
+			if err != nil {
+				indexErr = fmt.Errorf("lista de senhas vazadas: %w", err)
+				return
+			}
+			defer f.Close()
+			r = f
+		}
+		index, indexErr = loadIndex(r)
+	})
+	if indexErr != nil {
+		return nil, indexErr
+	}
+	return index[strings.ToUpper(prefix)], nil
+}
+
+// loadIndex lê o arquivo ignorando linhas em branco e comentários (#)
+func loadIndex(r io.Reader) (rangeIndex, error) {
+	idx := rangeIndex{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+		if len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("lista de senhas vazadas: linha %d inválida", line)
+		}
+		hash = strings.ToUpper(hash)
+		idx[hash[:prefixLen]] = append(idx[hash[:prefixLen]], hash[prefixLen:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for prefix := range idx {
+		sort.Strings(idx[prefix])
+	}
+	return idx, nil
+}
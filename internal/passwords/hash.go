@@ -0,0 +1,170 @@
+// Package passwords concentra o tratamento de senhas: hash (bcrypt ou argon2id, com troca
+// transparente no login), política de composição e a consulta à lista de senhas vazadas.
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"smartpicks-backend/internal/database"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algoritmos aceitos em PASSWORD_HASH_ALGORITHM
+const (
+	ALGORITMO_BCRYPT   = "bcrypt"
+	ALGORITMO_ARGON2ID = "argon2id"
+)
+
+// Parâmetros do argon2id (mínimos recomendados pela OWASP). Mudá-los faz os hashes antigos serem
+// refeitos no próximo login, como acontece com o custo do bcrypt.
+const (
+	argonMemory  = 19 * 1024 // KiB
+	argonTime    = 2
+	argonThreads = 1
+	argonSaltLen = 16
+	argonKeyLen  = 32
+)
+
+const defaultBcryptCost = 12
+
+var (
+	ErrMismatch      = errors.New("senha incorreta")
+	ErrUnknownFormat = errors.New("formato de hash de senha desconhecido")
+)
+
+var b64 = base64.RawStdEncoding
+
+// Algorithm lê PASSWORD_HASH_ALGORITHM (padrão bcrypt)
+func Algorithm() string {
+	if strings.EqualFold(os.Getenv("PASSWORD_HASH_ALGORITHM"), ALGORITMO_ARGON2ID) {
+		return ALGORITMO_ARGON2ID
+	}
+	return ALGORITMO_BCRYPT
+}
+
+// bcryptCost lê BCRYPT_COST (padrão 12)
+func bcryptCost() int {
+	if cost, err := strconv.Atoi(os.Getenv("BCRYPT_COST")); err == nil && cost >= bcrypt.MinCost && cost <= bcrypt.MaxCost {
+		return cost
+	}
+	return defaultBcryptCost
+}
+
+// Hash gera o hash da senha com o algoritmo configurado
+func Hash(password string) (string, error) {
+	if Algorithm() == ALGORITMO_ARGON2ID {
+		salt := make([]byte, argonSaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, argonMemory, argonTime, argonThreads, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost())
+	return string(hash), err
+}
+
+// Verify confere a senha com o hash, seja qual for o algoritmo em que ele foi gerado.
+// Devolve ErrMismatch quando a senha não confere.
+func Verify(hash, password string) error {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := parseArgon2(hash)
+		if err != nil {
+			return err
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return ErrMismatch
+		}
+		return nil
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
+
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// VerifyDummy gasta o mesmo tempo de Verify quando o email não está cadastrado, para o tempo de
+// resposta do login não revelar quais contas existem
+func VerifyDummy(password string) {
+	dummyOnce.Do(func() {
+		dummyHash, _ = Hash("smartpicks-conta-inexistente")
+	})
+	Verify(dummyHash, password)
+}
+
+// NeedsRehash indica se o hash foi gerado com outro algoritmo ou parâmetros que os atuais
+func NeedsRehash(hash string) bool {
+	if Algorithm() == ALGORITMO_ARGON2ID {
+		params, _, key, err := parseArgon2(hash)
+		return err != nil || params != (argonParams{argonMemory, argonTime, argonThreads}) || len(key) != argonKeyLen
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != bcryptCost()
+}
+
+// Upgrade refaz o hash com a configuração atual depois de um login com a senha correta. A
+// condição no UPDATE evita sobrescrever uma troca de senha concorrente; falhas só vão para o log.
+func Upgrade(userID int, hash, password string) {
+	if !NeedsRehash(hash) {
+		return
+	}
+	newHash, err := Hash(password)
+	if err != nil {
+		log.Printf("Erro ao refazer hash da senha do usuário %d: %v", userID, err)
+		return
+	}
+	if _, err := database.DB.Exec(
+		"UPDATE users SET password = $2 WHERE id = $1 AND password = $3", userID, newHash, hash); err != nil {
+		log.Printf("Erro ao atualizar hash da senha do usuário %d: %v", userID, err)
+	}
+}
+
+type argonParams struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+// parseArgon2 lê o formato PHC: $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+func parseArgon2(hash string) (argonParams, []byte, []byte, error) {
+	var p argonParams
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != ALGORITMO_ARGON2ID {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	return p, salt, key, nil
+}
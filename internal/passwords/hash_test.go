@@ -0,0 +1,159 @@
+package passwords
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// config é uma combinação de PASSWORD_HASH_ALGORITHM e BCRYPT_COST; custo 4 mantém os testes rápidos
+type config struct {
+	algorithm string
+	cost      string
+}
+
+func (c config) apply(t *testing.T) {
+	t.Helper()
+	t.Setenv("PASSWORD_HASH_ALGORITHM", c.algorithm)
+	t.Setenv("BCRYPT_COST", c.cost)
+}
+
+var (
+	bcrypt4  = config{ALGORITMO_BCRYPT, "4"}
+	bcrypt5  = config{ALGORITMO_BCRYPT, "5"}
+	argon2id = config{ALGORITMO_ARGON2ID, "4"}
+)
+
+func hashWith(t *testing.T, c config, password string) string {
+	t.Helper()
+	c.apply(t)
+	hash, err := Hash(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestHashVerifyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config
+		prefix   string
+		password string
+	}{
+		{"bcrypt", bcrypt4, "$2a$04$", "Palpite#Certo2024"},
+		{"argon2id", argon2id, "$argon2id$v=19$m=19456,t=2,p=1$", "Palpite#Certo2024"},
+		{"argon2id com acentos", argon2id, "$argon2id$", "Açaí-de-Ôlinda#7"},
+		{"bcrypt no limite de 72 bytes", bcrypt4, "$2a$04$", strings.Repeat("a", maxBytes)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := hashWith(t, tt.config, tt.password)
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("hash = %q, want prefixo %q", hash, tt.prefix)
+			}
+			if err := Verify(hash, tt.password); err != nil {
+				t.Errorf("Verify com a senha correta: %v", err)
+			}
+			if err := Verify(hash, "x"+tt.password[1:]); !errors.Is(err, ErrMismatch) {
+				t.Errorf("Verify com a senha errada = %v, want ErrMismatch", err)
+			}
+			if again := hashWith(t, tt.config, tt.password); again == hash {
+				t.Error("dois hashes da mesma senha são iguais; o salt não está sendo usado")
+			}
+		})
+	}
+}
+
+func TestVerifyAcrossAlgorithms(t *testing.T) {
+	// Trocar PASSWORD_HASH_ALGORITHM não pode impedir o login com hashes antigos
+	const password = "Palpite#Certo2024"
+	oldBcrypt := hashWith(t, bcrypt4, password)
+	oldArgon := hashWith(t, argon2id, password)
+
+	for _, current := range []config{bcrypt4, argon2id} {
+		current.apply(t)
+		for _, hash := range []string{oldBcrypt, oldArgon} {
+			if err := Verify(hash, password); err != nil {
+				t.Errorf("com %s, Verify(%.12s...): %v", current.algorithm, hash, err)
+			}
+		}
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"vazio", ""},
+		{"texto puro", "Palpite#Certo2024"},
+		{"argon2 sem partes", "$argon2id$v=19$m=19456,t=2,p=1"},
+		{"argon2 de outra versão", "$argon2id$v=16$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{"argon2 sem parâmetros", "$argon2id$v=19$x$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{"argon2 com salt inválido", "$argon2id$v=19$m=19456,t=2,p=1$!!!$a2V5"},
+		{"argon2 sem chave", "$argon2id$v=19$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0c2FsdA$"},
+		{"argon2i", "$argon2i$v=19$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.hash, "Palpite#Certo2024")
+			if err == nil || errors.Is(err, ErrMismatch) {
+				t.Errorf("Verify(%q) = %v, want erro de formato", tt.hash, err)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	const password = "Palpite#Certo2024"
+	hashes := map[config]string{
+		bcrypt4:  hashWith(t, bcrypt4, password),
+		bcrypt5:  hashWith(t, bcrypt5, password),
+		argon2id: hashWith(t, argon2id, password),
+	}
+	weakArgon := "$argon2id$v=19$m=4096,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$" + strings.Repeat("A", 43)
+
+	tests := []struct {
+		name    string
+		current config
+		hash    string
+		want    bool
+	}{
+		{"bcrypt com o mesmo custo", bcrypt4, hashes[bcrypt4], false},
+		{"bcrypt com custo menor", bcrypt5, hashes[bcrypt4], true},
+		{"bcrypt com custo maior", bcrypt4, hashes[bcrypt5], true},
+		{"argon2id quando o padrão é bcrypt", bcrypt4, hashes[argon2id], true},
+		{"bcrypt quando o padrão é argon2id", argon2id, hashes[bcrypt4], true},
+		{"argon2id com os parâmetros atuais", argon2id, hashes[argon2id], false},
+		{"argon2id com parâmetros antigos", argon2id, weakArgon, true},
+		{"hash ilegível", argon2id, "lixo", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.current.apply(t)
+			if got := NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBcryptCost(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", defaultBcryptCost},
+		{"10", 10},
+		{"3", defaultBcryptCost},
+		{"32", defaultBcryptCost},
+		{"doze", defaultBcryptCost},
+	}
+	for _, tt := range tests {
+		t.Setenv("BCRYPT_COST", tt.env)
+		if got := bcryptCost(); got != tt.want {
+			t.Errorf("BCRYPT_COST=%q: custo %d, want %d", tt.env, got, tt.want)
+		}
+	}
+}
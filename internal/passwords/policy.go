@@ -0,0 +1,131 @@
+package passwords
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"smartpicks-backend/internal/models"
+)
+
+// maxBytes é o limite do bcrypt; vale também para o argon2id, para que trocar o algoritmo não
+// invalide senhas
+const maxBytes = 72
+
+// Policy são as regras de composição de senhas, lidas do ambiente
+type Policy struct {
+	// TamanhoMinimo vem de PASSWORD_MIN_LENGTH (padrão 10)
+	TamanhoMinimo int `json:"tamanho_minimo"`
+	// ClassesMinimas vem de PASSWORD_MIN_CLASSES (padrão 3): quantos tipos de caractere, entre
+	// minúsculas, maiúsculas, números e símbolos, a senha deve ter
+	ClassesMinimas int `json:"classes_minimas"`
+	// VerificarVazadas vem de PASSWORD_CHECK_BREACHED (padrão true)
+	VerificarVazadas bool `json:"verificar_vazadas"`
+	TamanhoMaximo    int  `json:"tamanho_maximo"`
+}
+
+// PolicyError lista todos os requisitos não atendidos, para o usuário corrigir de uma vez
+type PolicyError struct {
+	Problemas []string
+}
+
+func (e *PolicyError) Error() string {
+	return "a senha não atende aos requisitos: " + strings.Join(e.Problemas, "; ")
+}
+
+// CurrentPolicy devolve a política configurada
+func CurrentPolicy() Policy {
+	p := Policy{TamanhoMinimo: 10, ClassesMinimas: 3, VerificarVazadas: true, TamanhoMaximo: maxBytes}
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && n >= 6 && n <= maxBytes {
+		p.TamanhoMinimo = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_CLASSES")); err == nil && n >= 1 && n <= 4 {
+		p.ClassesMinimas = n
+	}
+	if os.Getenv("PASSWORD_CHECK_BREACHED") == "false" {
+		p.VerificarVazadas = false
+	}
+	return p
+}
+
+// Validate confere a nova senha com a política: tamanho, tipos de caractere, ausência do email
+// e do nome do usuário e, se habilitado, a lista de senhas vazadas. Devolve *PolicyError.
+func Validate(password, email, nome string) error {
+	p := CurrentPolicy()
+	var problemas []string
+
+	if n := len([]rune(password)); n < p.TamanhoMinimo {
+		problemas = append(problemas, fmt.Sprintf("deve ter no mínimo %d caracteres", p.TamanhoMinimo))
+	}
+	if len(password) > maxBytes {
+		problemas = append(problemas, fmt.Sprintf("deve ter no máximo %d bytes", maxBytes))
+	}
+	if classes := characterClasses(password); classes < p.ClassesMinimas {
+		problemas = append(problemas, fmt.Sprintf(
+			"deve combinar pelo menos %d destes tipos: letras minúsculas, letras maiúsculas, números e símbolos", p.ClassesMinimas))
+	}
+	if containsPersonalInfo(password, email, nome) {
+		problemas = append(problemas, "não pode conter seu email ou seu nome")
+	}
+	if p.VerificarVazadas && len(problemas) == 0 {
+		breached, err := Breached(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			problemas = append(problemas, "aparece em vazamentos de dados conhecidos; escolha outra")
+		}
+	}
+
+	if len(problemas) > 0 {
+		return &PolicyError{Problemas: problemas}
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	n := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// containsPersonalInfo procura na senha, sem acentos nem diferença de maiúsculas, o início do
+// email e as partes do email e do nome com 4 letras ou mais ("joao.silva@..." veta "Joao@2024")
+func containsPersonalInfo(password, email, nome string) bool {
+	folded := fold(password)
+	local, _, _ := strings.Cut(email, "@")
+
+	terms := strings.Split(models.Slugify(local), "-")
+	terms = append(terms, fold(local))
+	terms = append(terms, strings.Split(models.Slugify(nome), "-")...)
+	for _, term := range terms {
+		if len(term) >= 4 && strings.Contains(folded, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// fold deixa só letras minúsculas sem acento e números
+func fold(s string) string {
+	return strings.ReplaceAll(models.Slugify(s), "-", "")
+}
@@ -0,0 +1,78 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const FakeProviderName = "fake"
+
+var fakeSequence atomic.Int64
+
+// FakeProvider simula um gateway para desenvolvimento e testes: aprova toda cobrança na hora
+// (ou deixa pendente com FAKE_PAYMENT_PENDING=true) e aceita webhooks JSON {"id", "type", "ref"}
+// assinados com HMAC-SHA256 de PAYMENT_WEBHOOK_SECRET no header X-Fake-Signature.
+type FakeProvider struct {
+	secret  string
+	pending bool
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		secret:  os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+		pending: os.Getenv("FAKE_PAYMENT_PENDING") == "true",
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error) {
+	ref := fmt.Sprintf("fake_%d_%d_%d", req.SubscriptionID, time.Now().UnixNano(), fakeSequence.Add(1))
+	checkout := &Checkout{Ref: ref, Paid: !p.pending}
+	if p.pending {
+		checkout.URL = req.ReturnURL + "?checkout=" + ref
+	}
+	return checkout, nil
+}
+
+func (p *FakeProvider) Cancel(ctx context.Context, ref string) error {
+	return nil
+}
+
+func (p *FakeProvider) ParseWebhook(r *http.Request) (*WebhookEvent, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		return nil, ErrInvalidWebhook
+	}
+	if p.secret == "" || !hmac.Equal([]byte(r.Header.Get("X-Fake-Signature")), []byte(Sign(p.secret, body))) {
+		return nil, ErrInvalidWebhook
+	}
+
+	var payload struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Ref  string `json:"ref"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ID == "" || payload.Ref == "" {
+		return nil, ErrInvalidWebhook
+	}
+	return &WebhookEvent{ID: payload.ID, Type: payload.Type, Ref: payload.Ref}, nil
+}
+
+// Sign calcula a assinatura hexadecimal HMAC-SHA256 do corpo, usada pelo FakeProvider
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
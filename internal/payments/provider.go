@@ -0,0 +1,95 @@
+// Package payments integra provedores de pagamento para as assinaturas de tipsters.
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Tipos de evento recebidos pelo webhook do provedor
+const (
+	EventPaymentSucceeded = "payment_succeeded"
+	EventPaymentFailed    = "payment_failed"
+	EventCanceled         = "subscription_canceled"
+)
+
+var (
+	ErrNotConfigured   = errors.New("pagamentos não configurados")
+	ErrUnknownProvider = errors.New("provedor de pagamento desconhecido")
+	ErrInvalidWebhook  = errors.New("webhook inválido")
+)
+
+// CheckoutRequest descreve a cobrança recorrente de uma assinatura
+type CheckoutRequest struct {
+	SubscriptionID int
+	PlanNome       string
+	AmountCents    int
+	Currency       string
+	IntervalMonths int
+	CustomerEmail  string
+	ReturnURL      string
+}
+
+// Checkout é a cobrança criada no provedor. Paid indica confirmação imediata (sem redirecionamento);
+// caso contrário o usuário conclui o pagamento em URL e a confirmação chega pelo webhook.
+type Checkout struct {
+	Ref  string
+	URL  string
+	Paid bool
+}
+
+// WebhookEvent é a notificação do provedor já validada. ID é o identificador único do evento no
+// provedor, usado para descartar reenvios.
+type WebhookEvent struct {
+	ID   string
+	Type string
+	Ref  string
+}
+
+// Provider é implementado por cada gateway de pagamento
+type Provider interface {
+	Name() string
+	CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error)
+	Cancel(ctx context.Context, ref string) error
+	// ParseWebhook valida a assinatura da requisição do provedor e extrai o evento
+	ParseWebhook(r *http.Request) (*WebhookEvent, error)
+}
+
+// FromEnv retorna o provedor configurado em PAYMENT_PROVIDER; sem configuração, as assinaturas
+// ficam desabilitadas (ErrNotConfigured)
+func FromEnv() (Provider, error) {
+	name := configuredName()
+	if name == "" {
+		return nil, ErrNotConfigured
+	}
+	return newProvider(name)
+}
+
+// ByName retorna o provedor pelo nome usado nas rotas de webhook. Só o provedor configurado é
+// aceito: sem isso, /api/payments/webhook/fake ativaria assinaturas em produção com o segredo
+// do fake.
+func ByName(name string) (Provider, error) {
+	configured := configuredName()
+	if configured == "" {
+		return nil, ErrNotConfigured
+	}
+	if name != configured {
+		return nil, ErrUnknownProvider
+	}
+	return newProvider(name)
+}
+
+func configuredName() string {
+	return strings.TrimSpace(os.Getenv("PAYMENT_PROVIDER"))
+}
+
+func newProvider(name string) (Provider, error) {
+	switch name {
+	case FakeProviderName:
+		return NewFakeProvider(), nil
+	}
+	return nil, ErrUnknownProvider
+}
@@ -0,0 +1,188 @@
+package privacy
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/services"
+)
+
+var (
+	ErrDeletionPending   = errors.New("já existe uma solicitação de exclusão pendente")
+	ErrNoPendingDeletion = errors.New("nenhuma solicitação de exclusão pendente")
+)
+
+// GracePeriod lê ACCOUNT_DELETION_GRACE_DAYS (padrão 30 dias), prazo em que o usuário pode desistir
+func GracePeriod() time.Duration {
+	if days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS")); err == nil && days >= 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return 30 * 24 * time.Hour
+}
+
+// RequestDeletion agenda a exclusão da conta para depois do período de carência
+func RequestDeletion(user *models.User, ip string) (*models.DeletionRequest, error) {
+	if _, err := PendingDeletion(user.ID); err == nil {
+		return nil, ErrDeletionPending
+	} else if err != ErrNoPendingDeletion {
+		return nil, err
+	}
+
+	return scanDeletionRequest(database.DB.QueryRow(`
+		INSERT INTO account_deletion_requests (user_id, email_hash, scheduled_for, requested_ip)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, status, requested_at, scheduled_for, cancelled_at, completed_at,
+				  palpites_removed, files_removed`,
+		user.ID, hashEmail(user.Email), time.Now().Add(GracePeriod()), ip))
+}
+
+// PendingDeletion retorna a solicitação pendente do usuário ou ErrNoPendingDeletion
+func PendingDeletion(userID int) (*models.DeletionRequest, error) {
+	d, err := scanDeletionRequest(database.DB.QueryRow(`
+		SELECT id, user_id, status, requested_at, scheduled_for, cancelled_at, completed_at,
+			   palpites_removed, files_removed
+		FROM account_deletion_requests
+		WHERE user_id = $1 AND status = $2`, userID, models.DELECAO_PENDENTE))
+	if err == sql.ErrNoRows {
+		return nil, ErrNoPendingDeletion
+	}
+	return d, err
+}
+
+// CancelDeletion desiste da exclusão durante o período de carência
+func CancelDeletion(userID int) error {
+	result, err := database.DB.Exec(`
+		UPDATE account_deletion_requests
+		SET status = $1, cancelled_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2 AND status = $3`,
+		models.DELECAO_CANCELADA, userID, models.DELECAO_PENDENTE)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNoPendingDeletion
+	}
+	return nil
+}
+
+// PurgeDueAccounts executa as exclusões cujo período de carência terminou.
+// Retorna quantas contas foram removidas; falhas individuais são registradas e tentadas de novo na próxima execução.
+func PurgeDueAccounts() (int, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, user_id FROM account_deletion_requests
+		WHERE status = $1 AND scheduled_for <= CURRENT_TIMESTAMP
+		ORDER BY scheduled_for`, models.DELECAO_PENDENTE)
+	if err != nil {
+		return 0, err
+	}
+
+	type due struct{ requestID, userID int }
+	var pending []due
+	for rows.Next() {
+		var d due
+		if err := rows.Scan(&d.requestID, &d.userID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, d := range pending {
+		if err := purgeAccount(d.requestID, d.userID); err != nil {
+			log.Printf("Erro ao excluir conta %d (solicitação %d): %v", d.userID, d.requestID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeAccount remove os arquivos enviados do S3 e depois apaga o usuário e seus palpites
+func purgeAccount(requestID, userID int) error {
+	keys, err := storedObjectKeys(userID)
+	if err != nil {
+		return err
+	}
+
+	removedFiles := 0
+	if len(keys) > 0 {
+		s3Service, err := services.NewS3Service()
+		if err != nil {
+			return fmt.Errorf("S3 indisponível: %w", err)
+		}
+		for _, key := range keys {
+			if err := s3Service.DeleteFile(key); err != nil {
+				return fmt.Errorf("falha ao remover %s do S3: %w", key, err)
+			}
+			removedFiles++
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM palpites WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+	removedPalpites, _ := result.RowsAffected()
+
+	if _, err := tx.Exec("DELETE FROM uploaded_files WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM users WHERE id = $1", userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE account_deletion_requests
+		SET status = $1, completed_at = CURRENT_TIMESTAMP, palpites_removed = $2, files_removed = $3
+		WHERE id = $4`,
+		models.DELECAO_CONCLUIDA, removedPalpites, removedFiles, requestID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// storedObjectKeys lista as chaves S3 dos arquivos enviados pelo próprio usuário. As chaves vêm
+// apenas de uploaded_files: avatar e img_url são URLs informadas pelo usuário e poderiam apontar
+// para arquivos de outra pessoa.
+func storedObjectKeys(userID int) ([]string, error) {
+	files, err := userFiles(userID)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var keys []string
+	for _, f := range files {
+		if f.Key != "" && !seen[f.Key] {
+			seen[f.Key] = true
+			keys = append(keys, f.Key)
+		}
+	}
+	return keys, nil
+}
+
+func hashEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
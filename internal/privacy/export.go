@@ -0,0 +1,116 @@
+package privacy
+
+import (
+	"time"
+
+	"smartpicks-backend/internal/compliance"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+)
+
+// BuildExport reúne o perfil, os palpites, os arquivos enviados e demais registros do usuário
+func BuildExport(user *models.User) (*models.DataExport, error) {
+	export := &models.DataExport{
+		GeradoEm:            time.Now(),
+		Usuario:             user.ToResponse(),
+		Palpites:            []models.PalpiteResponse{},
+		Arquivos:            []models.UploadedFile{},
+		SolicitacoesDelecao: []models.DeletionRequest{},
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT `+models.PalpiteColumns+`
+		FROM palpites WHERE user_id = $1
+		ORDER BY created_at`, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		p, err := models.ScanPalpite(rows)
+		if err != nil {
+			return nil, err
+		}
+		export.Palpites = append(export.Palpites, p.ToResponse())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	files, err := userFiles(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.Arquivos = append(export.Arquivos, files...)
+
+	settings, err := compliance.LoadSettings(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.JogoResponsavel = settings
+
+	requests, err := userDeletionRequests(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.SolicitacoesDelecao = append(export.SolicitacoesDelecao, requests...)
+
+	return export, nil
+}
+
+func userFiles(userID int) ([]models.UploadedFile, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, user_id, s3_key, url, content_type, size_bytes, original_name, created_at
+		FROM uploaded_files WHERE user_id = $1
+		ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []models.UploadedFile
+	for rows.Next() {
+		var f models.UploadedFile
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Key, &f.URL, &f.ContentType, &f.Size, &f.OriginalName, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+func userDeletionRequests(userID int) ([]models.DeletionRequest, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, user_id, status, requested_at, scheduled_for, cancelled_at, completed_at,
+			   palpites_removed, files_removed
+		FROM account_deletion_requests WHERE user_id = $1
+		ORDER BY requested_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []models.DeletionRequest
+	for rows.Next() {
+		d, err := scanDeletionRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, *d)
+	}
+	return requests, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDeletionRequest(s scanner) (*models.DeletionRequest, error) {
+	var d models.DeletionRequest
+	err := s.Scan(&d.ID, &d.UserID, &d.Status, &d.RequestedAt, &d.ScheduledFor,
+		&d.CancelledAt, &d.CompletedAt, &d.PalpitesRemovidos, &d.ArquivosRemovidos)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,120 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/notifications"
+	"smartpicks-backend/internal/services"
+)
+
+// Delivery é o job que envia uma notificação a uma inscrição; cada navegador tem seu próprio
+// job para que falhas em um não repitam o envio aos demais
+type Delivery struct {
+	SubscriptionID int `json:"subscription_id"`
+	NotificationID int `json:"notification_id"`
+}
+
+func (Delivery) JobType() string { return "push_delivery" }
+
+// Message é o JSON entregue ao Service Worker
+type Message struct {
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	Tipo           string `json:"tipo"`
+	NotificationID int    `json:"notification_id"`
+	URL            string `json:"url"`
+}
+
+// Register inscreve o Web Push nas notificações criadas e registra o job de entrega
+func Register() {
+	jobs.Register(deliver)
+	events.Subscribe("push", onNotificationCreated)
+}
+
+// onNotificationCreated enfileira uma entrega por inscrição do usuário. Sem VAPID configurado
+// não há o que enviar.
+func onNotificationCreated(ctx context.Context, e events.NotificationCreated) error {
+	if _, err := VAPIDFromEnv(); errors.Is(err, ErrNotConfigured) {
+		return nil
+	}
+
+	rows, err := database.DB.QueryContext(ctx, "SELECT id FROM push_subscriptions WHERE user_id = $1", e.UserID)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		d := Delivery{SubscriptionID: id, NotificationID: e.NotificationID}
+		opts := jobs.Options{UniqueKey: fmt.Sprintf("push:%d:%d", id, e.NotificationID)}
+		if _, err := jobs.EnqueueWith(database.DB, d, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deliver(ctx context.Context, d Delivery) error {
+	v, err := VAPIDFromEnv()
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	sub, err := get(d.SubscriptionID)
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		return nil // o navegador cancelou a inscrição depois do enfileiramento
+	}
+	if err != nil {
+		return err
+	}
+	n, err := notifications.Get(d.NotificationID)
+	if errors.Is(err, notifications.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(messageFor(n.Tipo, n.Mensagem, n.ID, n.PalpiteID))
+	if err != nil {
+		return err
+	}
+
+	err = Send(ctx, v, sub, payload)
+	switch {
+	case errors.Is(err, ErrGone):
+		log.Printf("Inscrição push %d expirada; removendo", sub.ID)
+		return remove(sub.ID)
+	case errors.Is(err, ErrRejected):
+		return jobs.Permanent(err)
+	case err != nil:
+		return err
+	}
+	return markSent(sub.ID)
+}
+
+func messageFor(tipo, mensagem string, notificationID int, palpiteID *int) Message {
+	link := services.FrontendURL() + "/notificacoes"
+	if palpiteID != nil {
+		link = fmt.Sprintf("%s/palpites/%d", services.FrontendURL(), *palpiteID)
+	}
+	return Message{Title: "SmartPicks", Body: mensagem, Tipo: tipo, NotificationID: notificationID, URL: link}
+}
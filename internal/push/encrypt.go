@@ -0,0 +1,159 @@
+package push
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// recordSize é o rs do cabeçalho aes128gcm; o payload inteiro vai em um único registro
+const recordSize = 4096
+
+// MaxPayload é o maior payload aceito pelos serviços de push: corpo de até 4096 bytes, menos o
+// cabeçalho (86), a tag GCM (16) e o delimitador de registro (1)
+const MaxPayload = recordSize - 16 - 1 - 86
+
+// Keys são as chaves da inscrição do navegador (PushSubscription.keys)
+type Keys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// Encrypt cifra o payload para a inscrição conforme a RFC 8291, com uma chave efêmera por
+// mensagem. O resultado é o corpo da requisição com Content-Encoding: aes128gcm.
+func Encrypt(keys Keys, plaintext []byte) ([]byte, error) {
+	if len(plaintext) > MaxPayload {
+		return nil, fmt.Errorf("payload maior que %d bytes", MaxPayload)
+	}
+	uaPublic, authSecret, err := decodeKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encrypt(uaPublic, authSecret, asPrivate, salt, plaintext)
+}
+
+func encrypt(uaPublic *ecdh.PublicKey, authSecret []byte, asPrivate *ecdh.PrivateKey, salt, plaintext []byte) ([]byte, error) {
+	asPublic := asPrivate.PublicKey().Bytes()
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, nonce, err := contentCipher(ecdhSecret, authSecret, uaPublic.Bytes(), asPublic, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	// 0x02 marca o último (e único) registro
+	record := append(append([]byte{}, plaintext...), 0x02)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// Decrypt é o inverso de Encrypt, do lado do navegador (uaPrivate). Usado pelo stub de testes.
+func Decrypt(uaPrivate *ecdh.PrivateKey, authSecret, body []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, errors.New("corpo aes128gcm truncado")
+	}
+	salt := body[:16]
+	idLen := int(body[20])
+	if len(body) < 21+idLen {
+		return nil, errors.New("corpo aes128gcm truncado")
+	}
+	asPublicBytes := body[21 : 21+idLen]
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: keyid", ErrInvalidKey)
+	}
+	ecdhSecret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, nonce, err := contentCipher(ecdhSecret, authSecret, uaPrivate.PublicKey().Bytes(), asPublicBytes, salt)
+	if err != nil {
+		return nil, err
+	}
+	record, err := gcm.Open(nil, nonce, body[21+idLen:], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove o padding (zeros) e o delimitador
+	for i := len(record) - 1; i >= 0; i-- {
+		switch record[i] {
+		case 0x00:
+			continue
+		case 0x02:
+			return record[:i], nil
+		}
+		break
+	}
+	return nil, errors.New("delimitador de registro ausente")
+}
+
+// contentCipher deriva a chave de conteúdo (CEK) e o nonce da RFC 8291 §3.4 / RFC 8188 §2.2
+func contentCipher(ecdhSecret, authSecret, uaPublic, asPublic, salt []byte) (cipher.AEAD, []byte, error) {
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, nil, err
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	return gcm, nonce, err
+}
+
+func decodeKeys(keys Keys) (*ecdh.PublicKey, []byte, error) {
+	raw, err := decodeBase64(keys.P256dh)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: p256dh", ErrInvalidKey)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: p256dh", ErrInvalidKey)
+	}
+	authSecret, err := decodeBase64(keys.Auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, nil, fmt.Errorf("%w: auth", ErrInvalidKey)
+	}
+	return uaPublic, authSecret, nil
+}
+
+// ValidateKeys confere se as chaves enviadas pelo navegador são utilizáveis
+func ValidateKeys(keys Keys) error {
+	_, _, err := decodeKeys(keys)
+	return err
+}
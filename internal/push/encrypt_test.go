@@ -0,0 +1,133 @@
+package push
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// Exemplo da seção 5 da RFC 8291, em base64url sem padding
+const (
+	rfcPlaintext = "When I grow up, I want to be a watermelon"
+	rfcASPrivate = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	rfcUAPublic  = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	rfcUAPrivate = "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"
+	rfcSalt      = "DGv6ra1nlYgDCS1FRnbzlw"
+	rfcAuth      = "BTBZMqHH6r4Tts7J_aSIgg"
+	rfcBody      = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := decodeBase64(s)
+	if err != nil {
+		t.Fatalf("decodeBase64(%q): %v", s, err)
+	}
+	return b
+}
+
+func TestEncryptRFC8291(t *testing.T) {
+	uaPublic, authSecret, err := decodeKeys(Keys{P256dh: rfcUAPublic, Auth: rfcAuth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	asPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, rfcASPrivate))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := encrypt(uaPublic, authSecret, asPrivate, mustDecode(t, rfcSalt), []byte(rfcPlaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustDecode(t, rfcBody); !bytes.Equal(got, want) {
+		t.Errorf("encrypt = %s, want %s", b64.EncodeToString(got), rfcBody)
+	}
+}
+
+func TestDecryptRFC8291(t *testing.T) {
+	uaPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, rfcUAPrivate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(uaPrivate, mustDecode(t, rfcAuth), mustDecode(t, rfcBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != rfcPlaintext {
+		t.Errorf("Decrypt = %q, want %q", got, rfcPlaintext)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	uaPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	keys := Keys{P256dh: b64.EncodeToString(uaPrivate.PublicKey().Bytes()), Auth: b64.EncodeToString(auth)}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		wantErr bool
+	}{
+		{"vazio", []byte{}, false},
+		{"texto", []byte(`{"titulo":"Novo palpite"}`), false},
+		{"limite", bytes.Repeat([]byte("a"), MaxPayload), false},
+		{"acima do limite", bytes.Repeat([]byte("a"), MaxPayload+1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := Encrypt(keys, tt.payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Encrypt aceitou payload acima do limite")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(body) > recordSize {
+				t.Errorf("corpo com %d bytes, maior que o registro de %d", len(body), recordSize)
+			}
+			got, err := Decrypt(uaPrivate, auth, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.payload) {
+				t.Errorf("Decrypt = %q, want %q", got, tt.payload)
+			}
+		})
+	}
+}
+
+func TestValidateKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys Keys
+		ok   bool
+	}{
+		{"exemplo da RFC", Keys{P256dh: rfcUAPublic, Auth: rfcAuth}, true},
+		{"auth com padding", Keys{P256dh: rfcUAPublic, Auth: rfcAuth + "=="}, true},
+		{"p256dh fora da curva", Keys{P256dh: "B" + rfcUAPublic[1:len(rfcUAPublic)-4] + "AAAA", Auth: rfcAuth}, false},
+		{"p256dh inválido", Keys{P256dh: "não é base64", Auth: rfcAuth}, false},
+		{"auth curto", Keys{P256dh: rfcUAPublic, Auth: "BTBZMqHH6r4"}, false},
+		{"auth ausente", Keys{P256dh: rfcUAPublic}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKeys(tt.keys)
+			if tt.ok && err != nil {
+				t.Errorf("ValidateKeys: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("ValidateKeys = %v, want ErrInvalidKey", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"smartpicks-backend/internal/webhooks"
+)
+
+var (
+	// ErrGone indica que o serviço de push descartou a inscrição (404/410): ela deve ser removida
+	ErrGone = errors.New("inscrição expirada no serviço de push")
+	// ErrRejected são recusas que não se resolvem com nova tentativa (payload ou chaves inválidas)
+	ErrRejected = errors.New("push recusado pelo serviço")
+)
+
+// defaultTTL é por quanto tempo o serviço de push guarda a mensagem se o navegador estiver offline
+const defaultTTL = 24 * time.Hour
+
+// client não segue redirecionamentos (um 3xx é recusa) e confere o IP de cada conexão, como nos
+// webhooks: o endpoint vem do navegador e não pode levar o servidor à rede interna
+var client = &http.Client{
+	Timeout: 15 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: guardAddress}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        20,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// guardAddress libera a rede local apenas com o stub, que roda no próprio servidor
+func guardAddress(network, address string, c syscall.RawConn) error {
+	if StubEnabled() {
+		return nil
+	}
+	return webhooks.PublicOnly(network, address, c)
+}
+
+// StubEnabled indica se o serviço de push local para testes está ativo (PUSH_STUB=true).
+// Com ele, endpoints http também são aceitos.
+func StubEnabled() bool {
+	return os.Getenv("PUSH_STUB") == "true"
+}
+
+// ValidateEndpoint exige https e um host público (http e rede local só com o stub), evitando que
+// o servidor faça requisições arbitrárias em nome do usuário. O IP é conferido de novo a cada
+// conexão.
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || u.User != nil {
+		return errors.New("endpoint inválido")
+	}
+	if StubEnabled() {
+		if u.Scheme != "https" && u.Scheme != "http" {
+			return errors.New("endpoint deve usar https")
+		}
+		return nil
+	}
+	if u.Scheme != "https" {
+		return errors.New("endpoint deve usar https")
+	}
+	if !webhooks.PublicHost(u.Hostname()) {
+		return errors.New("endpoint deve apontar para um serviço de push público")
+	}
+	return nil
+}
+
+// Send cifra o payload e o entrega ao serviço de push da inscrição
+func Send(ctx context.Context, v *VAPID, sub *Subscription, payload []byte) error {
+	body, err := Encrypt(sub.Keys, payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	authorization, err := v.Authorization(sub.Endpoint, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(defaultTTL.Seconds())))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrGone
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("serviço de push respondeu %d: %s", resp.StatusCode, detail)
+	default:
+		return fmt.Errorf("%w: status %d: %s", ErrRejected, resp.StatusCode, detail)
+	}
+}
@@ -0,0 +1,128 @@
+package push
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxStubMessages limita quantas mensagens o stub guarda em memória
+const maxStubMessages = 100
+
+// Stub é um serviço de push local para testes: gera inscrições com chaves conhecidas pelo
+// servidor, recebe os pushes como um FCM/Mozilla receberia (conferindo o VAPID) e guarda o
+// conteúdo já decifrado. O estado fica em memória, por instância.
+type Stub struct {
+	mu       sync.Mutex
+	subs     map[string]*stubSubscription
+	messages []StubMessage
+}
+
+type stubSubscription struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+// StubMessage é um push recebido pelo stub
+type StubMessage struct {
+	Endpoint   string    `json:"endpoint"`
+	VAPIDKey   string    `json:"vapid_key"`
+	Audience   string    `json:"audience"`
+	TTL        string    `json:"ttl"`
+	Payload    string    `json:"payload"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+var DefaultStub = &Stub{subs: map[string]*stubSubscription{}}
+
+// NewSubscription cria uma inscrição falsa cujo endpoint é baseURL + "/" + id
+func (s *Stub) NewSubscription(baseURL string) (string, Keys, error) {
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", Keys{}, err
+	}
+	auth := make([]byte, 16)
+	idBytes := make([]byte, 12)
+	if _, err := rand.Read(auth); err != nil {
+		return "", Keys{}, err
+	}
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", Keys{}, err
+	}
+	id := b64.EncodeToString(idBytes)
+
+	s.mu.Lock()
+	s.subs[id] = &stubSubscription{private: private, auth: auth}
+	s.mu.Unlock()
+
+	keys := Keys{P256dh: b64.EncodeToString(private.PublicKey().Bytes()), Auth: b64.EncodeToString(auth)}
+	return baseURL + "/" + id, keys, nil
+}
+
+// Expire descarta a inscrição: os próximos pushes recebem 410, como em um serviço real
+func (s *Stub) Expire(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.subs[id]
+	delete(s.subs, id)
+	return ok
+}
+
+// Receive processa um push enviado ao endpoint id e devolve o status HTTP a responder
+func (s *Stub) Receive(id string, r *http.Request) (int, error) {
+	s.mu.Lock()
+	sub, ok := s.subs[id]
+	s.mu.Unlock()
+	if !ok {
+		return http.StatusGone, errors.New("inscrição inexistente ou expirada")
+	}
+
+	vapidKey, audience, err := VerifyAuthorization(r.Header.Get("Authorization"), time.Now())
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+	if r.Header.Get("Content-Encoding") != "aes128gcm" {
+		return http.StatusUnsupportedMediaType, errors.New("Content-Encoding deve ser aes128gcm")
+	}
+	if r.Header.Get("TTL") == "" {
+		return http.StatusBadRequest, errors.New("header TTL obrigatório")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 4097))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if len(body) > 4096 {
+		return http.StatusRequestEntityTooLarge, errors.New("payload maior que 4096 bytes")
+	}
+	plaintext, err := Decrypt(sub.private, sub.auth, body)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, StubMessage{
+		Endpoint:   id,
+		VAPIDKey:   vapidKey,
+		Audience:   audience,
+		TTL:        r.Header.Get("TTL"),
+		Payload:    string(plaintext),
+		ReceivedAt: time.Now(),
+	})
+	if len(s.messages) > maxStubMessages {
+		s.messages = s.messages[len(s.messages)-maxStubMessages:]
+	}
+	s.mu.Unlock()
+	return http.StatusCreated, nil
+}
+
+// Messages devolve as mensagens recebidas, mais antigas primeiro
+func (s *Stub) Messages() []StubMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StubMessage{}, s.messages...)
+}
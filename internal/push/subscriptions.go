@@ -0,0 +1,105 @@
+package push
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"smartpicks-backend/internal/database"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("inscrição não encontrada")
+	ErrSubscriptionConflict = errors.New("endpoint já inscrito por outra conta")
+)
+
+// Subscription é a inscrição de um navegador (PushSubscription do Service Worker)
+type Subscription struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Endpoint   string     `json:"endpoint"`
+	Keys       Keys       `json:"keys"`
+	UserAgent  *string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+}
+
+const subscriptionColumns = `id, user_id, endpoint, p256dh, auth, user_agent, created_at, last_sent_at`
+
+func scanSubscription(row interface{ Scan(...interface{}) error }) (*Subscription, error) {
+	var s Subscription
+	err := row.Scan(&s.ID, &s.UserID, &s.Endpoint, &s.Keys.P256dh, &s.Keys.Auth, &s.UserAgent, &s.CreatedAt, &s.LastSentAt)
+	return &s, err
+}
+
+// Save registra a inscrição do usuário. O endpoint é único: uma nova inscrição do mesmo navegador
+// atualiza as chaves, mas um endpoint de outra conta nunca muda de dono (quem conhecesse o
+// endpoint passaria a receber os pushes da vítima) e devolve ErrSubscriptionConflict. Na troca de
+// conta o frontend cancela a inscrição (DELETE e PushSubscription.unsubscribe) e gera outra.
+func Save(userID int, endpoint string, keys Keys, userAgent string) (*Subscription, error) {
+	var ua *string
+	if userAgent != "" {
+		ua = &userAgent
+	}
+	s, err := scanSubscription(database.DB.QueryRow(`
+		INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (endpoint) DO UPDATE SET p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth,
+			user_agent = EXCLUDED.user_agent
+		WHERE push_subscriptions.user_id = EXCLUDED.user_id
+		RETURNING `+subscriptionColumns, userID, endpoint, keys.P256dh, keys.Auth, ua))
+	if err == sql.ErrNoRows {
+		return nil, ErrSubscriptionConflict
+	}
+	return s, err
+}
+
+// Delete remove a inscrição do endpoint, desde que pertença ao usuário
+func Delete(userID int, endpoint string) error {
+	result, err := database.DB.Exec("DELETE FROM push_subscriptions WHERE user_id = $1 AND endpoint = $2", userID, endpoint)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func ListByUser(userID int) ([]Subscription, error) {
+	rows, err := database.DB.Query(
+		"SELECT "+subscriptionColumns+" FROM push_subscriptions WHERE user_id = $1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Subscription{}
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *s)
+	}
+	return list, rows.Err()
+}
+
+func get(id int) (*Subscription, error) {
+	s, err := scanSubscription(database.DB.QueryRow(
+		"SELECT "+subscriptionColumns+" FROM push_subscriptions WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrSubscriptionNotFound
+	}
+	return s, err
+}
+
+func markSent(id int) error {
+	_, err := database.DB.Exec("UPDATE push_subscriptions SET last_sent_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+	return err
+}
+
+func remove(id int) error {
+	_, err := database.DB.Exec("DELETE FROM push_subscriptions WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,170 @@
+// Package push envia Web Push para navegadores inscritos: identificação do servidor por VAPID
+// (RFC 8292) e criptografia do payload com aes128gcm (RFC 8291 / RFC 8188).
+package push
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotConfigured = errors.New("web push não configurado (VAPID_PUBLIC_KEY/VAPID_PRIVATE_KEY)")
+	ErrInvalidKey    = errors.New("chave inválida")
+)
+
+// vapidTTL é a validade do JWT enviado ao serviço de push (máximo de 24h pela RFC 8292)
+const vapidTTL = 12 * time.Hour
+
+// VAPID é o par de chaves P-256 que identifica o servidor perante os serviços de push
+type VAPID struct {
+	PublicKey  string // ponto não comprimido (65 bytes), base64url sem padding
+	PrivateKey string // escalar (32 bytes), base64url sem padding
+	Subject    string // mailto: ou https: de contato
+	key        *ecdsa.PrivateKey
+}
+
+var b64 = base64.RawURLEncoding
+
+// GenerateVAPID cria um novo par de chaves
+func GenerateVAPID() (*VAPID, error) {
+	priv, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewVAPID(b64.EncodeToString(priv.Bytes()), "")
+}
+
+// NewVAPID carrega a chave privada (base64url) e deriva a pública
+func NewVAPID(privateKey, subject string) (*VAPID, error) {
+	raw, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	priv, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	pub := priv.PublicKey().Bytes()
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+	return &VAPID{PublicKey: b64.EncodeToString(pub), PrivateKey: b64.EncodeToString(raw), Subject: subject, key: key}, nil
+}
+
+// VAPIDFromEnv lê VAPID_PRIVATE_KEY, VAPID_PUBLIC_KEY (conferida contra a privada) e VAPID_SUBJECT
+func VAPIDFromEnv() (*VAPID, error) {
+	private := os.Getenv("VAPID_PRIVATE_KEY")
+	if private == "" {
+		return nil, ErrNotConfigured
+	}
+	subject := os.Getenv("VAPID_SUBJECT")
+	if subject == "" {
+		subject = "mailto:no-reply@smartpicks.com"
+	}
+	v, err := NewVAPID(private, subject)
+	if err != nil {
+		return nil, err
+	}
+	if public := os.Getenv("VAPID_PUBLIC_KEY"); public != "" && strings.TrimRight(public, "=") != v.PublicKey {
+		return nil, fmt.Errorf("%w: VAPID_PUBLIC_KEY não corresponde a VAPID_PRIVATE_KEY", ErrInvalidKey)
+	}
+	return v, nil
+}
+
+// Authorization monta o header "vapid t=<jwt>, k=<chave pública>" para o endpoint de push
+func (v *VAPID) Authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("endpoint inválido: %s", endpoint)
+	}
+
+	header := b64.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidTTL).Unix(),
+		"sub": v.Subject,
+	})
+	signingInput := header + "." + b64.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, v.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", signingInput, b64.EncodeToString(sig), v.PublicKey), nil
+}
+
+// VerifyAuthorization confere o header VAPID recebido por um serviço de push (usado pelo stub).
+// Devolve a chave pública e o audience do token.
+func VerifyAuthorization(header string, now time.Time) (publicKey string, audience string, err error) {
+	var token string
+	for _, part := range strings.Split(strings.TrimPrefix(header, "vapid "), ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case strings.HasPrefix(part, "t="):
+			token = part[2:]
+		case strings.HasPrefix(part, "k="):
+			publicKey = part[2:]
+		}
+	}
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 || publicKey == "" {
+		return "", "", errors.New("header vapid malformado")
+	}
+
+	pub, err := decodeBase64(publicKey)
+	if err != nil || len(pub) != 65 || pub[0] != 4 {
+		return "", "", ErrInvalidKey
+	}
+	key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(pub[1:33]), Y: new(big.Int).SetBytes(pub[33:])}
+	sig, err := decodeBase64(segments[2])
+	if err != nil || len(sig) != 64 {
+		return "", "", errors.New("assinatura vapid inválida")
+	}
+	digest := sha256.Sum256([]byte(segments[0] + "." + segments[1]))
+	if !ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		return "", "", errors.New("assinatura vapid inválida")
+	}
+
+	rawClaims, err := decodeBase64(segments[1])
+	if err != nil {
+		return "", "", errors.New("claims vapid inválidas")
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return "", "", errors.New("claims vapid inválidas")
+	}
+	if now.Unix() > claims.Exp {
+		return "", "", errors.New("token vapid expirado")
+	}
+	return publicKey, claims.Aud, nil
+}
+
+// decodeBase64 aceita base64url com ou sem padding (navegadores usam sem)
+func decodeBase64(s string) ([]byte, error) {
+	return b64.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,205 @@
+package results
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/settlement"
+)
+
+var ErrEventNotFound = errors.New("evento não encontrado")
+
+// Sync busca os resultados do provider e os aplica com Apply
+func Sync(ctx context.Context, p Provider, dryRun bool) (*models.SettlementReport, error) {
+	list, err := p.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.Name(), err)
+	}
+	return Apply(list, dryRun)
+}
+
+// Apply grava os placares e liquida os palpites dos eventos afetados em uma única transação.
+// Em dry run a transação é desfeita ao final, devolvendo apenas a prévia das liquidações.
+// Resultados inválidos ou de eventos desconhecidos são reportados em Errors e ignorados.
+func Apply(list []models.EventResult, dryRun bool) (*models.SettlementReport, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &models.SettlementReport{DryRun: dryRun, Settled: []models.SettlementChange{}}
+	eventIDs := recordAll(tx, list, report)
+
+	if len(eventIDs) > 0 {
+		report.Settled, report.Skipped, err = settlement.Settle(tx, eventIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if err := publishSettled(tx, report.Settled); err != nil {
+		return nil, err
+	}
+	return report, tx.Commit()
+}
+
+// Record grava os placares e enfileira a liquidação dos eventos afetados na mesma transação,
+// para que a requisição não espere a liquidação. O job só existe se os placares forem gravados.
+func Record(list []models.EventResult) (*models.SettlementReport, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &models.SettlementReport{Settled: []models.SettlementChange{}}
+	eventIDs := recordAll(tx, list, report)
+
+	if len(eventIDs) > 0 {
+		if report.JobID, err = jobs.EnqueueTx(tx, jobs.SettleEvents{EventIDs: eventIDs}, jobs.Options{}); err != nil {
+			return nil, err
+		}
+	}
+	return report, tx.Commit()
+}
+
+// SettleEvents liquida os palpites pendentes dos eventos (todos os encerrados se ids for vazio)
+func SettleEvents(eventIDs []int) (*models.SettlementReport, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &models.SettlementReport{}
+	if report.Settled, report.Skipped, err = settlement.Settle(tx, eventIDs); err != nil {
+		return nil, err
+	}
+	if err := publishSettled(tx, report.Settled); err != nil {
+		return nil, err
+	}
+	return report, tx.Commit()
+}
+
+// SettlePending liquida os palpites pendentes de todos os eventos já encerrados ou cancelados
+func SettlePending(dryRun bool) (*models.SettlementReport, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &models.SettlementReport{DryRun: dryRun}
+	if report.Settled, report.Skipped, err = settlement.Settle(tx, nil); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if err := publishSettled(tx, report.Settled); err != nil {
+		return nil, err
+	}
+	return report, tx.Commit()
+}
+
+// publishSettled emite um evento por palpite liquidado, na mesma transação da liquidação
+func publishSettled(tx *sql.Tx, changes []models.SettlementChange) error {
+	for _, c := range changes {
+		e := events.PalpiteSettled{PalpiteID: c.PalpiteID, UserID: c.UserID, Status: c.Status, Lucro: c.Lucro}
+		if err := events.PublishTx(tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordAll grava os resultados válidos e devolve os ids dos eventos; os inválidos vão para report.Errors
+func recordAll(tx *sql.Tx, list []models.EventResult, report *models.SettlementReport) []int {
+	var eventIDs []int
+	for i, res := range list {
+		id, err := record(tx, res)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("resultado %d: %v", i+1, err))
+			continue
+		}
+		eventIDs = append(eventIDs, id)
+		report.Results++
+	}
+	return eventIDs
+}
+
+// record grava o placar do evento (resolvido por id ou ref externa). Se o status ou o placar
+// mudarem em um evento já liquidado (inclusive cancelado ↔ encerrado), os palpites voltam a
+// pendente para serem reavaliados.
+func record(tx *sql.Tx, res models.EventResult) (int, error) {
+	if res.Status == "" {
+		res.Status = models.EVENTO_ENCERRADO
+	}
+	if res.Status != models.EVENTO_ENCERRADO && res.Status != models.EVENTO_CANCELADO {
+		return 0, errors.New("status deve ser encerrado ou cancelado")
+	}
+	if res.Status == models.EVENTO_ENCERRADO && (res.HomeScore < 0 || res.AwayScore < 0) {
+		return 0, errors.New("placar inválido")
+	}
+
+	var id int
+	var status string
+	var home, away *int
+	var err error
+	switch {
+	case res.EventID > 0:
+		err = tx.QueryRow("SELECT id, status, home_score, away_score FROM events WHERE id = $1 FOR UPDATE", res.EventID).
+			Scan(&id, &status, &home, &away)
+	case res.ExternalRef != "":
+		err = tx.QueryRow("SELECT id, status, home_score, away_score FROM events WHERE external_ref = $1 FOR UPDATE", res.ExternalRef).
+			Scan(&id, &status, &home, &away)
+	default:
+		return 0, errors.New("informe event_id ou ref")
+	}
+	if err == sql.ErrNoRows {
+		return 0, ErrEventNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var homeScore, awayScore *int
+	if res.Status == models.EVENTO_ENCERRADO {
+		homeScore, awayScore = &res.HomeScore, &res.AwayScore
+	}
+	// Palpites só são liquidados em eventos encerrados ou cancelados; nos demais Reopen não altera nada
+	if status != res.Status || !sameScore(home, homeScore) || !sameScore(away, awayScore) {
+		if err := settlement.Reopen(tx, id); err != nil {
+			return 0, err
+		}
+	}
+
+	finishedAt := time.Now()
+	if res.FinishedAt != nil {
+		finishedAt = *res.FinishedAt
+	}
+	_, err = tx.Exec(`
+		UPDATE events SET status = $1, home_score = $2, away_score = $3, finished_at = $4,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5`, res.Status, homeScore, awayScore, finishedAt, id)
+	return id, err
+}
+
+func sameScore(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,106 @@
+// Package results recebe placares finais de eventos (admin, arquivo ou feeds externos) e
+// dispara a liquidação automática dos palpites.
+package results
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"smartpicks-backend/internal/models"
+)
+
+// Provider é uma fonte de resultados. Feeds externos devem implementar esta interface e
+// resolver os eventos pela ref externa (external_ref) sempre que possível.
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) ([]models.EventResult, error)
+}
+
+// FileProvider lê resultados de um arquivo JSON ({"results": [...]}) ou CSV com cabeçalho
+// event_id,ref,home_score,away_score,status (event_id ou ref por linha)
+type FileProvider struct {
+	Path string
+}
+
+func (p FileProvider) Name() string {
+	return "file:" + filepath.Base(p.Path)
+}
+
+func (p FileProvider) Fetch(ctx context.Context) ([]models.EventResult, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(p.Path)) {
+	case ".json":
+		var body struct {
+			Results []models.EventResult `json:"results"`
+		}
+		if err := json.NewDecoder(f).Decode(&body); err != nil {
+			return nil, fmt.Errorf("JSON inválido: %w", err)
+		}
+		return body.Results, nil
+	case ".csv":
+		return readCSV(f)
+	}
+	return nil, errors.New("formato não suportado, use .json ou .csv")
+}
+
+func readCSV(r io.Reader) ([]models.EventResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("CSV sem cabeçalho: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, col string) string {
+		i, ok := index[col]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var list []models.EventResult
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var res models.EventResult
+		res.ExternalRef = field(record, "ref")
+		res.Status = field(record, "status")
+		if v := field(record, "event_id"); v != "" {
+			if res.EventID, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("linha %d: event_id inválido", line)
+			}
+		}
+		if res.HomeScore, err = strconv.Atoi(field(record, "home_score")); err != nil && res.Status != models.EVENTO_CANCELADO {
+			return nil, fmt.Errorf("linha %d: home_score inválido", line)
+		}
+		if res.AwayScore, err = strconv.Atoi(field(record, "away_score")); err != nil && res.Status != models.EVENTO_CANCELADO {
+			return nil, fmt.Errorf("linha %d: away_score inválido", line)
+		}
+		list = append(list, res)
+	}
+	return list, nil
+}
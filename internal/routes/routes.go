@@ -3,8 +3,13 @@ package routes
 import (
 	"net/http"
 
+	"smartpicks-backend/internal/audit"
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/compliance"
 	"smartpicks-backend/internal/database"
 	"smartpicks-backend/internal/handlers"
+	"smartpicks-backend/internal/push"
+	"smartpicks-backend/internal/tasks"
 
 	"github.com/gorilla/mux"
 )
@@ -20,9 +25,10 @@ func enableCORS(next http.Handler) http.Handler {
 		if allowed[origin] {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 		}
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Origin, Accept")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Origin, Accept, Last-Event-ID")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Next-Cursor, X-Impersonated-By")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -32,22 +38,187 @@ func enableCORS(next http.Handler) http.Handler {
 	})
 }
 
+// pickRoute aplica autenticação e as regras de jogo responsável às rotas de palpites
+func pickRoute(h http.HandlerFunc) http.HandlerFunc {
+	return auth.RequireAuth(compliance.EnforceExclusion(h))
+}
+
+// ownerRoute exige sessão do próprio usuário: tokens de personificação são recusados
+func ownerRoute(h http.HandlerFunc) http.HandlerFunc {
+	return auth.RequireAuth(auth.DenyImpersonation(h))
+}
+
 func RegisterRoutes(r *mux.Router) {
 	database.Connect()
+	tasks.Register()
 
 	r.Use(enableCORS)
 
+	// Rotas do cron ficam fora do subrouter /api: o token do cron não é um token de sessão.
+	// A Vercel chama com GET.
+	r.Handle("/api/cron/run-due-jobs", auth.RequireCronOrAdmin(handlers.RunDueJobs)).Methods("GET", "POST", "OPTIONS")
+	r.Handle("/api/cron/run-jobs", auth.RequireCronOrAdmin(handlers.RunJobs)).Methods("GET", "POST", "OPTIONS")
+
+	// SSE: o EventSource não envia headers, então o token também é aceito em ?token=
+	r.Handle("/api/stream", auth.TokenFromQuery(auth.Authenticate(audit.ImpersonatedRequests(auth.RequireAuth(compliance.EnforceExclusion(handlers.Stream)))))).Methods("GET", "OPTIONS")
+
 	api := r.PathPrefix("/api").Subrouter()
+	api.Use(auth.Authenticate)
+	api.Use(audit.ImpersonatedRequests)
 
 	api.HandleFunc("/login", handlers.Login).Methods("POST", "OPTIONS")
+	api.HandleFunc("/login/2fa", handlers.LoginTwoFactor).Methods("POST", "OPTIONS")
 	api.HandleFunc("/register", handlers.Register).Methods("POST", "OPTIONS")
-	api.HandleFunc("/users", handlers.GetAllUsers).Methods("GET", "OPTIONS")
-	api.HandleFunc("/users/permissions", handlers.CheckUserPermissions).Methods("GET", "OPTIONS")
-	api.HandleFunc("/users/profile", handlers.GetUsersByProfile).Methods("GET", "OPTIONS")
-	api.HandleFunc("/users/avatar", handlers.UpdateAvatar).Methods("POST", "PUT", "OPTIONS")
-	api.HandleFunc("/users/avatar", handlers.DeleteAvatar).Methods("DELETE", "OPTIONS")
-	api.HandleFunc("/palpites", handlers.PostPalpite).Methods("POST", "OPTIONS")
-	api.HandleFunc("/upload", handlers.UploadImageHandler).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/refresh", handlers.RefreshSession).Methods("POST", "OPTIONS")
+	api.HandleFunc("/logout", auth.RequireAuth(handlers.Logout)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/providers", handlers.GetLoginProviders).Methods("GET", "OPTIONS")
+	api.HandleFunc("/auth/oidc/{provider}/authorize", handlers.StartOIDCLogin).Methods("GET", "OPTIONS")
+	api.HandleFunc("/auth/oidc/callback", handlers.OIDCCallback).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/firebase", handlers.FirebaseLogin).Methods("POST", "OPTIONS")
+	api.HandleFunc("/users", auth.RequireAuth(handlers.GetAllUsers)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/users/permissions", auth.RequireAuth(handlers.CheckUserPermissions)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/users/profile", auth.RequireAuth(handlers.GetUsersByProfile)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/users/avatar", auth.RequireAuth(handlers.UpdateAvatar)).Methods("POST", "PUT", "OPTIONS")
+	api.HandleFunc("/users/avatar", auth.RequireAuth(handlers.DeleteAvatar)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/email/verify", handlers.VerifyEmail).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me", auth.RequireAuth(handlers.GetMe)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me", ownerRoute(handlers.UpdateMe)).Methods("PATCH", "OPTIONS")
+	api.HandleFunc("/me/password", ownerRoute(handlers.ChangePassword)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/2fa", auth.RequireAuth(handlers.GetTwoFactorStatus)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/2fa/setup", ownerRoute(handlers.SetupTwoFactor)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/2fa/enable", ownerRoute(handlers.EnableTwoFactor)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/2fa/disable", ownerRoute(handlers.DisableTwoFactor)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/2fa/recovery-codes", ownerRoute(handlers.RegenerateRecoveryCodes)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/identities", auth.RequireAuth(handlers.GetMyIdentities)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/identities/{id:[0-9]+}", ownerRoute(handlers.DeleteMyIdentity)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/me/sessions", auth.RequireAuth(handlers.GetMySessions)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/sessions", ownerRoute(handlers.DeleteMySessions)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/me/sessions/{id:[0-9]+}", ownerRoute(handlers.DeleteMySession)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/me/api-keys", auth.RequireAuth(handlers.GetMyAPIKeys)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/api-keys", ownerRoute(handlers.CreateMyAPIKey)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/api-keys/{id:[0-9]+}", ownerRoute(handlers.DeleteMyAPIKey)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/me/responsible-gaming", auth.RequireAuth(handlers.GetGamblingSettings)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/responsible-gaming", ownerRoute(handlers.UpdateGamblingSettings)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/me/export", ownerRoute(handlers.ExportMyData)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me", ownerRoute(handlers.DeleteMyAccount)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/me/deletion", auth.RequireAuth(handlers.GetMyAccountDeletion)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/deletion", ownerRoute(handlers.CancelMyAccountDeletion)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/admin/privacy/purge", auth.RequireAdmin(handlers.PurgeDeletedAccounts)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/password/reset", handlers.ResetPassword).Methods("POST", "OPTIONS")
+	api.HandleFunc("/password/policy", handlers.GetPasswordPolicy).Methods("GET", "OPTIONS")
+	api.HandleFunc("/admin/users/{id:[0-9]+}", auth.RequireAdmin(handlers.AdminGetUser)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/admin/users/{id:[0-9]+}", auth.RequireAdmin(handlers.AdminDeleteUser)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/admin/users/{id:[0-9]+}/perfil", auth.RequireAdmin(handlers.AdminChangePerfil)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/admin/users/{id:[0-9]+}/suspend", auth.RequireAdmin(handlers.AdminSuspendUser)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/users/{id:[0-9]+}/unsuspend", auth.RequireAdmin(handlers.AdminUnsuspendUser)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/users/{id:[0-9]+}/password-reset", auth.RequireAdmin(handlers.AdminForcePasswordReset)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/users/{id:[0-9]+}/2fa/reset", auth.RequireAdmin(handlers.AdminResetTwoFactor)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/users/{id:[0-9]+}/impersonate", auth.RequireAdmin(handlers.AdminImpersonateUser)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/invitations", auth.RequireAdmin(handlers.CreateInvitation)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/invitations", auth.RequireAdmin(handlers.GetInvitations)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/admin/invitations/{id:[0-9]+}", auth.RequireAdmin(handlers.RevokeInvitation)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/invitations/accept", ownerRoute(handlers.AcceptInvitation)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/audit", auth.RequireAdmin(handlers.GetAuditEvents)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/admin/exclusions", auth.RequireAdmin(handlers.GetActiveExclusions)).Methods("GET", "OPTIONS")
+
+	// Rotas de palpites: exigem sessão e respeitam pausa/autoexclusão. As que declaram escopo
+	// também aceitam chaves de API.
+	api.HandleFunc("/palpites", auth.RequireScope(auth.ESCOPO_ESCREVER_PALPITES, pickRoute(handlers.PostPalpite))).Methods("POST", "OPTIONS")
+	api.HandleFunc("/palpites", auth.RequireScope(auth.ESCOPO_LER_PALPITES, compliance.EnforceExclusion(handlers.GetPalpites))).Methods("GET", "OPTIONS")
+	api.HandleFunc("/palpites/{id:[0-9]+}", auth.RequireScope(auth.ESCOPO_LER_PALPITES, compliance.EnforceExclusion(handlers.GetPalpite))).Methods("GET", "OPTIONS")
+	api.HandleFunc("/palpites/{id:[0-9]+}", auth.RequireScope(auth.ESCOPO_ESCREVER_PALPITES, pickRoute(handlers.UpdatePalpite))).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/palpites/{id:[0-9]+}", auth.RequireScope(auth.ESCOPO_ESCREVER_PALPITES, pickRoute(handlers.DeletePalpite))).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/palpites/{id:[0-9]+}/comments", auth.RequireScope(auth.ESCOPO_LER_PALPITES, compliance.EnforceExclusion(handlers.GetComments))).Methods("GET", "OPTIONS")
+	api.HandleFunc("/palpites/{id:[0-9]+}/comments", pickRoute(handlers.PostComment)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/comments/{id:[0-9]+}", pickRoute(handlers.DeleteComment)).Methods("DELETE", "OPTIONS")
+
+	// Central de notificações
+	api.HandleFunc("/notifications", auth.RequireAuth(handlers.GetNotifications)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/notifications/unread-count", auth.RequireAuth(handlers.GetUnreadNotificationCount)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/notifications/read-all", auth.RequireAuth(handlers.MarkAllNotificationsRead)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/notifications/{id:[0-9]+}/read", auth.RequireAuth(handlers.MarkNotificationRead)).Methods("POST", "OPTIONS")
+
+	// Web Push
+	api.HandleFunc("/push/vapid-public-key", handlers.GetVAPIDPublicKey).Methods("GET", "OPTIONS")
+	api.HandleFunc("/push/subscriptions", auth.RequireAuth(handlers.GetPushSubscriptions)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/push/subscriptions", ownerRoute(handlers.SavePushSubscription)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/push/subscriptions", ownerRoute(handlers.DeletePushSubscription)).Methods("DELETE", "OPTIONS")
+	if push.StubEnabled() {
+		// Serviço de push local, apenas para desenvolvimento e testes
+		api.HandleFunc("/push/stub/subscriptions", handlers.CreateStubPushSubscription).Methods("POST", "OPTIONS")
+		api.HandleFunc("/push/stub/endpoints/{id}", handlers.ReceiveStubPush).Methods("POST")
+		api.HandleFunc("/push/stub/endpoints/{id}", handlers.ExpireStubPush).Methods("DELETE", "OPTIONS")
+		api.HandleFunc("/push/stub/messages", handlers.GetStubPushMessages).Methods("GET", "OPTIONS")
+	}
+
+	// Webhooks de integradores
+	api.HandleFunc("/webhooks", auth.RequireAuth(handlers.GetWebhooks)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/webhooks", ownerRoute(handlers.CreateWebhook)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/webhooks/{id:[0-9]+}", auth.RequireAuth(handlers.GetWebhook)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/webhooks/{id:[0-9]+}", ownerRoute(handlers.UpdateWebhook)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/webhooks/{id:[0-9]+}", ownerRoute(handlers.DeleteWebhook)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/webhooks/{id:[0-9]+}/secret", ownerRoute(handlers.RotateWebhookSecret)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/webhooks/{id:[0-9]+}/ping", ownerRoute(handlers.PingWebhook)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/webhooks/{id:[0-9]+}/deliveries", auth.RequireAuth(handlers.GetWebhookDeliveries)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/webhooks/{id:[0-9]+}/deliveries/{delivery_id:[0-9]+}", auth.RequireAuth(handlers.GetWebhookDelivery)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/webhooks/{id:[0-9]+}/deliveries/{delivery_id:[0-9]+}/redeliver", ownerRoute(handlers.RedeliverWebhook)).Methods("POST", "OPTIONS")
+
+	// Publicação dos palpites no Telegram e no Discord
+	api.HandleFunc("/social/templates", auth.RequireAuth(handlers.GetSocialTemplates)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/social-channels", auth.RequireAuth(handlers.GetSocialChannels)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/social-channels", ownerRoute(handlers.CreateSocialChannel)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/social-channels/{id:[0-9]+}", ownerRoute(handlers.UpdateSocialChannel)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/me/social-channels/{id:[0-9]+}", ownerRoute(handlers.DeleteSocialChannel)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/me/social-channels/{id:[0-9]+}/test", ownerRoute(handlers.TestSocialChannel)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/social-channels/{id:[0-9]+}/posts", auth.RequireAuth(handlers.GetSocialChannelPosts)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/social-channels/{id:[0-9]+}/verification", ownerRoute(handlers.StartSocialChannelVerification)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/social/telegram/webhook", handlers.TelegramWebhook).Methods("POST")
+
+	api.HandleFunc("/upload", pickRoute(handlers.UploadImageHandler)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/odds/convert", handlers.ConvertOdds).Methods("GET", "OPTIONS")
+	api.HandleFunc("/search", compliance.EnforceExclusion(handlers.Search)).Methods("GET", "OPTIONS")
+
+	// Seguidores, planos de assinatura e pagamentos
+	api.HandleFunc("/users/{id:[0-9]+}/follow", auth.RequireAuth(handlers.FollowUser)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/users/{id:[0-9]+}/follow", auth.RequireAuth(handlers.UnfollowUser)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/users/{id:[0-9]+}/follows", handlers.GetFollowStats).Methods("GET", "OPTIONS")
+	api.HandleFunc("/tipsters/{id:[0-9]+}/plans", handlers.GetTipsterPlans).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/plans", auth.RequireAuth(handlers.GetMyPlans)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/plans", ownerRoute(handlers.SaveMyPlan)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/plans/{id:[0-9]+}", ownerRoute(handlers.SaveMyPlan)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/me/plans/{id:[0-9]+}", ownerRoute(handlers.DeactivateMyPlan)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/plans/{id:[0-9]+}/subscribe", pickRoute(auth.DenyImpersonation(handlers.SubscribePlan))).Methods("POST", "OPTIONS")
+	api.HandleFunc("/me/subscriptions", auth.RequireAuth(handlers.GetMySubscriptions)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/me/subscriptions/{id:[0-9]+}", ownerRoute(handlers.CancelMySubscription)).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/payments/webhook/{provider}", handlers.PaymentWebhook).Methods("POST", "OPTIONS")
+
+	// Catálogo de esportes: leitura pública, escrita restrita a admins
+	api.HandleFunc("/sports", handlers.GetSports).Methods("GET", "OPTIONS")
+	api.HandleFunc("/competitions", handlers.GetCompetitions).Methods("GET", "OPTIONS")
+	api.HandleFunc("/teams", handlers.GetTeams).Methods("GET", "OPTIONS")
+	api.HandleFunc("/events", handlers.GetEvents).Methods("GET", "OPTIONS")
+	api.HandleFunc("/events/{id:[0-9]+}", handlers.GetEvent).Methods("GET", "OPTIONS")
+	api.HandleFunc("/admin/sports", auth.RequireAdmin(handlers.SaveSport)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/sports/{id:[0-9]+}", auth.RequireAdmin(handlers.SaveSport)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/admin/sports/{id:[0-9]+}", auth.RequireAdmin(handlers.DeleteCatalogItem("sports", "Esporte"))).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/admin/competitions", auth.RequireAdmin(handlers.SaveCompetition)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/competitions/{id:[0-9]+}", auth.RequireAdmin(handlers.SaveCompetition)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/admin/competitions/{id:[0-9]+}", auth.RequireAdmin(handlers.DeleteCatalogItem("competitions", "Competição"))).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/admin/teams", auth.RequireAdmin(handlers.SaveTeam)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/teams/{id:[0-9]+}", auth.RequireAdmin(handlers.SaveTeam)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/admin/teams/{id:[0-9]+}", auth.RequireAdmin(handlers.DeleteCatalogItem("teams", "Time"))).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/admin/events", auth.RequireAdmin(handlers.SaveEvent)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/events/{id:[0-9]+}", auth.RequireAdmin(handlers.SaveEvent)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/admin/events/{id:[0-9]+}", auth.RequireAdmin(handlers.DeleteCatalogItem("events", "Evento"))).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/admin/events/import", auth.RequireAdmin(handlers.ImportCatalog)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/events/{id:[0-9]+}/result", auth.RequireAdmin(handlers.PostEventResult)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/results", auth.RequireAdmin(handlers.PostResults)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/settle", auth.RequireAdmin(handlers.SettlePalpites)).Methods("POST", "OPTIONS")
+
+	// Fila de jobs em segundo plano
+	api.HandleFunc("/admin/jobs", auth.RequireAdmin(handlers.GetJobs)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/admin/jobs/{id:[0-9]+}", auth.RequireAdmin(handlers.GetJob)).Methods("GET", "OPTIONS")
+	api.HandleFunc("/admin/jobs/{id:[0-9]+}/retry", auth.RequireAdmin(handlers.RetryJob)).Methods("POST", "OPTIONS")
 
 	// Rotas públicas
 	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,173 @@
+// Package scheduler executa as tarefas com horário marcado (publicação agendada, revelação no
+// início do evento, liquidação e exclusões vencidas). Em modo serverless é acionado por cron
+// via /api/cron/run-due-jobs; todas as tarefas são idempotentes. A liquidação é enfileirada
+// como job (ver internal/jobs) em vez de rodar dentro da requisição.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/oidc"
+	"smartpicks-backend/internal/privacy"
+	"smartpicks-backend/internal/stream"
+	"smartpicks-backend/internal/twofactor"
+	"smartpicks-backend/internal/webhooks"
+)
+
+// lockKey identifica o advisory lock que impede execuções simultâneas (ex.: cron e admin)
+const lockKey = 72_000_039
+
+// Job é uma tarefa periódica; Run devolve quantos registros foram afetados
+type Job struct {
+	Name string
+	Run  func(now time.Time) (int, error)
+}
+
+// JobResult é o resultado de uma tarefa em RunDue
+type JobResult struct {
+	Name     string `json:"name"`
+	Affected int    `json:"affected"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report resume uma execução; Skipped indica que outra execução já estava em andamento
+type Report struct {
+	StartedAt time.Time   `json:"started_at"`
+	Skipped   bool        `json:"skipped"`
+	Jobs      []JobResult `json:"jobs"`
+}
+
+// Jobs são executados em ordem: publicar antes de revelar garante que palpites agendados até
+// o início do evento já estejam visíveis quando o conteúdo for liberado
+var Jobs = []Job{
+	{"publish_scheduled", PublishScheduled},
+	{"reveal_at_kickoff", RevealAtKickoff},
+	{"settle_pending", settlePending},
+	{"purge_deleted_accounts", purgeDeletedAccounts},
+	{"purge_finished_jobs", purgeFinishedJobs},
+	{"purge_stream_events", stream.Purge},
+	{"purge_webhook_deliveries", webhooks.Purge},
+	{"purge_oidc_states", oidc.PurgeStates},
+	{"purge_login_challenges", twofactor.PurgeChallenges},
+	{"purge_sessions", auth.PurgeSessions},
+	{"purge_api_key_usage", auth.PurgeAPIKeyUsage},
+}
+
+// finishedJobsRetention é por quanto tempo jobs concluídos ficam na tabela para consulta
+const finishedJobsRetention = 7 * 24 * time.Hour
+
+// RunDue executa todas as tarefas vencidas. Uma falha em uma tarefa não impede as demais.
+func RunDue(ctx context.Context, now time.Time) (*Report, error) {
+	report := &Report{StartedAt: now, Jobs: []JobResult{}}
+
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if !locked {
+		report.Skipped = true
+		return report, nil
+	}
+	// O unlock não usa ctx: precisa rodar mesmo que a requisição tenha sido cancelada
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	for _, job := range Jobs {
+		affected, err := job.Run(now)
+		result := JobResult{Name: job.Name, Affected: affected}
+		if err != nil {
+			log.Printf("Erro na tarefa agendada %s: %v", job.Name, err)
+			result.Error = err.Error()
+		}
+		report.Jobs = append(report.Jobs, result)
+	}
+	return report, nil
+}
+
+// PublishScheduled publica os palpites agendados cujo publish_at já passou
+func PublishScheduled(now time.Time) (int, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE palpites SET published_at = publish_at, updated_at = CURRENT_TIMESTAMP
+		WHERE published_at IS NULL AND NOT is_draft AND publish_at <= $1
+		RETURNING id, user_id, COALESCE(titulo, '')`, now)
+	if err != nil {
+		return 0, err
+	}
+	var published []events.PalpitePublished
+	for rows.Next() {
+		var e events.PalpitePublished
+		if err := rows.Scan(&e.PalpiteID, &e.AuthorID, &e.Titulo); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		published = append(published, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, e := range published {
+		if err := events.PublishTx(tx, e); err != nil {
+			return 0, err
+		}
+	}
+	return len(published), tx.Commit()
+}
+
+// RevealAtKickoff torna públicos os palpites restritos marcados para revelação quando o evento começa
+func RevealAtKickoff(now time.Time) (int, error) {
+	return affected(database.DB.Exec(`
+		UPDATE palpites p SET visibility = $1, updated_at = CURRENT_TIMESTAMP
+		FROM events e
+		WHERE e.id = p.event_id
+		  AND p.reveal_at_kickoff
+		  AND p.visibility <> $1
+		  AND p.published_at <= $2
+		  AND e.kickoff_at <= $2`, models.VISIBILIDADE_PUBLICO, now))
+}
+
+// settlePending enfileira a liquidação dos eventos encerrados; a chave única impede acumular
+// jobs repetidos enquanto o anterior não roda
+func settlePending(now time.Time) (int, error) {
+	_, err := jobs.EnqueueWith(database.DB, jobs.SettleEvents{}, jobs.Options{UniqueKey: "settle_events:all"})
+	if err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+func purgeDeletedAccounts(now time.Time) (int, error) {
+	return privacy.PurgeDueAccounts()
+}
+
+func purgeFinishedJobs(now time.Time) (int, error) {
+	return jobs.PurgeFinished(finishedJobsRetention)
+}
+
+func affected(result sql.Result, err error) (int, error) {
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
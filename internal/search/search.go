@@ -0,0 +1,128 @@
+// Package search implementa a busca textual (Postgres full-text, configuração pt_unaccent)
+// sobre palpites e tipsters.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+)
+
+const MaxTerms = 8
+
+// BuildTSQuery converte o texto digitado em uma tsquery com busca por prefixo em cada termo
+// ("flam palm" -> "flam:* & palm:*"). Retorna vazio se não houver termos pesquisáveis.
+func BuildTSQuery(q string) string {
+	terms := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	parts := []string{}
+	for _, t := range terms {
+		if len(parts) == MaxTerms {
+			break
+		}
+		parts = append(parts, strings.ToLower(t)+":*")
+	}
+	return strings.Join(parts, " & ")
+}
+
+// Search busca palpites (título, nome do evento e dos times) e tipsters (nome) e devolve os
+// resultados misturados por relevância. Os times são conferidos pelo nome atual, já que o nome
+// do evento guarda o dos times na data em que ele foi salvo.
+func Search(q string, types map[string]bool, limit int) ([]models.SearchHit, error) {
+	tsquery := BuildTSQuery(q)
+	hits := []models.SearchHit{}
+	if tsquery == "" {
+		return hits, nil
+	}
+
+	if types[models.SEARCH_PALPITE] {
+		palpites, err := searchPalpites(tsquery, limit)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, palpites...)
+	}
+
+	if types[models.SEARCH_TIPSTER] {
+		tipsters, err := searchTipsters(tsquery, limit)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, tipsters...)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func searchPalpites(tsquery string, limit int) ([]models.SearchHit, error) {
+	rows, err := database.DB.Query(`
+		SELECT `+models.PalpiteColumns+`,
+			   ts_rank(search_vector || COALESCE((SELECT e.search_vector FROM events e WHERE e.id = palpites.event_id), ''::tsvector), query) AS rank
+		FROM palpites, to_tsquery('pt_unaccent', $1) query
+		WHERE (search_vector @@ query
+		       OR event_id IN (
+		           SELECT id FROM events WHERE search_vector @@ query
+		           UNION
+		           SELECT e.id FROM events e
+		           JOIN teams t ON t.id IN (e.home_team_id, e.away_team_id)
+		           WHERE to_tsvector('pt_unaccent', t.nome) @@ query))
+		  AND published_at <= NOW()
+		  AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)
+		ORDER BY rank DESC, created_at DESC
+		LIMIT $2`, tsquery, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []models.SearchHit{}
+	for rows.Next() {
+		hit := models.SearchHit{Type: models.SEARCH_PALPITE}
+		p, err := models.ScanPalpite(rows, &hit.Rank)
+		if err != nil {
+			return nil, err
+		}
+		resp := p.ToResponse()
+		hit.Palpite = &resp
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+func searchTipsters(tsquery string, limit int) ([]models.SearchHit, error) {
+	rows, err := database.DB.Query(`
+		SELECT u.id, u.nome, u.avatar, u.bio,
+			   (SELECT COUNT(*) FROM palpites p WHERE p.user_id = u.id AND p.published_at <= NOW()) AS total_palpites,
+			   ts_rank(u.search_vector, query) AS rank
+		FROM users u, to_tsquery('pt_unaccent', $1) query
+		WHERE u.search_vector @@ query
+		  AND u.deleted_at IS NULL
+		  AND u.suspension_type IS NULL
+		ORDER BY rank DESC, total_palpites DESC
+		LIMIT $2`, tsquery, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []models.SearchHit{}
+	for rows.Next() {
+		var t models.TipsterSummary
+		hit := models.SearchHit{Type: models.SEARCH_TIPSTER}
+		if err := rows.Scan(&t.ID, &t.Nome, &t.Avatar, &t.Bio, &t.TotalPalpites, &hit.Rank); err != nil {
+			return nil, err
+		}
+		hit.Tipster = &t
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
@@ -63,3 +63,23 @@ func (s *S3Service) UploadFile(file multipart.File, fileName string, contentType
 	url := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.BucketName, s.Region, fileName)
 	return url, nil
 }
+
+// Open lê um objeto do bucket; quem chama deve fechar o corpo
+func (s *S3Service) Open(key string) (io.ReadCloser, error) {
+	out, err := s.Client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *S3Service) DeleteFile(fileName string) error {
+	_, err := s.Client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(fileName),
+	})
+	return err
+}
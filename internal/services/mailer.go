@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// Mailer envia emails transacionais (verificação de email, avisos de conta)
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer usa SMTP quando SMTP_HOST está definido; caso contrário apenas registra o email no log
+func NewMailer() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return logMailer{}
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "no-reply@smartpicks.com"
+	}
+
+	return &smtpMailer{
+		addr: host + ":" + port,
+		from: from,
+		auth: smtp.PlainAuth("", os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASSWORD"), host),
+	}
+}
+
+type smtpMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func (m *smtpMailer) Send(to, subject, body string) error {
+	msg := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg))
+}
+
+type logMailer struct{}
+
+func (logMailer) Send(to, subject, body string) error {
+	log.Printf("📧 [email não enviado - SMTP_HOST não configurado] para=%s assunto=%q\n%s", to, subject, body)
+	return nil
+}
+
+// FrontendURL retorna a URL base do frontend usada em links enviados por email
+func FrontendURL() string {
+	if u := os.Getenv("FRONTEND_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "https://smartpicks-88709.web.app"
+}
+
+// EmailVerificationLink monta o link de confirmação de email
+func EmailVerificationLink(token string) string {
+	return fmt.Sprintf("%s/verificar-email?token=%s", FrontendURL(), token)
+}
@@ -0,0 +1,127 @@
+// Package settlement liquida palpites a partir do placar final do evento.
+package settlement
+
+import (
+	"fmt"
+	"math"
+
+	"smartpicks-backend/internal/models"
+)
+
+type outcome int
+
+const (
+	lose outcome = iota - 1
+	push
+	win
+)
+
+// Evaluate calcula o status do palpite (green, red, meio_green, meio_red ou anulado) para o
+// placar informado. Linhas de quarto (2.25, -0.75...) são divididas em duas metades, como no
+// handicap asiático.
+func Evaluate(mercado, selecao string, linha float64, home, away int) (string, error) {
+	switch mercado {
+	case models.MERCADO_1X2:
+		var got string
+		switch {
+		case home > away:
+			got = models.SELECAO_CASA
+		case home < away:
+			got = models.SELECAO_FORA
+		default:
+			got = models.SELECAO_EMPATE
+		}
+		if got == selecao {
+			return models.STATUS_GREEN, nil
+		}
+		return models.STATUS_RED, nil
+
+	case models.MERCADO_AMBAS:
+		both := home > 0 && away > 0
+		if both == (selecao == models.SELECAO_SIM) {
+			return models.STATUS_GREEN, nil
+		}
+		return models.STATUS_RED, nil
+
+	case models.MERCADO_OVER_UNDER:
+		total := float64(home + away)
+		return splitLine(linha, func(l float64) outcome {
+			diff := total - l
+			if selecao == models.SELECAO_UNDER {
+				diff = -diff
+			}
+			return compare(diff)
+		}), nil
+
+	case models.MERCADO_HANDICAP:
+		margin := float64(home - away)
+		if selecao == models.SELECAO_FORA {
+			margin = -margin
+		}
+		return splitLine(linha, func(l float64) outcome {
+			return compare(margin + l)
+		}), nil
+	}
+
+	return "", fmt.Errorf("mercado não suportado: %s", mercado)
+}
+
+// Profit calcula o lucro em unidades para o status; sem odd só é possível calcular perdas e anulações
+func Profit(status string, stake float64, odd *float64) *float64 {
+	var profit float64
+	switch status {
+	case models.STATUS_RED:
+		profit = -stake
+	case models.STATUS_MEIO_RED:
+		profit = -stake / 2
+	case models.STATUS_ANULADO:
+		profit = 0
+	case models.STATUS_GREEN, models.STATUS_MEIO_GREEN:
+		if odd == nil {
+			return nil
+		}
+		profit = stake * (*odd - 1)
+		if status == models.STATUS_MEIO_GREEN {
+			profit /= 2
+		}
+	default:
+		return nil
+	}
+	profit = math.Round(profit*100) / 100
+	return &profit
+}
+
+// splitLine avalia linhas inteiras/meias diretamente e linhas de quarto como duas apostas de meia stake
+func splitLine(linha float64, eval func(float64) outcome) string {
+	quarters := math.Round(linha * 4)
+	if int(quarters)%2 == 0 {
+		return statusFor(eval(linha), eval(linha))
+	}
+	return statusFor(eval(linha-0.25), eval(linha+0.25))
+}
+
+func statusFor(a, b outcome) string {
+	switch a + b {
+	case 2 * win:
+		return models.STATUS_GREEN
+	case win:
+		return models.STATUS_MEIO_GREEN
+	case push:
+		return models.STATUS_ANULADO
+	case lose:
+		return models.STATUS_MEIO_RED
+	default:
+		return models.STATUS_RED
+	}
+}
+
+func compare(diff float64) outcome {
+	switch {
+	case diff > 1e-9:
+		return win
+	case diff < -1e-9:
+		return lose
+	default:
+		return push
+	}
+}
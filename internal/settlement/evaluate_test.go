@@ -0,0 +1,103 @@
+package settlement
+
+import (
+	"testing"
+
+	"smartpicks-backend/internal/models"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name       string
+		mercado    string
+		selecao    string
+		linha      float64
+		home, away int
+		want       string
+	}{
+		{"1x2 casa vence", models.MERCADO_1X2, models.SELECAO_CASA, 0, 2, 1, models.STATUS_GREEN},
+		{"1x2 empate", models.MERCADO_1X2, models.SELECAO_EMPATE, 0, 1, 1, models.STATUS_GREEN},
+		{"1x2 fora perde", models.MERCADO_1X2, models.SELECAO_FORA, 0, 2, 1, models.STATUS_RED},
+		{"1x2 casa no empate", models.MERCADO_1X2, models.SELECAO_CASA, 0, 0, 0, models.STATUS_RED},
+
+		{"ambas sim", models.MERCADO_AMBAS, models.SELECAO_SIM, 0, 1, 1, models.STATUS_GREEN},
+		{"ambas sim sem gol de um lado", models.MERCADO_AMBAS, models.SELECAO_SIM, 0, 1, 0, models.STATUS_RED},
+		{"ambas não no 0x0", models.MERCADO_AMBAS, models.SELECAO_NAO, 0, 0, 0, models.STATUS_GREEN},
+		{"ambas não com gols dos dois", models.MERCADO_AMBAS, models.SELECAO_NAO, 0, 2, 2, models.STATUS_RED},
+
+		{"over 2.5 com 3 gols", models.MERCADO_OVER_UNDER, models.SELECAO_OVER, 2.5, 2, 1, models.STATUS_GREEN},
+		{"over 2.5 com 2 gols", models.MERCADO_OVER_UNDER, models.SELECAO_OVER, 2.5, 1, 1, models.STATUS_RED},
+		{"under 2.5 com 2 gols", models.MERCADO_OVER_UNDER, models.SELECAO_UNDER, 2.5, 1, 1, models.STATUS_GREEN},
+		{"over 2 com 2 gols devolve", models.MERCADO_OVER_UNDER, models.SELECAO_OVER, 2, 1, 1, models.STATUS_ANULADO},
+		{"over 2.25 com 2 gols", models.MERCADO_OVER_UNDER, models.SELECAO_OVER, 2.25, 1, 1, models.STATUS_MEIO_RED},
+		{"under 2.25 com 2 gols", models.MERCADO_OVER_UNDER, models.SELECAO_UNDER, 2.25, 1, 1, models.STATUS_MEIO_GREEN},
+		{"over 2.75 com 3 gols", models.MERCADO_OVER_UNDER, models.SELECAO_OVER, 2.75, 2, 1, models.STATUS_MEIO_GREEN},
+		{"under 2.75 com 3 gols", models.MERCADO_OVER_UNDER, models.SELECAO_UNDER, 2.75, 2, 1, models.STATUS_MEIO_RED},
+
+		{"handicap casa 0 no empate", models.MERCADO_HANDICAP, models.SELECAO_CASA, 0, 0, 0, models.STATUS_ANULADO},
+		{"handicap casa -1 vencendo por 1", models.MERCADO_HANDICAP, models.SELECAO_CASA, -1, 2, 1, models.STATUS_ANULADO},
+		{"handicap casa -1.5 vencendo por 1", models.MERCADO_HANDICAP, models.SELECAO_CASA, -1.5, 2, 1, models.STATUS_RED},
+		{"handicap casa -0.25 vencendo", models.MERCADO_HANDICAP, models.SELECAO_CASA, -0.25, 1, 0, models.STATUS_GREEN},
+		{"handicap casa -0.25 no empate", models.MERCADO_HANDICAP, models.SELECAO_CASA, -0.25, 1, 1, models.STATUS_MEIO_RED},
+		{"handicap casa +0.25 no empate", models.MERCADO_HANDICAP, models.SELECAO_CASA, 0.25, 1, 1, models.STATUS_MEIO_GREEN},
+		{"handicap casa -0.75 vencendo por 1", models.MERCADO_HANDICAP, models.SELECAO_CASA, -0.75, 2, 1, models.STATUS_MEIO_GREEN},
+		{"handicap casa -0.75 no empate", models.MERCADO_HANDICAP, models.SELECAO_CASA, -0.75, 1, 1, models.STATUS_RED},
+		{"handicap casa +1.75 perdendo por 2", models.MERCADO_HANDICAP, models.SELECAO_CASA, 1.75, 0, 2, models.STATUS_MEIO_RED},
+		{"handicap fora -0.75 vencendo por 1", models.MERCADO_HANDICAP, models.SELECAO_FORA, -0.75, 0, 1, models.STATUS_MEIO_GREEN},
+		{"handicap fora +0.25 no empate", models.MERCADO_HANDICAP, models.SELECAO_FORA, 0.25, 0, 0, models.STATUS_MEIO_GREEN},
+		{"handicap fora +1.5 perdendo por 2", models.MERCADO_HANDICAP, models.SELECAO_FORA, 1.5, 2, 0, models.STATUS_RED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(tt.mercado, tt.selecao, tt.linha, tt.home, tt.away)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateUnknownMarket(t *testing.T) {
+	if got, err := Evaluate("escanteios", models.SELECAO_OVER, 9.5, 1, 0); err == nil {
+		t.Errorf("Evaluate com mercado desconhecido = %q, want erro", got)
+	}
+}
+
+func TestProfit(t *testing.T) {
+	odd := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name   string
+		status string
+		stake  float64
+		odd    *float64
+		want   *float64
+	}{
+		{"green", models.STATUS_GREEN, 1, odd(1.91), odd(0.91)},
+		{"green arredonda em centavos", models.STATUS_GREEN, 1, odd(1.333), odd(0.33)},
+		{"meio green", models.STATUS_MEIO_GREEN, 2, odd(1.9), odd(0.9)},
+		{"red", models.STATUS_RED, 2, odd(1.9), odd(-2)},
+		{"meio red", models.STATUS_MEIO_RED, 2, odd(1.9), odd(-1)},
+		{"anulado", models.STATUS_ANULADO, 2, odd(1.9), odd(0)},
+		{"red sem odd", models.STATUS_RED, 3, nil, odd(-3)},
+		{"meio red sem odd", models.STATUS_MEIO_RED, 3, nil, odd(-1.5)},
+		{"anulado sem odd", models.STATUS_ANULADO, 3, nil, odd(0)},
+		{"green sem odd", models.STATUS_GREEN, 1, nil, nil},
+		{"meio green sem odd", models.STATUS_MEIO_GREEN, 1, nil, nil},
+		{"pendente", models.STATUS_PENDENTE, 1, odd(1.9), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Profit(tt.status, tt.stake, tt.odd)
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil:
+				t.Errorf("Profit = %v, want %v", got, tt.want)
+			case *got != *tt.want:
+				t.Errorf("Profit = %v, want %v", *got, *tt.want)
+			}
+		})
+	}
+}
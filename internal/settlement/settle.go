@@ -0,0 +1,111 @@
+package settlement
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"smartpicks-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Settle liquida, dentro da transação, os palpites pendentes de eventos encerrados (com placar)
+// ou cancelados. eventIDs restringe os eventos avaliados; vazio avalia todos. Palpites sem
+// mercado só são liquidados (anulados) quando o evento é cancelado.
+func Settle(tx *sql.Tx, eventIDs []int) ([]models.SettlementChange, int, error) {
+	query := `
+		SELECT p.id, p.user_id, p.event_id, p.mercado, p.selecao, p.linha, p.odd, p.stake,
+			   e.status, e.home_score, e.away_score
+		FROM palpites p
+		JOIN events e ON e.id = p.event_id
+		WHERE p.status = $1
+		  AND (e.status = $2 AND e.home_score IS NOT NULL OR e.status = $3)`
+	args := []interface{}{models.STATUS_PENDENTE, models.EVENTO_ENCERRADO, models.EVENTO_CANCELADO}
+	if len(eventIDs) > 0 {
+		query += " AND p.event_id = ANY($4)"
+		args = append(args, pq.Array(eventIDs))
+	}
+	query += " ORDER BY p.id FOR UPDATE OF p"
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	type pending struct {
+		change  models.SettlementChange
+		linha   *float64
+		odd     *float64
+		stake   float64
+		mercado *string
+		selecao *string
+		status  string
+		home    *int
+		away    *int
+	}
+	var picks []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.change.PalpiteID, &p.change.UserID, &p.change.EventID, &p.mercado, &p.selecao,
+			&p.linha, &p.odd, &p.stake, &p.status, &p.home, &p.away); err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		picks = append(picks, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	changes := []models.SettlementChange{}
+	skipped := 0
+	now := time.Now()
+	for _, p := range picks {
+		c := p.change
+		if p.mercado != nil {
+			c.Mercado = *p.mercado
+		}
+		if p.selecao != nil {
+			c.Selecao = *p.selecao
+		}
+		if p.linha != nil {
+			c.Linha = *p.linha
+		}
+
+		switch {
+		case p.status == models.EVENTO_CANCELADO:
+			c.Status = models.STATUS_ANULADO
+		case p.mercado == nil || p.selecao == nil:
+			skipped++
+			continue
+		default:
+			if c.Status, err = Evaluate(c.Mercado, c.Selecao, c.Linha, *p.home, *p.away); err != nil {
+				return nil, 0, fmt.Errorf("palpite %d: %w", c.PalpiteID, err)
+			}
+		}
+
+		lucro := Profit(c.Status, p.stake, p.odd)
+		if lucro != nil {
+			c.Lucro = *lucro
+		}
+		if _, err := tx.Exec(`
+			UPDATE palpites SET status = $1, lucro = $2, settled_at = $3 WHERE id = $4`,
+			c.Status, lucro, now, c.PalpiteID); err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, skipped, nil
+}
+
+// Reopen devolve ao status pendente os palpites já liquidados de um evento, para que sejam
+// reavaliados após a correção do placar
+func Reopen(tx *sql.Tx, eventID int) error {
+	_, err := tx.Exec(`
+		UPDATE palpites SET status = $1, lucro = NULL, settled_at = NULL
+		WHERE event_id = $2 AND status <> $1`, models.STATUS_PENDENTE, eventID)
+	return err
+}
@@ -0,0 +1,280 @@
+// Package social republica os palpites dos tipsters em canais do Telegram e servidores do
+// Discord. Cada tipster cadastra seus canais; quando um palpite é publicado, uma mensagem
+// formatada por template (com a imagem do palpite) é enviada a cada canal por um job da fila.
+package social
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"smartpicks-backend/internal/database"
+)
+
+// Plataformas suportadas
+const (
+	PLATAFORMA_TELEGRAM = "telegram"
+	PLATAFORMA_DISCORD  = "discord"
+)
+
+var ValidPlatforms = []string{PLATAFORMA_TELEGRAM, PLATAFORMA_DISCORD}
+
+const (
+	// MaxCanaisPorUsuario limita quantos canais cada tipster pode cadastrar
+	MaxCanaisPorUsuario = 10
+	maxNomeLen          = 100
+)
+
+var (
+	ErrNotFound      = errors.New("canal não encontrado")
+	ErrLimit         = errors.New("limite de canais atingido")
+	ErrNotConfigured = errors.New("Telegram não está configurado no servidor")
+	ErrNotVerified   = errors.New("canal ainda não verificado: envie o código de verificação no chat")
+)
+
+var (
+	// telegramChatPattern aceita o id numérico do chat (grupos e canais começam com -100) ou o
+	// @username de um canal público
+	telegramChatPattern = regexp.MustCompile(`^(-?\d{1,20}|@[A-Za-z][A-Za-z0-9_]{3,31})$`)
+	// discordWebhookPattern extrai id e token de https://discord.com/api/webhooks/{id}/{token}
+	discordWebhookPattern = regexp.MustCompile(`^/api(?:/v\d+)?/webhooks/(\d{1,25})/([A-Za-z0-9_-]{1,100})/?$`)
+	discordHosts          = map[string]bool{
+		"discord.com": true, "discordapp.com": true, "ptb.discord.com": true, "canary.discord.com": true,
+	}
+)
+
+// Channel é um destino de publicação de um tipster. Para o Telegram, Destino é o chat_id; para
+// o Discord, o id do webhook (o token fica guardado e nunca é devolvido pela API). Canais do
+// Telegram só recebem mensagens depois de verificados (ver verification.go); no Discord, ter a
+// URL do webhook já comprova o controle do canal.
+type Channel struct {
+	ID               int        `json:"id"`
+	UserID           int        `json:"user_id"`
+	Plataforma       string     `json:"plataforma"`
+	Nome             string     `json:"nome"`
+	Destino          string     `json:"destino"`
+	Template         *string    `json:"template,omitempty"`
+	ComImagem        bool       `json:"com_imagem"`
+	IncluirRestritos bool       `json:"incluir_restritos"`
+	Ativo            bool       `json:"ativo"`
+	LastPostedAt     *time.Time `json:"last_posted_at,omitempty"`
+	VerificadoEm     *time.Time `json:"verificado_em"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	token string
+}
+
+// Input são os campos aceitos na criação e na edição; nil mantém o valor atual. O destino é
+// informado como chat_id (Telegram) ou webhook_url (Discord); template vazio volta ao padrão.
+type Input struct {
+	Plataforma       string  `json:"plataforma"`
+	Nome             *string `json:"nome"`
+	ChatID           *string `json:"chat_id"`
+	WebhookURL       *string `json:"webhook_url"`
+	Template         *string `json:"template"`
+	ComImagem        *bool   `json:"com_imagem"`
+	IncluirRestritos *bool   `json:"incluir_restritos"`
+	Ativo            *bool   `json:"ativo"`
+
+	destino, token *string
+}
+
+const channelColumns = `id, user_id, platform, name, target, token, template, with_image, include_restricted,
+	active, last_posted_at, verified_at, created_at, updated_at`
+
+func scanChannel(row interface{ Scan(...interface{}) error }) (*Channel, error) {
+	var c Channel
+	var token sql.NullString
+	err := row.Scan(&c.ID, &c.UserID, &c.Plataforma, &c.Nome, &c.Destino, &token, &c.Template, &c.ComImagem,
+		&c.IncluirRestritos, &c.Ativo, &c.LastPostedAt, &c.VerificadoEm, &c.CreatedAt, &c.UpdatedAt)
+	c.token = token.String
+	return &c, err
+}
+
+// Verified indica se o canal pode receber mensagens
+func (c *Channel) Verified() bool {
+	return c.VerificadoEm != nil
+}
+
+// TelegramBotToken é o token do bot da plataforma (TELEGRAM_BOT_TOKEN), que o tipster adiciona
+// como administrador do canal
+func TelegramBotToken() string {
+	return os.Getenv("TELEGRAM_BOT_TOKEN")
+}
+
+// Validate confere e normaliza os campos. Na edição, plataforma é a do canal existente.
+func (in *Input) Validate(creating bool, plataforma string) error {
+	if creating {
+		if in.Nome == nil {
+			return errors.New("nome é obrigatório")
+		}
+		switch plataforma {
+		case PLATAFORMA_TELEGRAM:
+			if in.ChatID == nil {
+				return errors.New("chat_id é obrigatório para o Telegram")
+			}
+		case PLATAFORMA_DISCORD:
+			if in.WebhookURL == nil {
+				return errors.New("webhook_url é obrigatório para o Discord")
+			}
+		default:
+			return errors.New("plataforma inválida. Use " + strings.Join(ValidPlatforms, ", "))
+		}
+	}
+
+	if in.Nome != nil {
+		*in.Nome = strings.TrimSpace(*in.Nome)
+		if *in.Nome == "" || utf8.RuneCountInString(*in.Nome) > maxNomeLen {
+			return errors.New("nome deve ter entre 1 e 100 caracteres")
+		}
+	}
+	if in.ChatID != nil {
+		if plataforma != PLATAFORMA_TELEGRAM {
+			return errors.New("chat_id só se aplica ao Telegram")
+		}
+		chatID := strings.TrimSpace(*in.ChatID)
+		if !telegramChatPattern.MatchString(chatID) {
+			return errors.New("chat_id inválido: use o id numérico do chat ou o @username do canal")
+		}
+		in.destino = &chatID
+	}
+	if in.WebhookURL != nil {
+		if plataforma != PLATAFORMA_DISCORD {
+			return errors.New("webhook_url só se aplica ao Discord")
+		}
+		id, token, err := parseDiscordWebhook(strings.TrimSpace(*in.WebhookURL))
+		if err != nil {
+			return err
+		}
+		in.destino, in.token = &id, &token
+	}
+	if in.Template != nil {
+		*in.Template = strings.TrimSpace(*in.Template)
+		if *in.Template != "" {
+			if err := ValidateTemplate(plataforma, *in.Template); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseDiscordWebhook extrai id e token da URL copiada em Integrações > Webhooks do Discord.
+// A URL não é guardada: o envio sempre monta o endereço a partir de DISCORD_API_URL.
+func parseDiscordWebhook(raw string) (string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", errors.New("webhook_url inválida")
+	}
+	host := strings.ToLower(u.Hostname())
+	if base, err := url.Parse(discordAPIURL()); err == nil && host == strings.ToLower(base.Hostname()) {
+		// Servidor fake ou de testes configurado em DISCORD_API_URL
+		u.Path = "/api" + strings.TrimPrefix(u.Path, strings.TrimSuffix(base.Path, "/"))
+	} else if u.Scheme != "https" || !discordHosts[host] {
+		return "", "", errors.New("webhook_url deve ser um webhook do Discord (https://discord.com/api/webhooks/...)")
+	}
+	m := discordWebhookPattern.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", "", errors.New("webhook_url deve ser um webhook do Discord (https://discord.com/api/webhooks/...)")
+	}
+	return m[1], m[2], nil
+}
+
+// Create cadastra o canal do tipster. Canais do Telegram começam sem verificação; o handler
+// gera o código com StartVerification.
+func Create(userID int, in Input) (*Channel, error) {
+	if err := in.Validate(true, in.Plataforma); err != nil {
+		return nil, err
+	}
+	if in.Plataforma == PLATAFORMA_TELEGRAM && TelegramBotToken() == "" {
+		return nil, ErrNotConfigured
+	}
+
+	var count int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM social_channels WHERE user_id = $1", userID).Scan(&count); err != nil {
+		return nil, err
+	}
+	if count >= MaxCanaisPorUsuario {
+		return nil, ErrLimit
+	}
+
+	return scanChannel(database.DB.QueryRow(`
+		INSERT INTO social_channels (user_id, platform, name, target, token, template, with_image,
+			include_restricted, active, verified_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), COALESCE($7, TRUE), COALESCE($8, FALSE), COALESCE($9, TRUE),
+			CASE WHEN $2 = 'discord' THEN NOW() END)
+		RETURNING `+channelColumns,
+		userID, in.Plataforma, *in.Nome, *in.destino, in.token, in.Template, in.ComImagem, in.IncluirRestritos, in.Ativo))
+}
+
+// Update altera os campos informados do canal. Trocar o chat do Telegram exige nova verificação.
+func Update(c *Channel, in Input) (*Channel, error) {
+	if err := in.Validate(false, c.Plataforma); err != nil {
+		return nil, err
+	}
+
+	updated, err := scanChannel(database.DB.QueryRow(`
+		UPDATE social_channels SET
+			name = COALESCE($2, name),
+			target = COALESCE($3, target),
+			token = COALESCE($4, token),
+			template = CASE WHEN $5::text IS NULL THEN template ELSE NULLIF($5, '') END,
+			with_image = COALESCE($6, with_image),
+			include_restricted = COALESCE($7, include_restricted),
+			active = COALESCE($8, active),
+			verified_at = CASE WHEN platform = 'telegram' AND $3::text IS NOT NULL AND $3 <> target
+				THEN NULL ELSE verified_at END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+channelColumns,
+		c.ID, in.Nome, in.destino, in.token, in.Template, in.ComImagem, in.IncluirRestritos, in.Ativo))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return updated, err
+}
+
+func ListByUser(userID int) ([]Channel, error) {
+	rows, err := database.DB.Query(
+		"SELECT "+channelColumns+" FROM social_channels WHERE user_id = $1 ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Channel{}
+	for rows.Next() {
+		c, err := scanChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *c)
+	}
+	return list, rows.Err()
+}
+
+func Get(id int) (*Channel, error) {
+	c, err := scanChannel(database.DB.QueryRow("SELECT "+channelColumns+" FROM social_channels WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return c, err
+}
+
+// Delete remove o canal e seu histórico; publicações pendentes terminam sem enviar
+func Delete(id int) error {
+	result, err := database.DB.Exec("DELETE FROM social_channels WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
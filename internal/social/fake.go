@@ -0,0 +1,166 @@
+package social
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeMessage é uma mensagem recebida pelo servidor fake
+type FakeMessage struct {
+	Plataforma string    `json:"plataforma"`
+	Destino    string    `json:"destino"` // chat_id ou id do webhook
+	MessageID  string    `json:"message_id"`
+	Texto      string    `json:"texto"`
+	Imagem     string    `json:"imagem,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Fake imita a API de bots do Telegram e os webhooks do Discord, guardando as mensagens em
+// memória. Destinos especiais simulam falhas: chat @fail_429 ou token fail_429 respondem 429,
+// @fail_500/fail_500 respondem 500 e @fail_403/fail_404 são recusas definitivas.
+//
+// Rotas: POST /telegram/bot{token}/sendMessage|sendPhoto|getChatMember,
+// POST /discord/api/webhooks/{id}/{token}, GET /messages e DELETE /messages.
+type Fake struct {
+	mu       sync.Mutex
+	messages []FakeMessage
+	nextID   int
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Messages() []FakeMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeMessage{}, f.messages...)
+}
+
+func (f *Fake) record(m FakeMessage) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	m.MessageID = strconv.Itoa(f.nextID)
+	m.ReceivedAt = time.Now()
+	f.messages = append(f.messages, m)
+	return m.MessageID
+}
+
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/messages" && r.Method == http.MethodGet:
+		fakeJSON(w, http.StatusOK, map[string]interface{}{"messages": f.Messages()})
+	case r.URL.Path == "/messages" && r.Method == http.MethodDelete:
+		f.mu.Lock()
+		f.messages = nil
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(r.URL.Path, "/telegram/bot") && r.Method == http.MethodPost:
+		f.telegram(w, r)
+	case strings.HasPrefix(r.URL.Path, "/discord/api/webhooks/") && r.Method == http.MethodPost:
+		f.discord(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *Fake) telegram(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	if method == "getChatMember" {
+		// o fake trata todo remetente como administrador, para a verificação de canais passar
+		fakeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "result": map[string]string{"status": "administrator"}})
+		return
+	}
+	var body struct {
+		ChatID  string `json:"chat_id"`
+		Text    string `json:"text"`
+		Caption string `json:"caption"`
+		Photo   string `json:"photo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ChatID == "" {
+		fakeJSON(w, http.StatusBadRequest, map[string]interface{}{"ok": false, "error_code": 400, "description": "Bad Request: chat_id is empty"})
+		return
+	}
+
+	switch body.ChatID {
+	case "@fail_429":
+		fakeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+			"ok": false, "error_code": 429, "description": "Too Many Requests: retry after 2",
+			"parameters": map[string]int{"retry_after": 2},
+		})
+		return
+	case "@fail_500":
+		fakeJSON(w, http.StatusInternalServerError, map[string]interface{}{"ok": false, "error_code": 500, "description": "Internal Server Error"})
+		return
+	case "@fail_403":
+		fakeJSON(w, http.StatusForbidden, map[string]interface{}{"ok": false, "error_code": 403, "description": "Forbidden: bot was kicked from the channel chat"})
+		return
+	}
+
+	m := FakeMessage{Plataforma: PLATAFORMA_TELEGRAM, Destino: body.ChatID, Texto: body.Text}
+	switch method {
+	case "sendPhoto":
+		m.Texto, m.Imagem = body.Caption, body.Photo
+	case "sendMessage":
+	default:
+		fakeJSON(w, http.StatusNotFound, map[string]interface{}{"ok": false, "error_code": 404, "description": "Not Found"})
+		return
+	}
+	id := f.record(m)
+	fakeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "result": map[string]interface{}{"message_id": json.Number(id)}})
+}
+
+func (f *Fake) discord(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/discord/api/webhooks/"), "/")
+	if len(parts) != 2 {
+		fakeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "Unknown Webhook", "code": 10015})
+		return
+	}
+	switch parts[1] {
+	case "fail_429":
+		w.Header().Set("Retry-After", "2")
+		fakeJSON(w, http.StatusTooManyRequests, map[string]interface{}{"message": "You are being rate limited.", "retry_after": 1.5, "global": false})
+		return
+	case "fail_500":
+		fakeJSON(w, http.StatusInternalServerError, map[string]interface{}{"message": "Internal Server Error"})
+		return
+	case "fail_404":
+		fakeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "Unknown Webhook", "code": 10015})
+		return
+	}
+
+	var body struct {
+		Content string `json:"content"`
+		Embeds  []struct {
+			Image struct {
+				URL string `json:"url"`
+			} `json:"image"`
+		} `json:"embeds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || (body.Content == "" && len(body.Embeds) == 0) {
+		fakeJSON(w, http.StatusBadRequest, map[string]interface{}{"message": "Cannot send an empty message", "code": 50006})
+		return
+	}
+
+	m := FakeMessage{Plataforma: PLATAFORMA_DISCORD, Destino: parts[0], Texto: body.Content}
+	if len(body.Embeds) > 0 {
+		m.Imagem = body.Embeds[0].Image.URL
+	}
+	id := f.record(m)
+	if r.URL.Query().Get("wait") != "true" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	fakeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "content": body.Content})
+}
+
+func fakeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,277 @@
+package social
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"smartpicks-backend/internal/catalog"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/models"
+)
+
+// Status de uma publicação
+const (
+	POST_PENDENTE  = "pendente"
+	POST_PUBLICADO = "publicado"
+	POST_FALHOU    = "falhou"
+)
+
+// MaxTentativas é o número de tentativas de cada publicação (erros de rede, 5xx e 429)
+const MaxTentativas = 6
+
+// maxPostsListados limita o histórico devolvido por canal
+const maxPostsListados = 50
+
+// Post é uma publicação de um palpite em um canal
+type Post struct {
+	ID         int        `json:"id"`
+	ChannelID  int        `json:"channel_id"`
+	PalpiteID  int        `json:"palpite_id"`
+	Status     string     `json:"status"`
+	MessageID  *string    `json:"message_id,omitempty"`
+	Erro       *string    `json:"erro,omitempty"`
+	Tentativas int        `json:"tentativas"`
+	CreatedAt  time.Time  `json:"created_at"`
+	PostedAt   *time.Time `json:"posted_at,omitempty"`
+}
+
+// Publish é o job que envia um palpite a um canal
+type Publish struct {
+	PostID int `json:"post_id"`
+}
+
+func (Publish) JobType() string { return "social_post" }
+
+const postColumns = `id, channel_id, palpite_id, status, message_id, error, attempts, created_at, posted_at`
+
+func scanPost(row interface{ Scan(...interface{}) error }) (*Post, error) {
+	var p Post
+	err := row.Scan(&p.ID, &p.ChannelID, &p.PalpiteID, &p.Status, &p.MessageID, &p.Erro, &p.Tentativas,
+		&p.CreatedAt, &p.PostedAt)
+	return &p, err
+}
+
+// Register inscreve a publicação social no evento de palpite publicado e registra o job
+func Register() {
+	jobs.Register(publish)
+	events.Subscribe("social", onPalpitePublished)
+}
+
+// onPalpitePublished enfileira uma publicação por canal ativo do autor. Palpites restritos só
+// vão para canais com incluir_restritos, e ainda assim como prévia bloqueada. A publicação é
+// única por canal e palpite, então a reexecução do evento não repete a mensagem.
+func onPalpitePublished(ctx context.Context, e events.PalpitePublished) error {
+	p, err := models.ScanPalpite(database.DB.QueryRowContext(ctx,
+		"SELECT "+models.PalpiteColumns+" FROM palpites WHERE id = $1", e.PalpiteID))
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !p.IsPublished(time.Now()) {
+		return nil
+	}
+	restrito := p.Visibilidade != "" && p.Visibilidade != models.VISIBILIDADE_PUBLICO
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		INSERT INTO social_posts (channel_id, palpite_id)
+		SELECT id, $2 FROM social_channels
+		WHERE user_id = $1 AND active AND verified_at IS NOT NULL AND (include_restricted OR NOT $3)
+		ON CONFLICT (channel_id, palpite_id) DO NOTHING
+		RETURNING id`, p.UserID, p.ID, restrito)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		opts := jobs.Options{MaxTentativas: MaxTentativas, UniqueKey: fmt.Sprintf("social_post:%d", id)}
+		if _, err := jobs.EnqueueTx(tx, Publish{PostID: id}, opts); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// publish é o handler do job: monta a mensagem com o estado atual do palpite e a envia
+func publish(ctx context.Context, job Publish) error {
+	post, err := scanPost(database.DB.QueryRowContext(ctx,
+		"SELECT "+postColumns+" FROM social_posts WHERE id = $1", job.PostID))
+	if err == sql.ErrNoRows {
+		// Canal ou palpite removido junto com o histórico
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if post.Status != POST_PENDENTE {
+		return nil
+	}
+
+	c, err := Get(post.ChannelID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !c.Ativo {
+		return finish(ctx, post.ID, POST_FALHOU, "canal desativado")
+	}
+	if !c.Verified() {
+		return finish(ctx, post.ID, POST_FALHOU, "canal aguardando verificação")
+	}
+
+	text, image, err := message(ctx, c, post.PalpiteID)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return finish(ctx, post.ID, POST_FALHOU, "palpite indisponível ou restrito para este canal")
+	}
+
+	messageID, sendErr := send(ctx, c, text, image)
+	if sendErr == nil {
+		return markPosted(ctx, post.ID, c.ID, messageID)
+	}
+
+	status := POST_PENDENTE
+	if errors.Is(sendErr, ErrRejected) || post.Tentativas+1 >= MaxTentativas {
+		status = POST_FALHOU
+	}
+	if _, err := database.DB.ExecContext(ctx,
+		"UPDATE social_posts SET status = $2, error = $3, attempts = attempts + 1 WHERE id = $1",
+		post.ID, status, sendErr.Error()); err != nil {
+		return err
+	}
+
+	var limited rateLimitError
+	switch {
+	case status == POST_FALHOU:
+		return jobs.Permanent(sendErr)
+	case errors.As(sendErr, &limited):
+		return jobs.RetryAfter(sendErr, limited.after)
+	default:
+		return sendErr
+	}
+}
+
+// message renderiza o palpite para o canal. Texto vazio indica que o palpite não deve mais ser
+// publicado (removido, despublicado ou restrito sem incluir_restritos).
+func message(ctx context.Context, c *Channel, palpiteID int) (string, string, error) {
+	p, err := models.ScanPalpite(database.DB.QueryRowContext(ctx,
+		"SELECT "+models.PalpiteColumns+" FROM palpites WHERE id = $1", palpiteID))
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	restrito := p.Visibilidade != "" && p.Visibilidade != models.VISIBILIDADE_PUBLICO
+	if !p.IsPublished(time.Now()) || (restrito && !c.IncluirRestritos) {
+		return "", "", nil
+	}
+
+	var tipster string
+	if err := database.DB.QueryRowContext(ctx, "SELECT nome FROM users WHERE id = $1", p.UserID).Scan(&tipster); err != nil {
+		return "", "", err
+	}
+	var event *models.Event
+	if p.EventID != nil {
+		event, err = catalog.GetEvent(*p.EventID)
+		if err != nil && !errors.Is(err, catalog.ErrNotFound) {
+			return "", "", err
+		}
+	}
+
+	data := NewData(p, tipster, event)
+	var tmpl string
+	if c.Template != nil {
+		tmpl = *c.Template
+	}
+	text, err := render(c.Plataforma, tmpl, data)
+	if err != nil {
+		return "", "", jobs.Permanent(fmt.Errorf("template inválido: %w", err))
+	}
+	return text, data.Imagem, nil
+}
+
+func markPosted(ctx context.Context, postID, channelID int, messageID string) error {
+	if _, err := database.DB.ExecContext(ctx, `
+		UPDATE social_posts SET status = $2, message_id = NULLIF($3, ''), error = NULL,
+			attempts = attempts + 1, posted_at = NOW()
+		WHERE id = $1`, postID, POST_PUBLICADO, messageID); err != nil {
+		return err
+	}
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE social_channels SET last_posted_at = NOW() WHERE id = $1", channelID)
+	return err
+}
+
+func finish(ctx context.Context, id int, status, errMsg string) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE social_posts SET status = $2, error = $3 WHERE id = $1", id, status, errMsg)
+	return err
+}
+
+// SendTest envia na hora uma mensagem de exemplo com o template do canal, para o tipster
+// conferir a configuração (bot no canal, webhook válido, formatação)
+func SendTest(ctx context.Context, c *Channel) (string, error) {
+	if !c.Verified() {
+		return "", ErrNotVerified
+	}
+	var tmpl string
+	if c.Template != nil {
+		tmpl = *c.Template
+	}
+	text, err := render(c.Plataforma, tmpl, sampleData())
+	if err != nil {
+		return "", fmt.Errorf("%w: template inválido: %v", ErrRejected, err)
+	}
+	return send(ctx, c, text, "")
+}
+
+// ListPosts devolve as publicações mais recentes do canal
+func ListPosts(channelID int) ([]Post, error) {
+	rows, err := database.DB.Query(
+		"SELECT "+postColumns+" FROM social_posts WHERE channel_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2",
+		channelID, maxPostsListados)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Post{}
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *p)
+	}
+	return list, rows.Err()
+}
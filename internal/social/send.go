@@ -0,0 +1,186 @@
+package social
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrRejected são recusas que não se resolvem com nova tentativa (chat inexistente, bot
+	// removido do canal, webhook apagado, mensagem inválida)
+	ErrRejected = errors.New("mensagem recusada pela plataforma")
+)
+
+// rateLimitError é um 429: a plataforma informa quando aceitará de novo
+type rateLimitError struct {
+	after time.Duration
+}
+
+func (e rateLimitError) Error() string {
+	return fmt.Sprintf("limite de envio da plataforma; tentar de novo em %s", e.after)
+}
+
+var client = &http.Client{Timeout: 15 * time.Second}
+
+// telegramAPIURL é a API de bots do Telegram; TELEGRAM_API_URL aponta para o servidor fake nos testes
+func telegramAPIURL() string {
+	if u := os.Getenv("TELEGRAM_API_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "https://api.telegram.org"
+}
+
+// discordAPIURL é a API do Discord; DISCORD_API_URL aponta para o servidor fake nos testes
+func discordAPIURL() string {
+	if u := os.Getenv("DISCORD_API_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "https://discord.com/api"
+}
+
+// send publica a mensagem no canal e devolve o id da mensagem na plataforma
+func send(ctx context.Context, c *Channel, text, image string) (string, error) {
+	if !c.ComImagem {
+		image = ""
+	}
+	switch c.Plataforma {
+	case PLATAFORMA_TELEGRAM:
+		return sendTelegram(ctx, c.Destino, text, image)
+	case PLATAFORMA_DISCORD:
+		return sendDiscord(ctx, c.Destino, c.token, text, image)
+	default:
+		return "", fmt.Errorf("%w: plataforma %q", ErrRejected, c.Plataforma)
+	}
+}
+
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Result      struct {
+		MessageID int64 `json:"message_id"`
+	} `json:"result"`
+	Parameters struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// sendTelegram usa sendPhoto quando há imagem e o texto cabe na legenda; senão sendMessage,
+// com a imagem como prévia do link
+func sendTelegram(ctx context.Context, chatID, text, image string) (string, error) {
+	token := TelegramBotToken()
+	if token == "" {
+		return "", fmt.Errorf("%w: %v", ErrRejected, ErrNotConfigured)
+	}
+
+	method := "sendMessage"
+	body := map[string]interface{}{"chat_id": chatID, "parse_mode": "HTML"}
+	if image != "" && utf8.RuneCountInString(text) <= telegramCaptionLen {
+		method = "sendPhoto"
+		body["photo"] = image
+		body["caption"] = text
+	} else {
+		body["text"] = truncate(text, telegramTextLen)
+		if image != "" {
+			body["link_preview_options"] = map[string]interface{}{
+				"url": image, "prefer_large_media": true, "show_above_text": true,
+			}
+		}
+	}
+
+	status, raw, header, err := postJSON(ctx, telegramAPIURL()+"/bot"+token+"/"+method, body)
+	if err != nil {
+		return "", err
+	}
+	var resp telegramResponse
+	json.Unmarshal(raw, &resp)
+
+	switch {
+	case status == http.StatusOK && resp.OK:
+		return strconv.FormatInt(resp.Result.MessageID, 10), nil
+	case status == http.StatusTooManyRequests:
+		return "", rateLimitError{after: retryAfter(float64(resp.Parameters.RetryAfter), header)}
+	case status >= 500:
+		return "", fmt.Errorf("Telegram respondeu %d: %s", status, resp.Description)
+	default:
+		return "", fmt.Errorf("%w: Telegram respondeu %d: %s", ErrRejected, status, resp.Description)
+	}
+}
+
+// sendDiscord executa o webhook com ?wait=true para receber o id da mensagem. Menções são
+// desativadas para que um título com @everyone não notifique o servidor inteiro.
+func sendDiscord(ctx context.Context, webhookID, token, text, image string) (string, error) {
+	body := map[string]interface{}{
+		"content":          truncate(text, discordContentLen),
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+	}
+	if image != "" {
+		body["embeds"] = []interface{}{map[string]interface{}{"image": map[string]string{"url": image}}}
+	}
+
+	status, raw, header, err := postJSON(ctx, discordAPIURL()+"/webhooks/"+webhookID+"/"+token+"?wait=true", body)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		ID         string  `json:"id"`
+		Message    string  `json:"message"`
+		RetryAfter float64 `json:"retry_after"`
+	}
+	json.Unmarshal(raw, &resp)
+
+	switch {
+	case status >= 200 && status < 300:
+		return resp.ID, nil
+	case status == http.StatusTooManyRequests:
+		return "", rateLimitError{after: retryAfter(resp.RetryAfter, header)}
+	case status >= 500:
+		return "", fmt.Errorf("Discord respondeu %d: %s", status, resp.Message)
+	default:
+		return "", fmt.Errorf("%w: Discord respondeu %d: %s", ErrRejected, status, resp.Message)
+	}
+}
+
+func postJSON(ctx context.Context, endpoint string, body interface{}) (int, []byte, http.Header, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// A URL contém o token do bot ou do webhook: não vai para o log nem para o histórico
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, nil, nil, fmt.Errorf("falha de conexão: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, raw, resp.Header, nil
+}
+
+// retryAfter usa o valor do corpo (segundos) ou, na falta dele, o header Retry-After
+func retryAfter(seconds float64, header http.Header) time.Duration {
+	if seconds <= 0 {
+		seconds, _ = strconv.ParseFloat(header.Get("Retry-After"), 64)
+	}
+	return max(time.Duration(seconds*float64(time.Second)), time.Second)
+}
@@ -0,0 +1,198 @@
+package social
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	_ "time/tzdata" // o horário do evento é exibido em America/Sao_Paulo mesmo sem tzdata no sistema
+	"unicode/utf8"
+
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/odds"
+	"smartpicks-backend/internal/services"
+)
+
+const (
+	maxTemplateLen = 2000
+	// Limites das plataformas: legenda de foto e mensagem no Telegram, content no Discord
+	telegramCaptionLen = 1024
+	telegramTextLen    = 4096
+	discordContentLen  = 2000
+)
+
+// DefaultTelegramTemplate usa o HTML aceito pelo Telegram (parse_mode HTML); os valores são
+// escapados automaticamente
+const DefaultTelegramTemplate = `🎯 <b>{{.Tipster}}</b> publicou um palpite
+{{if .Titulo}}
+<b>{{.Titulo}}</b>{{end}}{{if .Evento}}
+⚽ {{.Evento}}{{if .Competicao}} · {{.Competicao}}{{end}}{{if .Inicio}}
+🕒 {{.Inicio}}{{end}}{{end}}
+{{if .Bloqueado}}
+🔒 Palpite exclusivo para {{.Publico}}{{else}}{{if .Selecao}}
+✅ {{if .Mercado}}{{.Mercado}}: {{end}}{{.Selecao}}{{if .Linha}} ({{.Linha}}){{end}}{{end}}{{if .Odd}}
+📈 Odd {{.Odd}}{{end}}
+💰 Stake {{.Stake}}u{{end}}
+
+{{.URL}}`
+
+// DefaultDiscordTemplate usa o markdown do Discord; menções (@everyone, @here) são desativadas no envio
+const DefaultDiscordTemplate = `🎯 **{{.Tipster}}** publicou um palpite
+{{if .Titulo}}
+**{{.Titulo}}**{{end}}{{if .Evento}}
+⚽ {{.Evento}}{{if .Competicao}} · {{.Competicao}}{{end}}{{if .Inicio}}
+🕒 {{.Inicio}}{{end}}{{end}}
+{{if .Bloqueado}}
+🔒 Palpite exclusivo para {{.Publico}}{{else}}{{if .Selecao}}
+✅ {{if .Mercado}}{{.Mercado}}: {{end}}{{.Selecao}}{{if .Linha}} ({{.Linha}}){{end}}{{end}}{{if .Odd}}
+📈 Odd {{.Odd}}{{end}}
+💰 Stake {{.Stake}}u{{end}}
+
+{{.URL}}`
+
+// Data são as variáveis disponíveis nos templates. Em palpites bloqueados (restritos, com
+// incluir_restritos) mercado, seleção, linha, odd e imagem ficam vazios.
+type Data struct {
+	Tipster    string
+	Titulo     string
+	Evento     string
+	Competicao string
+	Inicio     string // data e hora do evento, horário de Brasília
+	Mercado    string
+	Selecao    string
+	Linha      string
+	Odd        string
+	Stake      string
+	Link       string // link externo informado pelo tipster
+	URL        string // página do palpite no SmartPicks
+	Imagem     string
+	Bloqueado  bool
+	Publico    string // "assinantes" ou "seguidores"
+}
+
+var brasilia = loadLocation("America/Sao_Paulo")
+
+func loadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// NewData monta as variáveis do template. Palpites restritos viram prévia bloqueada.
+func NewData(p *models.Palpite, tipster string, event *models.Event) Data {
+	d := Data{
+		Tipster: tipster,
+		Stake:   strconv.FormatFloat(p.Stake, 'f', -1, 64),
+		URL:     services.FrontendURL() + "/palpites/" + strconv.Itoa(p.ID),
+		Imagem:  p.ImgURL,
+	}
+	if p.Titulo != nil {
+		d.Titulo = *p.Titulo
+	}
+	if p.Link != nil {
+		d.Link = *p.Link
+	}
+	if event != nil {
+		d.Evento = event.Nome
+		d.Competicao = event.Competicao
+		d.Inicio = event.KickoffAt.In(brasilia).Format("02/01 15:04")
+	}
+	if p.Visibilidade != "" && p.Visibilidade != models.VISIBILIDADE_PUBLICO {
+		d.Bloqueado = true
+		d.Publico = p.Visibilidade
+		d.Imagem = ""
+		return d
+	}
+	if p.Mercado != nil {
+		d.Mercado = *p.Mercado
+	}
+	if p.Selecao != nil {
+		d.Selecao = *p.Selecao
+	}
+	if p.Linha != nil {
+		d.Linha = strconv.FormatFloat(*p.Linha, 'f', -1, 64)
+	}
+	if p.Odd != nil {
+		d.Odd = odds.Format(*p.Odd, odds.FORMATO_DECIMAL)
+	}
+	return d
+}
+
+// sampleData é usado na validação de templates e no envio de teste
+func sampleData() Data {
+	return Data{
+		Tipster:    "SmartPicks",
+		Titulo:     "Mensagem de teste",
+		Evento:     "Flamengo x Palmeiras",
+		Competicao: "Brasileirão Série A",
+		Inicio:     time.Now().Add(24 * time.Hour).In(brasilia).Format("02/01 15:04"),
+		Mercado:    "Resultado final",
+		Selecao:    "Flamengo",
+		Odd:        "2.10",
+		Stake:      "1",
+		URL:        services.FrontendURL(),
+	}
+}
+
+func defaultTemplate(plataforma string) string {
+	if plataforma == PLATAFORMA_TELEGRAM {
+		return DefaultTelegramTemplate
+	}
+	return DefaultDiscordTemplate
+}
+
+// render aplica o template do canal (ou o padrão da plataforma). Telegram usa html/template
+// para escapar os valores; o Discord recebe texto puro.
+func render(plataforma, tmpl string, data Data) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultTemplate(plataforma)
+	}
+	var buf bytes.Buffer
+	if plataforma == PLATAFORMA_TELEGRAM {
+		t, err := htmltemplate.New("mensagem").Parse(tmpl)
+		if err != nil {
+			return "", err
+		}
+		if err := t.Execute(&buf, data); err != nil {
+			return "", err
+		}
+	} else {
+		t, err := texttemplate.New("mensagem").Parse(tmpl)
+		if err != nil {
+			return "", err
+		}
+		if err := t.Execute(&buf, data); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// ValidateTemplate confere se o template compila e produz uma mensagem com os dados de exemplo
+func ValidateTemplate(plataforma, tmpl string) error {
+	if utf8.RuneCountInString(tmpl) > maxTemplateLen {
+		return errors.New("template deve ter no máximo 2000 caracteres")
+	}
+	msg, err := render(plataforma, tmpl, sampleData())
+	if err != nil {
+		return errors.New("template inválido: " + err.Error())
+	}
+	if msg == "" {
+		return errors.New("template inválido: a mensagem gerada está vazia")
+	}
+	return nil
+}
+
+// truncate corta a mensagem no limite da plataforma, em runas
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
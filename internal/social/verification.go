@@ -0,0 +1,205 @@
+package social
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/database"
+)
+
+// VerificationTTL é o prazo para enviar o código no chat depois de gerá-lo
+const VerificationTTL = time.Hour
+
+// ComandoVerificacao é o comando que o tipster envia no chat com o código
+const ComandoVerificacao = "/verificar"
+
+var verificationEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Verification é o código de uso único que comprova que o tipster administra o chat do Telegram.
+// O bot usa o token da plataforma, então sem essa prova qualquer tipster poderia fazê-lo publicar
+// em chats de terceiros onde ele está.
+type Verification struct {
+	Codigo   string    `json:"codigo"`
+	Comando  string    `json:"comando"`
+	ExpiraEm time.Time `json:"expira_em"`
+}
+
+// TelegramWebhookSecret é o secret_token informado no setWebhook do bot
+// (TELEGRAM_WEBHOOK_SECRET); o Telegram o envia no header X-Telegram-Bot-Api-Secret-Token
+func TelegramWebhookSecret() string {
+	return os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+}
+
+// ValidWebhookSecret compara o header recebido com o segredo configurado
+func ValidWebhookSecret(header string) bool {
+	secret := TelegramWebhookSecret()
+	return secret != "" && subtle.ConstantTimeCompare([]byte(header), []byte(secret)) == 1
+}
+
+// StartVerification gera um novo código para o canal do Telegram, substituindo o anterior
+func StartVerification(c *Channel) (*Verification, error) {
+	if c.Plataforma != PLATAFORMA_TELEGRAM {
+		return nil, fmt.Errorf("canais do %s não precisam de verificação", c.Plataforma)
+	}
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	code := verificationEncoding.EncodeToString(b)
+	expires := time.Now().Add(VerificationTTL)
+	if _, err := database.DB.Exec(`
+		UPDATE social_channels
+		SET verification_code_hash = $2, verification_expires_at = $3
+		WHERE id = $1`, c.ID, hashCode(code), expires); err != nil {
+		return nil, err
+	}
+	return &Verification{Codigo: code, Comando: ComandoVerificacao + " " + code, ExpiraEm: expires}, nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// TelegramUpdate contém os campos usados de um update da API de bots
+type TelegramUpdate struct {
+	Message     *telegramMessage `json:"message"`
+	ChannelPost *telegramMessage `json:"channel_post"`
+}
+
+type telegramMessage struct {
+	Text string       `json:"text"`
+	Chat telegramChat `json:"chat"`
+	From *struct {
+		ID int64 `json:"id"`
+	} `json:"from"`
+	// SenderChat é o próprio grupo quando um administrador anônimo envia a mensagem
+	SenderChat *telegramChat `json:"sender_chat"`
+}
+
+type telegramChat struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Username string `json:"username"`
+}
+
+// HandleTelegramUpdate processa as mensagens recebidas pelo bot e verifica o canal quando o
+// código chega no chat cadastrado, enviado por um administrador. Em canais só administradores
+// publicam; em grupos o remetente é conferido com getChatMember. Depois da verificação o
+// destino passa a ser o id numérico do chat, que não muda como o @username.
+func HandleTelegramUpdate(ctx context.Context, u TelegramUpdate) error {
+	msg := u.ChannelPost
+	if msg == nil {
+		msg = u.Message
+	}
+	if msg == nil {
+		return nil
+	}
+	code, ok := parseVerifyCommand(msg.Text)
+	if !ok {
+		return nil
+	}
+
+	var channelID int
+	var target string
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT id, target FROM social_channels
+		WHERE platform = $1 AND verification_code_hash = $2 AND verification_expires_at > NOW()`,
+		PLATAFORMA_TELEGRAM, hashCode(code)).Scan(&channelID, &target)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !sameChat(target, msg.Chat) {
+		log.Printf("Código de verificação do canal %d enviado em outro chat (%d)", channelID, msg.Chat.ID)
+		return nil
+	}
+	admin, err := sentByAdmin(ctx, msg)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		log.Printf("Código de verificação do canal %d enviado por quem não administra o chat", channelID)
+		return nil
+	}
+
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	result, err := database.DB.ExecContext(ctx, `
+		UPDATE social_channels
+		SET verified_at = NOW(), target = $3, verification_code_hash = NULL, verification_expires_at = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND verification_code_hash = $2`, channelID, hashCode(code), chatID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 1 {
+		if _, err := sendTelegram(ctx, chatID, "✅ Canal verificado. Os palpites passam a ser publicados aqui.", ""); err != nil {
+			log.Printf("Erro ao confirmar verificação do canal %d: %v", channelID, err)
+		}
+	}
+	return nil
+}
+
+// parseVerifyCommand aceita "/verificar CODIGO" e "/verificar@NomeDoBot CODIGO"
+func parseVerifyCommand(text string) (string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return "", false
+	}
+	cmd, _, _ := strings.Cut(fields[0], "@")
+	if cmd != ComandoVerificacao {
+		return "", false
+	}
+	return strings.ToUpper(fields[1]), true
+}
+
+func sameChat(target string, chat telegramChat) bool {
+	if strings.HasPrefix(target, "@") {
+		return chat.Username != "" && strings.EqualFold(target, "@"+chat.Username)
+	}
+	return target == strconv.FormatInt(chat.ID, 10)
+}
+
+func sentByAdmin(ctx context.Context, msg *telegramMessage) (bool, error) {
+	switch {
+	case msg.Chat.Type == "channel":
+		return true, nil
+	case msg.SenderChat != nil:
+		return msg.SenderChat.ID == msg.Chat.ID, nil
+	case msg.From == nil:
+		return false, nil
+	case msg.Chat.Type == "private":
+		return msg.From.ID == msg.Chat.ID, nil
+	}
+
+	status, raw, _, err := postJSON(ctx, telegramAPIURL()+"/bot"+TelegramBotToken()+"/getChatMember",
+		map[string]interface{}{"chat_id": msg.Chat.ID, "user_id": msg.From.ID})
+	if err != nil {
+		return false, err
+	}
+	var resp struct {
+		OK     bool `json:"ok"`
+		Result struct {
+			Status string `json:"status"`
+		} `json:"result"`
+	}
+	json.Unmarshal(raw, &resp)
+	if status >= 500 {
+		return false, fmt.Errorf("Telegram respondeu %d ao consultar membro do chat", status)
+	}
+	return resp.OK && (resp.Result.Status == "creator" || resp.Result.Status == "administrator"), nil
+}
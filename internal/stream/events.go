@@ -0,0 +1,134 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"smartpicks-backend/internal/compliance"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/notifications"
+)
+
+// Tipos de evento SSE (campo "event:")
+const (
+	EVENTO_PALPITE      = "palpite"      // tipster seguido publicou um palpite
+	EVENTO_LIQUIDACAO   = "liquidacao"   // palpite seu ou de quem você segue foi liquidado
+	EVENTO_NOTIFICACOES = "notificacoes" // total de notificações não lidas mudou
+)
+
+// Event é um evento gravado para um usuário; ID é o id SSE
+type Event struct {
+	ID        int
+	Tipo      string
+	Data      json.RawMessage
+	CreatedAt time.Time
+}
+
+// Retention é por quanto tempo os eventos ficam disponíveis para retomar com Last-Event-ID
+const Retention = 24 * time.Hour
+
+// Register inscreve o stream nos eventos de domínio
+func Register() {
+	events.Subscribe("stream", onPalpitePublished)
+	events.Subscribe("stream", onPalpiteSettled)
+	events.Subscribe("stream", onNotificationCreated)
+	events.Subscribe("stream", onNotificationsRead)
+}
+
+// onPalpitePublished avisa os seguidores do autor, exceto os que estão em pausa ou autoexclusão
+func onPalpitePublished(ctx context.Context, e events.PalpitePublished) error {
+	return insert(ctx, `
+		SELECT f.follower_id FROM user_follows f
+		WHERE f.followed_id = $1 AND `+compliance.NotExcluded("f.follower_id"), e.AuthorID,
+		EVENTO_PALPITE, e, fmt.Sprintf("palpite:%d", e.PalpiteID))
+}
+
+// onPalpiteSettled avisa o autor e quem o segue (o feed mostra o resultado), com o mesmo filtro
+// de exclusão dos seguidores
+func onPalpiteSettled(ctx context.Context, e events.PalpiteSettled) error {
+	return insert(ctx, `
+		SELECT $1::int UNION SELECT f.follower_id FROM user_follows f
+		WHERE f.followed_id = $1 AND `+compliance.NotExcluded("f.follower_id"), e.UserID,
+		EVENTO_LIQUIDACAO, e, fmt.Sprintf("liquidacao:%d:%s", e.PalpiteID, e.Status))
+}
+
+func onNotificationCreated(ctx context.Context, e events.NotificationCreated) error {
+	return unreadCount(ctx, e.UserID)
+}
+
+func onNotificationsRead(ctx context.Context, e events.NotificationsRead) error {
+	return unreadCount(ctx, e.UserID)
+}
+
+// unreadCount grava o total atual de não lidas; eventos repetidos são inofensivos
+func unreadCount(ctx context.Context, userID int) error {
+	n, err := notifications.UnreadCount(userID)
+	if err != nil {
+		return err
+	}
+	return insert(ctx, "SELECT $1::int", userID, EVENTO_NOTIFICACOES, map[string]int{"unread_count": n}, "")
+}
+
+// insert grava o evento para cada usuário devolvido por recipients ($1 = recipientArg). O trigger
+// de stream_events emite o NOTIFY; dedupeKey (opcional) evita repetir eventos em reentregas.
+func insert(ctx context.Context, recipients string, recipientArg int, tipo string, data interface{}, dedupeKey string) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var key interface{}
+	if dedupeKey != "" {
+		key = dedupeKey
+	}
+	_, err = database.DB.ExecContext(ctx, `
+		INSERT INTO stream_events (user_id, type, data, dedupe_key)
+		SELECT r.user_id, $2::varchar, $3::jsonb, $4::varchar FROM (`+recipients+`) AS r(user_id)
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING`, recipientArg, tipo, payload, key)
+	return err
+}
+
+// Since devolve os eventos do usuário posteriores a lastID, em ordem
+func Since(ctx context.Context, userID, lastID, limit int) ([]Event, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, type, data, created_at FROM stream_events
+		WHERE user_id = $1 AND id > $2
+		ORDER BY id LIMIT $3`, userID, lastID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Event
+	for rows.Next() {
+		var e Event
+		var data []byte
+		if err := rows.Scan(&e.ID, &e.Tipo, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Data = data
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// LatestID é o id do último evento do usuário (0 se não houver), ponto de partida de uma
+// conexão nova sem Last-Event-ID
+func LatestID(ctx context.Context, userID int) (int, error) {
+	var id int
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(id), 0) FROM stream_events WHERE user_id = $1", userID).Scan(&id)
+	return id, err
+}
+
+// Purge remove eventos mais antigos que Retention
+func Purge(now time.Time) (int, error) {
+	result, err := database.DB.Exec("DELETE FROM stream_events WHERE created_at < $1", now.Add(-Retention))
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,135 @@
+// Package stream alimenta o endpoint SSE /api/stream. Os eventos de cada usuário são gravados em
+// stream_events (o id é o id SSE, o que permite retomar com Last-Event-ID); um trigger emite
+// NOTIFY a cada evento gravado e o Listener acorda as conexões do usuário em qualquer instância.
+package stream
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// channel é o canal LISTEN/NOTIFY usado pelo trigger de stream_events
+const channel = "smartpicks_stream"
+
+// Hub mantém as conexões SSE abertas nesta instância, por usuário
+type Hub struct {
+	mu      sync.Mutex
+	clients map[int]map[chan struct{}]struct{}
+	closed  chan struct{}
+	once    sync.Once
+	running bool
+}
+
+var DefaultHub = &Hub{clients: map[int]map[chan struct{}]struct{}{}, closed: make(chan struct{})}
+
+// Subscribe registra uma conexão do usuário. O canal recebe um sinal (sem conteúdo) quando há
+// eventos novos; quem recebe consulta stream_events a partir do último id enviado.
+func (h *Hub) Subscribe(userID int) (chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = map[chan struct{}]struct{}{}
+	}
+	h.clients[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.clients[userID], ch)
+		if len(h.clients[userID]) == 0 {
+			delete(h.clients, userID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Wake sinaliza as conexões do usuário sem bloquear (um sinal pendente basta)
+func (h *Hub) Wake(userID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients[userID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// WakeAll sinaliza todas as conexões (ex.: após reconectar o LISTEN, quando NOTIFYs podem ter se perdido)
+func (h *Hub) WakeAll() {
+	h.mu.Lock()
+	users := make([]int, 0, len(h.clients))
+	for id := range h.clients {
+		users = append(users, id)
+	}
+	h.mu.Unlock()
+	for _, id := range users {
+		h.Wake(id)
+	}
+}
+
+// Live indica se o Listener está ativo. Sem ele (ex.: na Vercel), as conexões consultam o banco
+// periodicamente.
+func (h *Hub) Live() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.running
+}
+
+// Closed é fechado no desligamento do servidor, para encerrar as conexões abertas
+func (h *Hub) Closed() <-chan struct{} {
+	return h.closed
+}
+
+// Stop encerra as conexões SSE (registrado em http.Server.RegisterOnShutdown)
+func (h *Hub) Stop() {
+	h.once.Do(func() { close(h.closed) })
+}
+
+// Listen escuta o canal de NOTIFY até ctx ser cancelado. Cada notificação traz o user_id do
+// evento gravado; o pq.Listener reconecta sozinho.
+func (h *Hub) Listen(ctx context.Context, databaseURL string) {
+	listener := pq.NewListener(databaseURL, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Stream: LISTEN %s: %v", channel, err)
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(channel); err != nil {
+		log.Printf("Stream: não foi possível escutar %s: %v", channel, err)
+		return
+	}
+
+	h.mu.Lock()
+	h.running = true
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.running = false
+		h.mu.Unlock()
+	}()
+	log.Printf("Stream: escutando %s", channel)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			if n == nil {
+				// Conexão restabelecida: notificações do intervalo podem ter se perdido
+				h.WakeAll()
+				continue
+			}
+			if userID, err := strconv.Atoi(n.Extra); err == nil {
+				h.Wake(userID)
+			}
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package subscriptions
+
+import (
+	"log"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/follows"
+	"smartpicks-backend/internal/models"
+)
+
+// Viewer decide quais palpites o usuário da requisição pode ver por completo. Guarda em cache,
+// por tipster, se o usuário segue e se é assinante, para listagens com vários palpites.
+type Viewer struct {
+	user       *models.User
+	following  map[int]bool
+	subscribed map[int]bool
+}
+
+// NewViewer cria o verificador para o usuário (nil para visitantes)
+func NewViewer(user *models.User) *Viewer {
+	return &Viewer{user: user, following: map[int]bool{}, subscribed: map[int]bool{}}
+}
+
+// CanView indica se o conteúdo de um palpite do tipster com a visibilidade informada é liberado.
+// Autor e administradores sempre veem; erros de consulta negam o acesso.
+func (v *Viewer) CanView(tipsterID int, visibilidade string) bool {
+	if visibilidade == "" || visibilidade == models.VISIBILIDADE_PUBLICO {
+		return true
+	}
+	if v.user == nil {
+		return false
+	}
+	if v.user.ID == tipsterID || v.user.IsAdmin() {
+		return true
+	}
+
+	if v.isSubscribed(tipsterID) {
+		return true
+	}
+	return visibilidade == models.VISIBILIDADE_SEGUIDORES && v.isFollowing(tipsterID)
+}
+
+func (v *Viewer) isFollowing(tipsterID int) bool {
+	if following, ok := v.following[tipsterID]; ok {
+		return following
+	}
+	following, err := follows.IsFollowing(v.user.ID, tipsterID)
+	if err != nil {
+		log.Printf("Erro ao verificar se %d segue %d: %v", v.user.ID, tipsterID, err)
+		return false
+	}
+	v.following[tipsterID] = following
+	return following
+}
+
+func (v *Viewer) isSubscribed(tipsterID int) bool {
+	if subscribed, ok := v.subscribed[tipsterID]; ok {
+		return subscribed
+	}
+	var subscribed bool
+	err := database.DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM subscriptions
+			WHERE subscriber_id = $1 AND tipster_id = $2 AND status IN ($3, $4) AND current_period_end > NOW()
+		)`, v.user.ID, tipsterID, models.ASSINATURA_ATIVA, models.ASSINATURA_CANCELADA).Scan(&subscribed)
+	if err != nil {
+		log.Printf("Erro ao verificar assinatura de %d em %d: %v", v.user.ID, tipsterID, err)
+		return false
+	}
+	v.subscribed[tipsterID] = subscribed
+	return subscribed
+}
@@ -0,0 +1,105 @@
+// Package subscriptions gerencia planos de assinatura dos tipsters, assinaturas e o controle de
+// acesso (entitlement) aos palpites restritos.
+package subscriptions
+
+import (
+	"database/sql"
+	"errors"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+)
+
+var (
+	ErrNotFound       = errors.New("registro não encontrado")
+	ErrPlanInactive   = errors.New("plano indisponível")
+	ErrOwnPlan        = errors.New("não é possível assinar o próprio plano")
+	ErrAlreadyActive  = errors.New("já existe uma assinatura ativa com este tipster")
+	ErrNotCancellable = errors.New("assinatura não pode ser cancelada")
+	ErrDuplicateEvent = errors.New("evento do provedor já processado")
+)
+
+const planColumns = `id, tipster_id, nome, descricao, preco_centavos, moeda, intervalo, ativo, created_at, updated_at`
+
+// DefaultCurrency é a moeda dos planos
+const DefaultCurrency = "BRL"
+
+// ListPlans lista os planos de um tipster; onlyActive omite os desativados
+func ListPlans(tipsterID int, onlyActive bool) ([]models.SubscriptionPlan, error) {
+	query := "SELECT " + planColumns + " FROM subscription_plans WHERE tipster_id = $1"
+	if onlyActive {
+		query += " AND ativo"
+	}
+	rows, err := database.DB.Query(query+" ORDER BY preco_centavos, id", tipsterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []models.SubscriptionPlan{}
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+	return plans, rows.Err()
+}
+
+func GetPlan(id int) (*models.SubscriptionPlan, error) {
+	plan, err := scanPlan(database.DB.QueryRow("SELECT "+planColumns+" FROM subscription_plans WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return plan, err
+}
+
+// SavePlan cria (id 0) ou atualiza um plano do tipster. Alterar o preço não afeta assinaturas em curso.
+func SavePlan(tipsterID, id int, req models.PlanRequest) (*models.SubscriptionPlan, error) {
+	var row *sql.Row
+	if id == 0 {
+		row = database.DB.QueryRow(`
+			INSERT INTO subscription_plans (tipster_id, nome, descricao, preco_centavos, moeda, intervalo)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING `+planColumns,
+			tipsterID, req.Nome, req.Descricao, req.PrecoCentavos, DefaultCurrency, req.Intervalo)
+	} else {
+		row = database.DB.QueryRow(`
+			UPDATE subscription_plans
+			SET nome = $1, descricao = $2, preco_centavos = $3, intervalo = $4, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $5 AND tipster_id = $6
+			RETURNING `+planColumns,
+			req.Nome, req.Descricao, req.PrecoCentavos, req.Intervalo, id, tipsterID)
+	}
+
+	plan, err := scanPlan(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return plan, err
+}
+
+// DeactivatePlan impede novas assinaturas do plano; as existentes seguem até o fim do período
+func DeactivatePlan(tipsterID, id int) error {
+	result, err := database.DB.Exec(`
+		UPDATE subscription_plans SET ativo = FALSE, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND tipster_id = $2`, id, tipsterID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanPlan(row models.RowScanner) (*models.SubscriptionPlan, error) {
+	var p models.SubscriptionPlan
+	err := row.Scan(&p.ID, &p.TipsterID, &p.Nome, &p.Descricao, &p.PrecoCentavos, &p.Moeda, &p.Intervalo,
+		&p.Ativo, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,240 @@
+package subscriptions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/payments"
+	"smartpicks-backend/internal/services"
+)
+
+const subscriptionColumns = `id, plan_id, subscriber_id, tipster_id, status, provider, provider_ref,
+	current_period_end, canceled_at, created_at, updated_at`
+
+// Subscribe cria a assinatura (pendente) e a cobrança no provedor. Se o provedor confirmar o
+// pagamento na hora, a assinatura já volta ativa; senão checkout.URL leva ao pagamento.
+func Subscribe(ctx context.Context, provider payments.Provider, subscriber *models.User, planID int) (*models.Subscription, *payments.Checkout, error) {
+	plan, err := GetPlan(planID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !plan.Ativo {
+		return nil, nil, ErrPlanInactive
+	}
+	if plan.TipsterID == subscriber.ID {
+		return nil, nil, ErrOwnPlan
+	}
+
+	var active bool
+	err = database.DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM subscriptions
+			WHERE subscriber_id = $1 AND tipster_id = $2 AND status = $3 AND current_period_end > NOW()
+		)`, subscriber.ID, plan.TipsterID, models.ASSINATURA_ATIVA).Scan(&active)
+	if err != nil {
+		return nil, nil, err
+	}
+	if active {
+		return nil, nil, ErrAlreadyActive
+	}
+
+	var subID int
+	err = database.DB.QueryRow(`
+		INSERT INTO subscriptions (plan_id, subscriber_id, tipster_id, status, provider)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`, plan.ID, subscriber.ID, plan.TipsterID, models.ASSINATURA_PENDENTE, provider.Name()).Scan(&subID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	checkout, err := provider.CreateCheckout(ctx, payments.CheckoutRequest{
+		SubscriptionID: subID,
+		PlanNome:       plan.Nome,
+		AmountCents:    plan.PrecoCentavos,
+		Currency:       plan.Moeda,
+		IntervalMonths: models.IntervaloMeses[plan.Intervalo],
+		CustomerEmail:  subscriber.Email,
+		ReturnURL:      fmt.Sprintf("%s/assinaturas/%d", services.FrontendURL(), subID),
+	})
+	if err != nil {
+		database.DB.Exec("DELETE FROM subscriptions WHERE id = $1", subID)
+		return nil, nil, fmt.Errorf("%s: %w", provider.Name(), err)
+	}
+
+	if _, err := database.DB.Exec("UPDATE subscriptions SET provider_ref = $1 WHERE id = $2", checkout.Ref, subID); err != nil {
+		return nil, nil, err
+	}
+	if checkout.Paid {
+		if err := renew(database.DB, subID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sub, err := GetSubscription(subID)
+	return sub, checkout, err
+}
+
+// HandleWebhook aplica um evento do provedor: pagamento confirmado renova o período,
+// cancelamento encerra a renovação e falha de pagamento expira a assinatura pendente. Cada evento
+// é aplicado uma única vez: o id fica em payment_events e reenvios devolvem ErrDuplicateEvent.
+func HandleWebhook(providerName string, event *payments.WebhookEvent) (*models.Subscription, error) {
+	var subID int
+	err := database.DB.QueryRow(`
+		SELECT id FROM subscriptions WHERE provider = $1 AND provider_ref = $2`, providerName, event.Ref).Scan(&subID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO payment_events (provider, event_id, subscription_id, type)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, event_id) DO NOTHING`, providerName, event.ID, subID, event.Type)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		sub, err := GetSubscription(subID)
+		if err != nil {
+			return nil, err
+		}
+		return sub, ErrDuplicateEvent
+	}
+
+	switch event.Type {
+	case payments.EventPaymentSucceeded:
+		err = renew(tx, subID)
+	case payments.EventCanceled:
+		err = markCanceled(tx, subID)
+	case payments.EventPaymentFailed:
+		_, err = tx.Exec(`
+			UPDATE subscriptions SET status = $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2 AND status = $3`, models.ASSINATURA_EXPIRADA, subID, models.ASSINATURA_PENDENTE)
+	default:
+		return nil, payments.ErrInvalidWebhook
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetSubscription(subID)
+}
+
+// Cancel encerra a renovação da assinatura do usuário; o acesso continua até o fim do período pago
+func Cancel(ctx context.Context, subscriberID, id int) (*models.Subscription, error) {
+	sub, err := GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.SubscriberID != subscriberID {
+		return nil, ErrNotFound
+	}
+	if sub.Status != models.ASSINATURA_ATIVA && sub.Status != models.ASSINATURA_PENDENTE {
+		return nil, ErrNotCancellable
+	}
+
+	if sub.ProviderRef != nil {
+		provider, err := payments.ByName(sub.Provider)
+		if err != nil {
+			return nil, err
+		}
+		if err := provider.Cancel(ctx, *sub.ProviderRef); err != nil {
+			return nil, fmt.Errorf("%s: %w", sub.Provider, err)
+		}
+	}
+
+	if err := markCanceled(database.DB, id); err != nil {
+		return nil, err
+	}
+	return GetSubscription(id)
+}
+
+func GetSubscription(id int) (*models.Subscription, error) {
+	sub, err := scanSubscription(database.DB.QueryRow("SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return sub, err
+}
+
+// ListBySubscriber lista as assinaturas feitas pelo usuário
+func ListBySubscriber(userID int) ([]models.Subscription, error) {
+	return listSubscriptions("subscriber_id", userID)
+}
+
+// ListByTipster lista as assinaturas dos planos do tipster
+func ListByTipster(tipsterID int) ([]models.Subscription, error) {
+	return listSubscriptions("tipster_id", tipsterID)
+}
+
+func listSubscriptions(column string, userID int) ([]models.Subscription, error) {
+	rows, err := database.DB.Query("SELECT "+subscriptionColumns+" FROM subscriptions WHERE "+column+" = $1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *sub)
+	}
+	return list, rows.Err()
+}
+
+// execer é o *sql.DB ou a transação do webhook
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// renew ativa a assinatura e estende o período pelo intervalo do plano, a partir do fim do
+// período atual (ou de agora, se já expirou). Só assinaturas pendentes ou ativas são renovadas:
+// um pagamento que chega depois do cancelamento não reabre a assinatura.
+func renew(db execer, id int) error {
+	_, err := db.Exec(`
+		UPDATE subscriptions s
+		SET status = $1,
+			current_period_end = GREATEST(COALESCE(s.current_period_end, NOW()), NOW())
+				+ make_interval(months => CASE p.intervalo WHEN $2 THEN 12 WHEN $3 THEN 3 ELSE 1 END),
+			updated_at = CURRENT_TIMESTAMP
+		FROM subscription_plans p
+		WHERE p.id = s.plan_id AND s.id = $4 AND s.status IN ($5, $1)`,
+		models.ASSINATURA_ATIVA, models.INTERVALO_ANUAL, models.INTERVALO_TRIMESTRAL, id, models.ASSINATURA_PENDENTE)
+	return err
+}
+
+func markCanceled(db execer, id int) error {
+	_, err := db.Exec(`
+		UPDATE subscriptions
+		SET status = CASE WHEN status = $1 THEN $2 ELSE $3 END,
+			canceled_at = NOW(), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4`,
+		models.ASSINATURA_PENDENTE, models.ASSINATURA_EXPIRADA, models.ASSINATURA_CANCELADA, id)
+	return err
+}
+
+func scanSubscription(row models.RowScanner) (*models.Subscription, error) {
+	var s models.Subscription
+	err := row.Scan(&s.ID, &s.PlanID, &s.SubscriberID, &s.TipsterID, &s.Status, &s.Provider, &s.ProviderRef,
+		&s.CurrentPeriodEnd, &s.CanceledAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,103 @@
+// Package tasks registra os handlers de cada tipo de job da fila (internal/jobs). Fica separado
+// de jobs para que os pacotes de domínio possam enfileirar sem ciclos de importação.
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"sync"
+
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/notifications"
+	"smartpicks-backend/internal/push"
+	"smartpicks-backend/internal/results"
+	"smartpicks-backend/internal/services"
+	"smartpicks-backend/internal/social"
+	"smartpicks-backend/internal/stream"
+	"smartpicks-backend/internal/webhooks"
+)
+
+var once sync.Once
+
+// Register associa todos os tipos de job aos seus handlers e inscreve os assinantes de eventos;
+// pode ser chamado mais de uma vez
+func Register() {
+	once.Do(func() {
+		jobs.Register(sendEmail)
+		jobs.Register(processImage)
+		jobs.Register(settleEvents)
+		jobs.Register(events.Deliver)
+
+		// Assinantes do barramento de eventos
+		notifications.Register()
+		push.Register()
+		stream.Register()
+		webhooks.Register()
+		social.Register()
+	})
+}
+
+func sendEmail(ctx context.Context, p jobs.SendEmail) error {
+	if p.To == "" {
+		return jobs.Permanent(errors.New("destinatário vazio"))
+	}
+	return services.NewMailer().Send(p.To, p.Subject, p.Body)
+}
+
+// processImage lê o cabeçalho da imagem no S3 e grava largura e altura. Formatos sem decoder
+// na biblioteca padrão (webp) são marcados como processados sem dimensões.
+func processImage(ctx context.Context, p jobs.ProcessImage) error {
+	var key string
+	err := database.DB.QueryRowContext(ctx, "SELECT s3_key FROM uploaded_files WHERE id = $1", p.UploadID).Scan(&key)
+	if err == sql.ErrNoRows {
+		return jobs.Permanent(fmt.Errorf("upload %d não encontrado", p.UploadID))
+	}
+	if err != nil {
+		return err
+	}
+
+	s3Service, err := services.NewS3Service()
+	if err != nil {
+		return err
+	}
+	body, err := s3Service.Open(key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var width, height *int
+	cfg, format, err := image.DecodeConfig(body)
+	switch {
+	case errors.Is(err, image.ErrFormat):
+		log.Printf("Upload %d: formato sem suporte para leitura de dimensões", p.UploadID)
+	case err != nil:
+		return jobs.Permanent(fmt.Errorf("imagem inválida: %w", err))
+	default:
+		width, height = &cfg.Width, &cfg.Height
+		log.Printf("Upload %d: %s %dx%d", p.UploadID, format, cfg.Width, cfg.Height)
+	}
+
+	_, err = database.DB.ExecContext(ctx, `
+		UPDATE uploaded_files SET width = $1, height = $2, processed_at = CURRENT_TIMESTAMP
+		WHERE id = $3`, width, height, p.UploadID)
+	return err
+}
+
+func settleEvents(ctx context.Context, p jobs.SettleEvents) error {
+	report, err := results.SettleEvents(p.EventIDs)
+	if err != nil {
+		return err
+	}
+	log.Printf("Liquidação: %d palpites liquidados, %d ignorados", len(report.Settled), report.Skipped)
+	return nil
+}
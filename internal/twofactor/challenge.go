@@ -0,0 +1,62 @@
+package twofactor
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+)
+
+// ErrInvalidChallenge é um desafio desconhecido, expirado, já usado ou com tentativas esgotadas
+var ErrInvalidChallenge = errors.New("verificação expirada; faça login novamente")
+
+const (
+	// ChallengeTTL é o tempo para informar o código depois da senha
+	ChallengeTTL = 5 * time.Minute
+	// maxChallengeAttempts limita a adivinhação do código de 6 dígitos por desafio
+	maxChallengeAttempts = 5
+)
+
+// NewChallenge registra que o usuário passou pelo primeiro fator (senha ou provedor social) e
+// devolve o token que acompanha o código no segundo passo do login
+func NewChallenge(userID int) (string, error) {
+	token, err := auth.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	_, err = database.DB.Exec(`
+		INSERT INTO login_challenges (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)`, auth.HashToken(token), userID, time.Now().Add(ChallengeTTL))
+	return token, err
+}
+
+// ChallengeUser conta uma tentativa no desafio e devolve o usuário dele
+func ChallengeUser(token string) (int, error) {
+	var userID int
+	err := database.DB.QueryRow(`
+		UPDATE login_challenges SET attempts = attempts + 1
+		WHERE token_hash = $1 AND expires_at > NOW() AND attempts < $2
+		RETURNING user_id`, auth.HashToken(token), maxChallengeAttempts).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidChallenge
+	}
+	return userID, err
+}
+
+// ConsumeChallenge encerra o desafio depois do código aceito
+func ConsumeChallenge(token string) error {
+	_, err := database.DB.Exec("DELETE FROM login_challenges WHERE token_hash = $1", auth.HashToken(token))
+	return err
+}
+
+// PurgeChallenges remove desafios vencidos
+func PurgeChallenges(now time.Time) (int, error) {
+	result, err := database.DB.Exec("DELETE FROM login_challenges WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
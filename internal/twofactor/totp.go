@@ -0,0 +1,102 @@
+// Package twofactor implementa a verificação em duas etapas: TOTP (RFC 6238, compatível com
+// Google Authenticator, Authy e similares), códigos de recuperação de uso único e o desafio
+// que o login devolve quando o segundo fator é exigido.
+package twofactor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Parâmetros do TOTP: os padrões dos aplicativos autenticadores
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew aceita o código do período anterior e do seguinte (relógio do celular adiantado
+	// ou atrasado em até 30s)
+	totpSkew = 1
+
+	issuer = "SmartPicks"
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newSecret gera um segredo de 160 bits, o tamanho recomendado para HMAC-SHA1
+func newSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(b), nil
+}
+
+// otpauthURI é o conteúdo do QR code lido pelo aplicativo autenticador
+func otpauthURI(secret, account string) string {
+	q := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// code calcula o código do período (HOTP da RFC 4226 com o contador de tempo)
+func code(secret string, step int64) (string, error) {
+	key, err := secretEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000), nil
+}
+
+func stepAt(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// matchStep devolve o período em que o código é válido, dentro da janela de tolerância, ou 0.
+// Períodos até lastStep já foram usados e são recusados, para que um código observado não
+// possa ser reaproveitado.
+func matchStep(secret, input string, now time.Time, lastStep int64) int64 {
+	input = normalizeCode(input)
+	if len(input) != totpDigits {
+		return 0
+	}
+	current := stepAt(now)
+	for s := current - totpSkew; s <= current+totpSkew; s++ {
+		if s <= lastStep {
+			continue
+		}
+		expected, err := code(secret, s)
+		if err != nil {
+			return 0
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(input)) == 1 {
+			return s
+		}
+	}
+	return 0
+}
+
+// normalizeCode remove espaços e hífens que o usuário copia junto com o código
+func normalizeCode(s string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(s))
+}
@@ -0,0 +1,93 @@
+package twofactor
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret é a chave ASCII "12345678901234567890" dos vetores SHA-1 do apêndice B da RFC 6238
+var rfcSecret = secretEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238(t *testing.T) {
+	// A RFC publica códigos de 8 dígitos; os de 6 são os últimos 6 dígitos do mesmo valor
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := code(rfcSecret, stepAt(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("code(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	upper, err := code(rfcSecret, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lower, err := code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upper != lower {
+		t.Errorf("segredo em minúsculas gerou %s, want %s", lower, upper)
+	}
+}
+
+func TestMatchStep(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := stepAt(now)
+	at := func(step int64) string {
+		c, err := code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	// wrong troca o último dígito até obter um código que não vale em nenhum período da janela
+	wrong := func(c string) string {
+		for d := byte('0'); d <= '9'; d++ {
+			w := c[:5] + string(d)
+			if w != at(current-1) && w != at(current) && w != at(current+1) {
+				return w
+			}
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		lastStep int64
+		want     int64
+	}{
+		{"período atual", at(current), 0, current},
+		{"período anterior", at(current - 1), 0, current - 1},
+		{"período seguinte", at(current + 1), 0, current + 1},
+		{"fora da janela", at(current - 2), 0, 0},
+		{"com espaço e hífen", at(current)[:3] + " - " + at(current)[3:], 0, current},
+		{"já usado", at(current), current, 0},
+		{"anterior ao último usado", at(current - 1), current - 1, 0},
+		{"tamanho errado", "12345", 0, 0},
+		{"código errado", wrong(at(current)), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchStep(rfcSecret, tt.input, now, tt.lastStep); got != tt.want {
+				t.Errorf("matchStep(%q, last=%d) = %d, want %d", tt.input, tt.lastStep, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package twofactor
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/models"
+)
+
+var (
+	ErrAlreadyEnabled = errors.New("verificação em duas etapas já está ativa")
+	ErrNotEnabled     = errors.New("verificação em duas etapas não está ativa")
+	ErrNotSetUp       = errors.New("inicie a configuração antes de ativar")
+	ErrInvalidCode    = errors.New("código inválido")
+	ErrRequired       = errors.New("verificação em duas etapas é obrigatória para administradores")
+	ErrLocked         = errors.New("muitos códigos incorretos; tente novamente em alguns minutos")
+)
+
+const (
+	// recoveryCodeCount é quantos códigos de recuperação são gerados por vez
+	recoveryCodeCount = 10
+	// maxFailures é quantos códigos incorretos o usuário pode errar, somando todos os desafios,
+	// antes do bloqueio: o limite por desafio sozinho não impede abrir desafios novos com a senha
+	maxFailures = 10
+	// Lockout é quanto tempo a verificação fica bloqueada depois de maxFailures erros
+	Lockout = 15 * time.Minute
+)
+
+// Status é a situação do 2FA do usuário
+type Status struct {
+	Ativo            bool       `json:"ativo"`
+	AtivadoEm        *time.Time `json:"ativado_em,omitempty"`
+	CodigosRestantes int        `json:"codigos_restantes"`
+	Obrigatorio      bool       `json:"obrigatorio"`
+}
+
+// GetStatus devolve a situação do 2FA e quantos códigos de recuperação ainda não foram usados
+func GetStatus(user *models.User) (*Status, error) {
+	st := &Status{
+		Ativo:       user.TwoFactorEnabled(),
+		AtivadoEm:   user.TOTPEnabledAt,
+		Obrigatorio: auth.TwoFactorRequired(user),
+	}
+	if !st.Ativo {
+		return st, nil
+	}
+	err := database.DB.QueryRow(
+		"SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL", user.ID).Scan(&st.CodigosRestantes)
+	return st, err
+}
+
+// Setup gera um novo segredo, ainda inativo, e devolve o segredo e a URI otpauth:// para o QR
+// code. Chamar de novo antes de ativar substitui o segredo anterior.
+func Setup(user *models.User) (string, string, error) {
+	if user.TwoFactorEnabled() {
+		return "", "", ErrAlreadyEnabled
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return "", "", err
+	}
+	result, err := database.DB.Exec(
+		"UPDATE users SET totp_secret = $2, totp_last_step = NULL WHERE id = $1 AND totp_enabled_at IS NULL",
+		user.ID, secret)
+	if err != nil {
+		return "", "", err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return "", "", ErrAlreadyEnabled
+	}
+	return secret, otpauthURI(secret, user.Email), nil
+}
+
+// Enable confirma o segredo com um código do aplicativo, ativa o 2FA e devolve os códigos de
+// recuperação, que não são exibidos de novo
+func Enable(userID int, input string) ([]string, error) {
+	var secret sql.NullString
+	var enabledAt *time.Time
+	if err := database.DB.QueryRow(
+		"SELECT totp_secret, totp_enabled_at FROM users WHERE id = $1", userID).Scan(&secret, &enabledAt); err != nil {
+		return nil, err
+	}
+	if enabledAt != nil {
+		return nil, ErrAlreadyEnabled
+	}
+	if !secret.Valid {
+		return nil, ErrNotSetUp
+	}
+	step := matchStep(secret.String, input, time.Now(), 0)
+	if step == 0 {
+		return nil, ErrInvalidCode
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET totp_enabled_at = NOW(), totp_last_step = $3
+		WHERE id = $1 AND totp_secret = $2 AND totp_enabled_at IS NULL`, userID, secret.String, step)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// Outro Setup ou Enable concorrente trocou o segredo
+		return nil, ErrNotSetUp
+	}
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return codes, tx.Commit()
+}
+
+// Verify confere um código do aplicativo ou, no formato xxxxx-xxxxx, um código de recuperação,
+// que fica marcado como usado. recovery indica qual dos dois foi aceito. Os erros do usuário são
+// contados em todos os desafios e fluxos; ao atingir maxFailures, Verify devolve ErrLocked por
+// Lockout sem conferir o código.
+func Verify(userID int, input string) (recovery bool, err error) {
+	var locked bool
+	if err := database.DB.QueryRow(
+		"SELECT COALESCE(totp_locked_until > NOW(), FALSE) FROM users WHERE id = $1", userID).Scan(&locked); err != nil {
+		return false, err
+	}
+	if locked {
+		return false, ErrLocked
+	}
+
+	recovery, err = verify(userID, input)
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		_, dbErr := database.DB.Exec(`
+			UPDATE users SET
+				totp_locked_until = CASE WHEN totp_failed_attempts + 1 >= $2
+					THEN NOW() + $3::interval ELSE totp_locked_until END,
+				totp_failed_attempts = CASE WHEN totp_failed_attempts + 1 >= $2
+					THEN 0 ELSE totp_failed_attempts + 1 END
+			WHERE id = $1`, userID, maxFailures, fmt.Sprintf("%d seconds", int(Lockout.Seconds())))
+		if dbErr != nil {
+			return recovery, dbErr
+		}
+	case err == nil:
+		_, err = database.DB.Exec(
+			"UPDATE users SET totp_failed_attempts = 0 WHERE id = $1 AND totp_failed_attempts > 0", userID)
+	}
+	return recovery, err
+}
+
+func verify(userID int, input string) (recovery bool, err error) {
+	normalized := normalizeCode(input)
+	if len(normalized) != totpDigits {
+		return true, useRecoveryCode(userID, normalized)
+	}
+
+	var secret sql.NullString
+	var lastStep sql.NullInt64
+	var enabledAt *time.Time
+	if err := database.DB.QueryRow(
+		"SELECT totp_secret, totp_last_step, totp_enabled_at FROM users WHERE id = $1",
+		userID).Scan(&secret, &lastStep, &enabledAt); err != nil {
+		return false, err
+	}
+	if enabledAt == nil || !secret.Valid {
+		return false, ErrNotEnabled
+	}
+	step := matchStep(secret.String, normalized, time.Now(), lastStep.Int64)
+	if step == 0 {
+		return false, ErrInvalidCode
+	}
+	// A condição no UPDATE impede que duas requisições simultâneas usem o mesmo código
+	result, err := database.DB.Exec(`
+		UPDATE users SET totp_last_step = $2
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, ErrInvalidCode
+	}
+	return false, nil
+}
+
+func useRecoveryCode(userID int, normalized string) error {
+	if normalized == "" {
+		return ErrInvalidCode
+	}
+	result, err := database.DB.Exec(`
+		UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, auth.HashToken(strings.ToLower(normalized)))
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// Disable desativa o 2FA e apaga o segredo e os códigos de recuperação
+func Disable(user *models.User) error {
+	if !user.TwoFactorEnabled() {
+		return ErrNotEnabled
+	}
+	if auth.TwoFactorRequired(user) {
+		return ErrRequired
+	}
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL,
+			totp_failed_attempts = 0, totp_locked_until = NULL
+		WHERE id = $1`, user.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = $1", user.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reset é o Disable do admin para quem perdeu o celular e os códigos de recuperação; não
+// respeita a obrigatoriedade, e o usuário configura de novo no próximo acesso
+func Reset(userID int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL,
+			totp_failed_attempts = 0, totp_locked_until = NULL
+		WHERE id = $1 AND totp_enabled_at IS NOT NULL`, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotEnabled
+	}
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RegenerateRecoveryCodes invalida os códigos atuais e gera novos
+func RegenerateRecoveryCodes(userID int) ([]string, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return codes, tx.Commit()
+}
+
+var recoveryEncoding = base32.NewEncoding("abcdefghijkmnpqrstuvwxyz23456789").WithPadding(base32.NoPadding)
+
+// replaceRecoveryCodes gera os códigos (50 bits cada, sem caracteres ambíguos como l, o, 0 e 1)
+// e guarda apenas o hash
+func replaceRecoveryCodes(tx *sql.Tx, userID int) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := recoveryEncoding.EncodeToString(b)[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		if _, err := tx.Exec(
+			"INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, auth.HashToken(raw)); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
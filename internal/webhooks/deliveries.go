@@ -0,0 +1,283 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/listing"
+)
+
+// Status de uma entrega
+const (
+	ENTREGA_PENDENTE = "pendente" // aguardando a primeira tentativa ou uma nova tentativa
+	ENTREGA_ENTREGUE = "entregue"
+	ENTREGA_FALHOU   = "falhou" // tentativas esgotadas ou webhook desativado
+)
+
+var ValidDeliveryStatus = []string{ENTREGA_PENDENTE, ENTREGA_ENTREGUE, ENTREGA_FALHOU}
+
+// MaxTentativas é o número de tentativas de cada entrega. Com o backoff da fila (30s dobrando a
+// cada falha, até 1h) as tentativas se espalham por cerca de uma hora.
+const MaxTentativas = 8
+
+// deliveriesRetention é por quanto tempo o histórico de entregas é mantido
+const deliveriesRetention = 30 * 24 * time.Hour
+
+var ErrDeliveryNotFound = errors.New("entrega não encontrada")
+
+// Delivery é uma entrega do histórico. EventoID é o mesmo em todas as entregas de um evento
+// (inclusive reenvios), para que o integrador descarte duplicatas.
+type Delivery struct {
+	ID             int             `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	Evento         string          `json:"evento"`
+	EventoID       string          `json:"evento_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Tentativas     int             `json:"tentativas"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	ResponseBody   *string         `json:"response_body,omitempty"`
+	Erro           *string         `json:"erro,omitempty"`
+	DuracaoMs      *int            `json:"duracao_ms,omitempty"`
+	RedeliveryOf   *int            `json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// Envelope é o corpo JSON enviado ao integrador
+type Envelope struct {
+	ID        string      `json:"id"`
+	Evento    string      `json:"evento"`
+	CreatedAt time.Time   `json:"created_at"`
+	Dados     interface{} `json:"dados"`
+}
+
+// Dispatch é o job que faz uma tentativa de entrega; as novas tentativas usam o backoff da fila
+type Dispatch struct {
+	DeliveryID int `json:"delivery_id"`
+}
+
+func (Dispatch) JobType() string { return "webhook_delivery" }
+
+const deliveryColumns = `id, webhook_id, event_type, event_id, payload, status, attempts, response_status,
+	response_body, error, duration_ms, redelivery_of, created_at, last_attempt_at, delivered_at`
+
+func scanDelivery(row interface{ Scan(...interface{}) error }) (*Delivery, error) {
+	var d Delivery
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Evento, &d.EventoID, &d.Payload, &d.Status, &d.Tentativas,
+		&d.ResponseStatus, &d.ResponseBody, &d.Erro, &d.DuracaoMs, &d.RedeliveryOf, &d.CreatedAt,
+		&d.LastAttemptAt, &d.DeliveredAt)
+	return &d, err
+}
+
+// enqueue grava a entrega e o job na mesma transação. Entregas originais são únicas por
+// webhook e evento: se o barramento repetir o evento, nada é duplicado e ok é false.
+func enqueue(tx *sql.Tx, webhookID int, envelope Envelope, redeliveryOf *int) (*Delivery, bool, error) {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, false, err
+	}
+
+	d, err := scanDelivery(tx.QueryRow(`
+		INSERT INTO webhook_deliveries (webhook_id, event_type, event_id, payload, redelivery_of)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (webhook_id, event_id) WHERE redelivery_of IS NULL DO NOTHING
+		RETURNING `+deliveryColumns, webhookID, envelope.Evento, envelope.ID, payload, redeliveryOf))
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	opts := jobs.Options{MaxTentativas: MaxTentativas, UniqueKey: fmt.Sprintf("webhook_delivery:%d", d.ID)}
+	if _, err := jobs.EnqueueTx(tx, Dispatch{DeliveryID: d.ID}, opts); err != nil {
+		return nil, false, err
+	}
+	return d, true, nil
+}
+
+func enqueueOne(webhookID int, envelope Envelope, redeliveryOf *int) (*Delivery, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	d, _, err := enqueue(tx, webhookID, envelope, redeliveryOf)
+	if err != nil {
+		return nil, err
+	}
+	return d, tx.Commit()
+}
+
+// Ping enfileira uma entrega de teste para o integrador conferir a URL e a assinatura
+func Ping(wh *Webhook) (*Delivery, error) {
+	if !wh.Ativo {
+		return nil, ErrInactive
+	}
+	token, err := auth.GenerateSecureToken(12)
+	if err != nil {
+		return nil, err
+	}
+	return enqueueOne(wh.ID, Envelope{
+		ID:        EVENTO_PING + ":" + token,
+		Evento:    EVENTO_PING,
+		CreatedAt: time.Now().UTC(),
+		Dados:     map[string]interface{}{"webhook_id": wh.ID, "eventos": wh.Eventos},
+	}, nil)
+}
+
+// Redeliver cria uma nova entrega com o mesmo corpo (e o mesmo evento_id) de uma entrega anterior
+func Redeliver(wh *Webhook, deliveryID int) (*Delivery, error) {
+	if !wh.Ativo {
+		return nil, ErrInactive
+	}
+	original, err := GetDelivery(wh.ID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dados é decodificado como JSON bruto para ser reenviado exatamente como estava
+	var dados json.RawMessage
+	envelope := Envelope{Dados: &dados}
+	if err := json.Unmarshal(original.Payload, &envelope); err != nil {
+		return nil, err
+	}
+	return enqueueOne(wh.ID, envelope, &original.ID)
+}
+
+// DeliveryFilter são os filtros do histórico de entregas
+type DeliveryFilter struct {
+	Status string
+	Evento string
+}
+
+// ListDeliveries lista o histórico de entregas do webhook, mais recentes primeiro
+func ListDeliveries(webhookID int, f DeliveryFilter, page *listing.Params) ([]Delivery, bool, error) {
+	query := listing.New("SELECT "+deliveryColumns+" FROM webhook_deliveries").
+		Where("webhook_id = ?", webhookID).
+		WhereIf(f.Status != "", "status = ?", f.Status).
+		WhereIf(f.Evento != "", "event_type = ?", f.Evento)
+	page.Apply(query)
+
+	q, args := query.SQL()
+	rows, err := database.DB.Query(q, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	list := []Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		list = append(list, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := page.HasMore(len(list))
+	if hasMore {
+		list = list[:page.Limit]
+	}
+	return list, hasMore, nil
+}
+
+func GetDelivery(webhookID, id int) (*Delivery, error) {
+	d, err := scanDelivery(database.DB.QueryRow(
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2", id, webhookID))
+	if err == sql.ErrNoRows {
+		return nil, ErrDeliveryNotFound
+	}
+	return d, err
+}
+
+// deliver é o handler do job Dispatch: faz uma tentativa e registra o resultado no histórico.
+// Devolver erro faz a fila tentar de novo; na última tentativa a entrega fica como falhou.
+func deliver(ctx context.Context, job Dispatch) error {
+	d, err := scanDelivery(database.DB.QueryRowContext(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = $1", job.DeliveryID))
+	if err == sql.ErrNoRows {
+		// Webhook removido junto com o histórico
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if d.Status != ENTREGA_PENDENTE {
+		return nil
+	}
+
+	wh, err := Get(d.WebhookID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !wh.Ativo {
+		return finish(ctx, d.ID, ENTREGA_FALHOU, ErrInactive.Error())
+	}
+
+	a := post(ctx, wh, d)
+	status := ENTREGA_PENDENTE
+	switch {
+	case a.ok():
+		status = ENTREGA_ENTREGUE
+	case d.Tentativas+1 >= MaxTentativas:
+		status = ENTREGA_FALHOU
+	}
+	var errMsg *string
+	if a.err != nil {
+		msg := a.err.Error()
+		errMsg = &msg
+	}
+
+	_, err = database.DB.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET
+			status = $2, attempts = attempts + 1, response_status = $3, response_body = $4, error = $5,
+			duration_ms = $6, last_attempt_at = NOW(),
+			delivered_at = CASE WHEN $2 = 'entregue' THEN NOW() ELSE delivered_at END
+		WHERE id = $1`, d.ID, status, a.status, a.body, errMsg, a.duration.Milliseconds())
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case ENTREGA_ENTREGUE:
+		return nil
+	case ENTREGA_FALHOU:
+		return jobs.Permanent(a.err)
+	default:
+		return a.err
+	}
+}
+
+func finish(ctx context.Context, id int, status, errMsg string) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET status = $2, error = $3 WHERE id = $1", id, status, errMsg)
+	return err
+}
+
+// Purge apaga o histórico de entregas mais antigo que a retenção (tarefa do scheduler)
+func Purge(now time.Time) (int, error) {
+	result, err := database.DB.Exec(
+		"DELETE FROM webhook_deliveries WHERE created_at < $1", now.Add(-deliveriesRetention))
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
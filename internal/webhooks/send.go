@@ -0,0 +1,140 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Headers enviados em cada entrega
+const (
+	HEADER_ASSINATURA = "X-SmartPicks-Signature"
+	HEADER_EVENTO     = "X-SmartPicks-Event"
+	HEADER_EVENTO_ID  = "X-SmartPicks-Event-Id"
+	HEADER_ENTREGA    = "X-SmartPicks-Delivery"
+)
+
+const (
+	userAgent = "SmartPicks-Webhooks/1.0"
+	// maxResponseBody é quanto da resposta do integrador fica guardado no histórico
+	maxResponseBody = 1024
+)
+
+var errBlockedAddress = errors.New("endereço de rede não permitido")
+
+// cgnat é a faixa 100.64.0.0/10, que net.IP.IsPrivate não cobre
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// client não segue redirecionamentos (um 3xx conta como falha) e confere o IP de cada conexão,
+// o que cobre hosts cujo DNS aponta para a rede interna
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: guardAddress}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        20,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+func guardAddress(network, address string, c syscall.RawConn) error {
+	if Insecure() {
+		return nil
+	}
+	return PublicOnly(network, address, c)
+}
+
+// PublicOnly é o Control de net.Dialer que recusa conexões a IPs que não sejam públicos. Também
+// protege o envio de Web Push, cujo endpoint é informado pelo navegador.
+func PublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errBlockedAddress
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+// IsPublicIP indica se o IP é roteável na internet (fora de loopback, redes privadas, link-local,
+// multicast e CGNAT)
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || cgnat.Contains(ip))
+}
+
+// Sign calcula a assinatura enviada em X-SmartPicks-Signature: "t=<unix>,v1=<hex>", em que v1 é o
+// HMAC-SHA256 de "<unix>.<corpo>" com o segredo do webhook. O timestamp permite ao integrador
+// recusar reenvios antigos (replay).
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// attempt é o resultado de uma tentativa de entrega
+type attempt struct {
+	status   *int
+	body     *string
+	err      error
+	duration time.Duration
+}
+
+func (a *attempt) ok() bool {
+	return a.err == nil && a.status != nil && *a.status >= 200 && *a.status < 300
+}
+
+// post envia o corpo assinado ao webhook. Erros de rede ficam em attempt.err; respostas fora de
+// 2xx são registradas com status e corpo.
+func post(ctx context.Context, wh *Webhook, d *Delivery) *attempt {
+	started := time.Now()
+	a := &attempt{}
+	defer func() { a.duration = time.Since(started) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		a.err = err
+		return a
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HEADER_EVENTO, d.Evento)
+	req.Header.Set(HEADER_EVENTO_ID, d.EventoID)
+	req.Header.Set(HEADER_ENTREGA, strconv.Itoa(d.ID))
+	req.Header.Set(HEADER_ASSINATURA, Sign(wh.secret, time.Now(), d.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		a.err = err
+		return a
+	}
+	defer resp.Body.Close()
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// O Postgres não aceita NUL nem UTF-8 inválido em colunas de texto
+	body := strings.ReplaceAll(string(bytes.ToValidUTF8(detail, nil)), "\x00", "")
+	a.status = &resp.StatusCode
+	a.body = &body
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		a.err = fmt.Errorf("webhook respondeu %d", resp.StatusCode)
+	}
+	return a
+}
@@ -0,0 +1,138 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"smartpicks-backend/internal/compliance"
+	"smartpicks-backend/internal/database"
+	"smartpicks-backend/internal/events"
+	"smartpicks-backend/internal/jobs"
+	"smartpicks-backend/internal/models"
+	"smartpicks-backend/internal/odds"
+	"smartpicks-backend/internal/subscriptions"
+)
+
+// Register inscreve os webhooks nos eventos de palpites e registra o job de entrega
+func Register() {
+	jobs.Register(deliver)
+	events.Subscribe("webhooks", onPalpitePublished)
+	events.Subscribe("webhooks", onPalpiteSettled)
+}
+
+func onPalpitePublished(ctx context.Context, e events.PalpitePublished) error {
+	return dispatch(ctx, EVENTO_PALPITE_CRIADO, e.PalpiteID, fmt.Sprintf("%s:%d", EVENTO_PALPITE_CRIADO, e.PalpiteID))
+}
+
+// onPalpiteSettled inclui o status no id do evento: uma correção de placar que muda o resultado
+// gera uma nova entrega
+func onPalpiteSettled(ctx context.Context, e events.PalpiteSettled) error {
+	return dispatch(ctx, EVENTO_PALPITE_LIQUIDADO, e.PalpiteID,
+		fmt.Sprintf("%s:%d:%s", EVENTO_PALPITE_LIQUIDADO, e.PalpiteID, e.Status))
+}
+
+// target é um webhook interessado no evento, com o dono que define o acesso ao conteúdo
+type target struct {
+	webhookID int
+	owner     *models.User
+}
+
+// dispatch enfileira uma entrega por webhook ativo inscrito no evento cujo dono é o autor do
+// palpite ou o segue. O palpite vai com o acesso do dono: conteúdo restrito que ele não
+// alcança segue como prévia bloqueada, e a odd respeita o formato e a opção de ocultar odds.
+func dispatch(ctx context.Context, evento string, palpiteID int, eventoID string) error {
+	p, err := models.ScanPalpite(database.DB.QueryRowContext(ctx,
+		"SELECT "+models.PalpiteColumns+" FROM palpites WHERE id = $1", palpiteID))
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !p.IsPublished(time.Now()) {
+		return nil
+	}
+
+	targets, err := targetsFor(ctx, evento, p.UserID)
+	if err != nil || len(targets) == 0 {
+		return err
+	}
+
+	var autor string
+	if err := database.DB.QueryRowContext(ctx, "SELECT nome FROM users WHERE id = $1", p.UserID).Scan(&autor); err != nil {
+		return err
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for _, t := range targets {
+		settings, err := compliance.LoadSettings(t.owner.ID)
+		if err != nil {
+			return err
+		}
+		if settings.IsExcluded(now) {
+			continue
+		}
+
+		format := t.owner.FormatoOdds
+		if format == "" {
+			format = odds.FORMATO_DECIMAL
+		}
+		resp := p.ToResponse()
+		resp.RenderOdds(format, settings.OcultarOdds)
+		if !subscriptions.NewViewer(t.owner).CanView(p.UserID, p.Visibilidade) {
+			resp.Lock()
+		}
+
+		envelope := Envelope{
+			ID:        eventoID,
+			Evento:    evento,
+			CreatedAt: now,
+			Dados: map[string]interface{}{
+				"palpite": resp,
+				"autor":   map[string]interface{}{"id": p.UserID, "nome": autor},
+			},
+		}
+		if _, _, err := enqueue(tx, t.webhookID, envelope, nil); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// targetsFor lê os webhooks interessados junto com o dono; a subconsulta expõe as colunas de
+// users sem ambiguidade para UserColumns
+func targetsFor(ctx context.Context, evento string, authorID int) ([]target, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+models.UserColumns+`, webhook_id
+		FROM (
+			SELECT u.*, w.id AS webhook_id
+			FROM webhooks w
+			JOIN users u ON u.id = w.user_id
+			WHERE w.active AND $1 = ANY(w.event_types) AND u.deleted_at IS NULL
+			  AND (w.user_id = $2 OR EXISTS (
+				SELECT 1 FROM user_follows f WHERE f.follower_id = w.user_id AND f.followed_id = $2))
+		) t
+		ORDER BY webhook_id`, evento, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []target
+	for rows.Next() {
+		var t target
+		if t.owner, err = models.ScanUser(rows, &t.webhookID); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,300 @@
+// Package webhooks envia os eventos de palpites aos sistemas de parceiros (integradores). Cada
+// webhook pertence a um usuário e recebe os eventos dos próprios palpites e dos tipsters que ele
+// segue, com o mesmo acesso ao conteúdo que o usuário tem no app. As entregas são assinadas com
+// HMAC-SHA256, repetidas pela fila de jobs com backoff exponencial e ficam registradas no histórico.
+package webhooks
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"smartpicks-backend/internal/auth"
+	"smartpicks-backend/internal/database"
+
+	"github.com/lib/pq"
+)
+
+// Eventos enviados aos webhooks
+const (
+	EVENTO_PALPITE_CRIADO    = "palpite.created" // palpite publicado (imediato ou agendado)
+	EVENTO_PALPITE_LIQUIDADO = "palpite.settled"
+	EVENTO_PING              = "ping" // entrega de teste, sempre enviada
+)
+
+var ValidEvents = []string{EVENTO_PALPITE_CRIADO, EVENTO_PALPITE_LIQUIDADO}
+
+const (
+	// MaxWebhooksPorUsuario limita quantos webhooks cada usuário pode cadastrar
+	MaxWebhooksPorUsuario = 10
+	maxDescricaoLen       = 255
+	secretPrefix          = "whsec_"
+	secretBytes           = 24
+	minSecretLen          = 16
+)
+
+var (
+	ErrNotFound = errors.New("webhook não encontrado")
+	ErrLimit    = errors.New("limite de webhooks atingido")
+	ErrInactive = errors.New("webhook desativado")
+)
+
+// Webhook é a inscrição de um integrador. O segredo só é devolvido na criação e na rotação;
+// nas demais respostas aparece apenas o prefixo.
+type Webhook struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	URL          string    `json:"url"`
+	Descricao    *string   `json:"descricao,omitempty"`
+	Eventos      []string  `json:"eventos"`
+	Ativo        bool      `json:"ativo"`
+	SecretPrefix string    `json:"secret_prefix"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	secret string
+}
+
+// Input são os campos aceitos na criação e na edição; nil mantém o valor atual.
+// Secret vazio na criação gera um segredo aleatório.
+type Input struct {
+	URL       *string  `json:"url"`
+	Descricao *string  `json:"descricao"`
+	Eventos   []string `json:"eventos"`
+	Ativo     *bool    `json:"ativo"`
+	Secret    *string  `json:"secret"`
+}
+
+const webhookColumns = `id, user_id, url, description, event_types, active, secret, created_at, updated_at`
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*Webhook, error) {
+	var wh Webhook
+	err := row.Scan(&wh.ID, &wh.UserID, &wh.URL, &wh.Descricao, pq.Array(&wh.Eventos), &wh.Ativo,
+		&wh.secret, &wh.CreatedAt, &wh.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	wh.SecretPrefix = maskSecret(wh.secret)
+	return &wh, nil
+}
+
+// Insecure libera URLs http e endereços da rede local (WEBHOOKS_INSECURE=true), apenas para
+// desenvolvimento e testes
+func Insecure() bool {
+	return os.Getenv("WEBHOOKS_INSECURE") == "true"
+}
+
+// ValidateURL exige https e um host público, evitando que o servidor faça requisições à rede
+// interna em nome do usuário. A resolução DNS é conferida de novo a cada conexão (ver send.go).
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil {
+		return errors.New("url inválida")
+	}
+	if Insecure() {
+		if u.Scheme != "https" && u.Scheme != "http" {
+			return errors.New("url deve usar http ou https")
+		}
+		return nil
+	}
+	if u.Scheme != "https" {
+		return errors.New("url deve usar https")
+	}
+	if !PublicHost(u.Hostname()) {
+		return errors.New("url deve apontar para um host público")
+	}
+	return nil
+}
+
+// PublicHost recusa nomes locais e IPs literais fora da internet; nomes comuns só são conferidos
+// na conexão (PublicOnly)
+func PublicHost(host string) bool {
+	host = strings.ToLower(host)
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return false
+	}
+	return true
+}
+
+func validateEvents(list []string) error {
+	if len(list) == 0 {
+		return errors.New("informe ao menos um evento. Use " + strings.Join(ValidEvents, ", "))
+	}
+	for _, e := range list {
+		if !slices.Contains(ValidEvents, e) {
+			return errors.New("evento inválido: " + e + ". Use " + strings.Join(ValidEvents, ", "))
+		}
+	}
+	return nil
+}
+
+// Validate confere e normaliza os campos informados; creating exige url e eventos
+func (in *Input) Validate(creating bool) error {
+	if creating && (in.URL == nil || in.Eventos == nil) {
+		return errors.New("url e eventos são obrigatórios")
+	}
+	if in.URL != nil {
+		*in.URL = strings.TrimSpace(*in.URL)
+		if err := ValidateURL(*in.URL); err != nil {
+			return err
+		}
+	}
+	if in.Eventos != nil {
+		if err := validateEvents(in.Eventos); err != nil {
+			return err
+		}
+		slices.Sort(in.Eventos)
+		in.Eventos = slices.Compact(in.Eventos)
+	}
+	if in.Descricao != nil {
+		*in.Descricao = strings.TrimSpace(*in.Descricao)
+		if utf8.RuneCountInString(*in.Descricao) > maxDescricaoLen {
+			return errors.New("descricao deve ter no máximo 255 caracteres")
+		}
+	}
+	if in.Secret != nil && len(*in.Secret) < minSecretLen {
+		return errors.New("secret deve ter ao menos 16 caracteres")
+	}
+	return nil
+}
+
+// Create cadastra o webhook e devolve o segredo usado nas assinaturas
+func Create(userID int, in Input) (*Webhook, string, error) {
+	if err := in.Validate(true); err != nil {
+		return nil, "", err
+	}
+
+	var count int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM webhooks WHERE user_id = $1", userID).Scan(&count); err != nil {
+		return nil, "", err
+	}
+	if count >= MaxWebhooksPorUsuario {
+		return nil, "", ErrLimit
+	}
+
+	secret, err := secretOrNew(in.Secret)
+	if err != nil {
+		return nil, "", err
+	}
+	active := in.Ativo == nil || *in.Ativo
+
+	wh, err := scanWebhook(database.DB.QueryRow(`
+		INSERT INTO webhooks (user_id, url, description, event_types, active, secret)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+webhookColumns, userID, *in.URL, in.Descricao, pq.Array(in.Eventos), active, secret))
+	if err != nil {
+		return nil, "", err
+	}
+	return wh, secret, nil
+}
+
+func ListByUser(userID int) ([]Webhook, error) {
+	rows, err := database.DB.Query(
+		"SELECT "+webhookColumns+" FROM webhooks WHERE user_id = $1 ORDER BY created_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Webhook{}
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *wh)
+	}
+	return list, rows.Err()
+}
+
+func Get(id int) (*Webhook, error) {
+	wh, err := scanWebhook(database.DB.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return wh, err
+}
+
+// Update altera os campos informados. Reativar um webhook não reenvia as entregas que falharam
+// enquanto ele estava desativado; use o reenvio manual.
+func Update(id int, in Input) (*Webhook, error) {
+	if err := in.Validate(false); err != nil {
+		return nil, err
+	}
+
+	var eventos interface{}
+	if in.Eventos != nil {
+		eventos = pq.Array(in.Eventos)
+	}
+	wh, err := scanWebhook(database.DB.QueryRow(`
+		UPDATE webhooks SET
+			url = COALESCE($2, url),
+			description = COALESCE($3, description),
+			event_types = COALESCE($4, event_types),
+			active = COALESCE($5, active),
+			secret = COALESCE($6, secret),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+webhookColumns, id, in.URL, in.Descricao, eventos, in.Ativo, in.Secret))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return wh, err
+}
+
+// RotateSecret troca o segredo por um novo aleatório; as entregas seguintes já usam o novo
+func RotateSecret(id int) (*Webhook, string, error) {
+	secret, err := secretOrNew(nil)
+	if err != nil {
+		return nil, "", err
+	}
+	wh, err := Update(id, Input{Secret: &secret})
+	if err != nil {
+		return nil, "", err
+	}
+	return wh, secret, nil
+}
+
+// Delete remove o webhook com seu histórico de entregas; jobs pendentes terminam sem enviar
+func Delete(id int) error {
+	result, err := database.DB.Exec("DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func secretOrNew(secret *string) (string, error) {
+	if secret != nil {
+		return *secret, nil
+	}
+	token, err := auth.GenerateSecureToken(secretBytes)
+	if err != nil {
+		return "", err
+	}
+	return secretPrefix + token, nil
+}
+
+// maskSecret mostra apenas o início do segredo, para o integrador identificar qual está em uso
+func maskSecret(secret string) string {
+	n := len(secretPrefix) + 4
+	if !strings.HasPrefix(secret, secretPrefix) {
+		n = 4
+	}
+	if len(secret) <= n {
+		return "…"
+	}
+	return secret[:n] + "…"
+}
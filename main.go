@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
+	"smartpicks-backend/internal/jobs"
 	"smartpicks-backend/internal/routes"
+	"smartpicks-backend/internal/stream"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -17,13 +25,49 @@ func main() {
 		log.Println("⚠️  Arquivo .env não encontrado, usando variáveis de ambiente do sistema")
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	r := mux.NewRouter()
 	routes.RegisterRoutes(r)
 
+	// Workers da fila de jobs (JOB_WORKERS=0 desliga, ex.: quando só o cron executa os jobs)
+	var workers sync.WaitGroup
+	if n := jobWorkers(); n > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			jobs.NewPool(n).Run(ctx)
+		}()
+	}
+
+	// LISTEN/NOTIFY para o SSE: mantém as conexões de todas as instâncias sincronizadas
+	go stream.DefaultHub.Listen(ctx, os.Getenv("DATABASE_URL"))
+
 	port := getEnv("PORT", "8080")
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	srv.RegisterOnShutdown(stream.DefaultHub.Stop)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
 
 	log.Printf("Servidor rodando na porta %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	workers.Wait()
+}
+
+func jobWorkers() int {
+	n, err := strconv.Atoi(getEnv("JOB_WORKERS", "2"))
+	if err != nil || n < 0 {
+		log.Println("⚠️  JOB_WORKERS inválido, usando 2")
+		return 2
+	}
+	return n
 }
 
 func getEnv(key, defaultValue string) string {
@@ -0,0 +1,33 @@
+-- Normaliza o CPF dos usuários existentes para apenas dígitos (formato canônico)
+-- Execute após database_setup_postgres.sql. O script é idempotente.
+
+-- Aborta se a normalização gerar CPFs duplicados (ex.: "000.000.000-00" e "00000000000")
+DO $$
+DECLARE
+    duplicados TEXT;
+BEGIN
+    SELECT string_agg(cpf_normalizado || ' (ids ' || ids || ')', ', ')
+    INTO duplicados
+    FROM (
+        SELECT regexp_replace(cpf, '\D', '', 'g') AS cpf_normalizado,
+               string_agg(id::text, ',' ORDER BY id) AS ids
+        FROM users
+        GROUP BY 1
+        HAVING COUNT(*) > 1
+    ) d;
+
+    IF duplicados IS NOT NULL THEN
+        RAISE EXCEPTION 'CPFs duplicados após normalização, resolva manualmente: %', duplicados;
+    END IF;
+END $$;
+
+UPDATE users
+SET cpf = regexp_replace(cpf, '\D', '', 'g')
+WHERE cpf ~ '\D';
+
+-- Novos cadastros devem ter exatamente 11 dígitos; NOT VALID preserva linhas legadas fora do padrão
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_cpf_digits_check;
+ALTER TABLE users ADD CONSTRAINT users_cpf_digits_check CHECK (cpf ~ '^[0-9]{11}$') NOT VALID;
+
+-- Lista CPFs legados que não passam na validação de formato para revisão manual
+SELECT id, nome, email, cpf FROM users WHERE cpf !~ '^[0-9]{11}$';
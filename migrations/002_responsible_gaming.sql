@@ -0,0 +1,16 @@
+-- Configurações de jogo responsável por usuário (ocultar odds, pausa e autoexclusão)
+
+CREATE TABLE IF NOT EXISTS user_gambling_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    hide_odds BOOLEAN NOT NULL DEFAULT FALSE,
+    exclusion_type VARCHAR(20) NULL CHECK (exclusion_type IN ('pausa', 'autoexclusao')),
+    excluded_from TIMESTAMP WITH TIME ZONE NULL,
+    excluded_until TIMESTAMP WITH TIME ZONE NULL, -- NULL com exclusion_type preenchido = tempo indeterminado
+    exclusion_reason TEXT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_gambling_settings_exclusion
+    ON user_gambling_settings (exclusion_type, excluded_until)
+    WHERE exclusion_type IS NOT NULL;
@@ -0,0 +1,34 @@
+-- LGPD: registro de arquivos enviados e solicitações de exclusão de conta
+
+CREATE TABLE IF NOT EXISTS uploaded_files (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    s3_key VARCHAR(512) NOT NULL,
+    url TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    original_name VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploaded_files_user ON uploaded_files (user_id);
+
+-- Registro de auditoria das exclusões: sem FK para sobreviver à remoção do usuário
+CREATE TABLE IF NOT EXISTS account_deletion_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    email_hash CHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente' CHECK (status IN ('pendente', 'cancelada', 'concluida')),
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    requested_ip VARCHAR(64) NULL,
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NULL,
+    palpites_removed INTEGER NOT NULL DEFAULT 0,
+    files_removed INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_deletion_pending
+    ON account_deletion_requests (user_id) WHERE status = 'pendente';
+CREATE INDEX IF NOT EXISTS idx_account_deletion_due
+    ON account_deletion_requests (scheduled_for) WHERE status = 'pendente';
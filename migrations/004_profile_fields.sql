@@ -0,0 +1,12 @@
+-- Campos opcionais de perfil e troca de email com verificação
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bio TEXT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS favorite_sports TEXT[] NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS social_links JSONB NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255) NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verification_token_hash CHAR(64) NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verification_expires_at TIMESTAMP WITH TIME ZONE NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_verification_token
+    ON users (email_verification_token_hash)
+    WHERE email_verification_token_hash IS NOT NULL;
@@ -0,0 +1,25 @@
+-- Convites para conceder perfil admin e desativação das contas padrão antigas
+
+CREATE TABLE IF NOT EXISTS admin_invitations (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    perfil VARCHAR(20) NOT NULL DEFAULT 'admin' CHECK (perfil IN ('admin')),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    invited_by INTEGER NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE NULL,
+    accepted_by INTEGER NULL REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_invitations_email ON admin_invitations (LOWER(email));
+
+-- Versões anteriores do setup criavam admin@smartpicks.com e user@smartpicks.com com uma senha
+-- pública. Bloqueia o login dessas contas enquanto ainda usarem esse hash: o valor "!" nunca
+-- corresponde a um bcrypt válido e must_reset_password exige a redefinição.
+UPDATE users
+SET password = '!',
+    must_reset_password = TRUE,
+    tokens_valid_after = CURRENT_TIMESTAMP
+WHERE password = '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi';
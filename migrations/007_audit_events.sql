@@ -0,0 +1,20 @@
+-- Log de auditoria de ações relevantes para segurança
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(64) NOT NULL,
+    actor_id INTEGER NULL,        -- sem FK: o registro sobrevive à exclusão do usuário
+    impersonator_id INTEGER NULL, -- admin que agia como o ator (sessão de suporte)
+    target_type VARCHAR(32) NULL,
+    target_id INTEGER NULL,
+    ip VARCHAR(64) NULL,
+    user_agent VARCHAR(512) NULL,
+    diff JSONB NULL,
+    metadata JSONB NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created ON audit_events (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events (action, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events (target_type, target_id, created_at DESC);
@@ -0,0 +1,11 @@
+-- Índices para a paginação por cursor e a busca por prefixo nas listagens de usuários
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_users_created_id ON users (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_nome_id ON users (nome, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_email_id ON users (email, id) WHERE deleted_at IS NULL;
+
+-- ILIKE 'prefixo%' não usa índices btree; trigramas atendem a busca sem diferenciar maiúsculas
+CREATE INDEX IF NOT EXISTS idx_users_nome_trgm ON users USING gin (nome gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING gin (email gin_trgm_ops);
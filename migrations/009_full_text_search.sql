@@ -0,0 +1,21 @@
+-- Busca textual em português, sem diferenciar acentos ("sao paulo" encontra "São Paulo")
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'pt_unaccent') THEN
+        CREATE TEXT SEARCH CONFIGURATION pt_unaccent (COPY = portuguese);
+        ALTER TEXT SEARCH CONFIGURATION pt_unaccent
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, portuguese_stem;
+    END IF;
+END $$;
+
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('pt_unaccent', COALESCE(titulo, ''))) STORED;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('pt_unaccent', nome)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_palpites_search ON palpites USING gin (search_vector);
+CREATE INDEX IF NOT EXISTS idx_users_search ON users USING gin (search_vector);
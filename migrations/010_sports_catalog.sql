@@ -0,0 +1,53 @@
+-- Catálogo de esportes, competições, times e eventos; palpites passam a referenciar um evento
+
+CREATE TABLE IF NOT EXISTS sports (
+    id SERIAL PRIMARY KEY,
+    nome VARCHAR(100) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS competitions (
+    id SERIAL PRIMARY KEY,
+    sport_id INTEGER NOT NULL REFERENCES sports(id),
+    nome VARCHAR(150) NOT NULL,
+    slug VARCHAR(150) NOT NULL UNIQUE,
+    pais VARCHAR(100) NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS teams (
+    id SERIAL PRIMARY KEY,
+    sport_id INTEGER NOT NULL REFERENCES sports(id),
+    nome VARCHAR(150) NOT NULL,
+    slug VARCHAR(150) NOT NULL UNIQUE,
+    pais VARCHAR(100) NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id SERIAL PRIMARY KEY,
+    competition_id INTEGER NOT NULL REFERENCES competitions(id),
+    home_team_id INTEGER NOT NULL REFERENCES teams(id),
+    away_team_id INTEGER NOT NULL REFERENCES teams(id),
+    nome VARCHAR(310) NOT NULL,
+    kickoff_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'agendado'
+        CHECK (status IN ('agendado', 'ao_vivo', 'encerrado', 'adiado', 'cancelado')),
+    external_ref VARCHAR(100) NULL UNIQUE,
+    search_vector tsvector GENERATED ALWAYS AS (to_tsvector('pt_unaccent', nome)) STORED,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (home_team_id <> away_team_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_competitions_sport ON competitions (sport_id);
+CREATE INDEX IF NOT EXISTS idx_teams_sport ON teams (sport_id);
+CREATE INDEX IF NOT EXISTS idx_events_kickoff ON events (kickoff_at, id);
+CREATE INDEX IF NOT EXISTS idx_events_competition ON events (competition_id, kickoff_at);
+CREATE INDEX IF NOT EXISTS idx_events_teams ON events (home_team_id, away_team_id);
+CREATE INDEX IF NOT EXISTS idx_events_search ON events USING gin (search_vector);
+
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS event_id INTEGER NULL REFERENCES events(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_palpites_event ON palpites (event_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_palpites_user_created ON palpites (user_id, created_at DESC, id DESC);
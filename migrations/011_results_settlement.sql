@@ -0,0 +1,18 @@
+-- Placar final dos eventos e liquidação automática dos palpites
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS home_score INTEGER NULL CHECK (home_score >= 0);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS away_score INTEGER NULL CHECK (away_score >= 0);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS finished_at TIMESTAMP WITH TIME ZONE NULL;
+
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS mercado VARCHAR(20) NULL
+    CHECK (mercado IN ('1x2', 'over_under', 'ambas_marcam', 'handicap'));
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS selecao VARCHAR(20) NULL;
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS linha NUMERIC(6, 2) NULL;
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS odd NUMERIC(8, 3) NULL CHECK (odd > 1);
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS stake NUMERIC(8, 2) NOT NULL DEFAULT 1 CHECK (stake > 0);
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pendente'
+    CHECK (status IN ('pendente', 'green', 'red', 'meio_green', 'meio_red', 'anulado'));
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS lucro NUMERIC(10, 2) NULL;
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS settled_at TIMESTAMP WITH TIME ZONE NULL;
+
+CREATE INDEX IF NOT EXISTS idx_palpites_pending ON palpites (event_id) WHERE status = 'pendente';
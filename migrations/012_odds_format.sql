@@ -0,0 +1,4 @@
+-- Formato de odds preferido do usuário; as odds dos palpites continuam gravadas como decimais
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS odds_format VARCHAR(20) NOT NULL DEFAULT 'decimal'
+    CHECK (odds_format IN ('decimal', 'fracionaria', 'americana'));
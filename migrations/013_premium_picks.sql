@@ -0,0 +1,48 @@
+-- Palpites premium: visibilidade, seguidores, planos de assinatura dos tipsters e assinaturas
+
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'publico'
+    CHECK (visibility IN ('publico', 'seguidores', 'assinantes'));
+
+CREATE TABLE IF NOT EXISTS user_follows (
+    follower_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followed_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followed_id),
+    CHECK (follower_id <> followed_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_follows_followed ON user_follows (followed_id);
+
+CREATE TABLE IF NOT EXISTS subscription_plans (
+    id SERIAL PRIMARY KEY,
+    tipster_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    nome VARCHAR(100) NOT NULL,
+    descricao TEXT NULL,
+    preco_centavos INTEGER NOT NULL CHECK (preco_centavos > 0),
+    moeda CHAR(3) NOT NULL DEFAULT 'BRL',
+    intervalo VARCHAR(20) NOT NULL CHECK (intervalo IN ('mensal', 'trimestral', 'anual')),
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_plans_tipster ON subscription_plans (tipster_id);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id SERIAL PRIMARY KEY,
+    plan_id INTEGER NOT NULL REFERENCES subscription_plans(id),
+    subscriber_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tipster_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente'
+        CHECK (status IN ('pendente', 'ativa', 'cancelada', 'expirada')),
+    provider VARCHAR(30) NOT NULL,
+    provider_ref VARCHAR(255) NULL,
+    current_period_end TIMESTAMP WITH TIME ZONE NULL,
+    canceled_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_provider_ref ON subscriptions (provider, provider_ref);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_entitlement ON subscriptions (subscriber_id, tipster_id, current_period_end);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tipster ON subscriptions (tipster_id, created_at DESC);
@@ -0,0 +1,13 @@
+-- Rascunhos, publicação agendada e revelação automática no início do evento
+
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS is_draft BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE NULL;
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS published_at TIMESTAMP WITH TIME ZONE NULL;
+ALTER TABLE palpites ADD COLUMN IF NOT EXISTS reveal_at_kickoff BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Palpites existentes já estavam visíveis
+UPDATE palpites SET published_at = created_at WHERE published_at IS NULL AND NOT is_draft AND publish_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_palpites_scheduled ON palpites (publish_at) WHERE published_at IS NULL AND NOT is_draft;
+CREATE INDEX IF NOT EXISTS idx_palpites_reveal ON palpites (event_id) WHERE reveal_at_kickoff AND visibility <> 'publico';
+CREATE INDEX IF NOT EXISTS idx_palpites_published ON palpites (published_at DESC);
@@ -0,0 +1,33 @@
+-- Fila de jobs em segundo plano (internal/jobs)
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente' CHECK (status IN ('pendente', 'executando', 'concluido', 'morto')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5 CHECK (max_attempts > 0),
+    unique_key VARCHAR(255) NULL,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_at TIMESTAMP WITH TIME ZONE NULL,
+    locked_by VARCHAR(255) NULL,
+    last_error TEXT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+-- Dequeue: próximos pendentes por run_at e jobs abandonados em execução
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs (run_at, id) WHERE status = 'pendente';
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs (locked_at) WHERE status = 'executando';
+CREATE INDEX IF NOT EXISTS idx_jobs_status_created ON jobs (status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_completed ON jobs (completed_at) WHERE status = 'concluido';
+
+-- Um único job ativo por chave (ex.: settle_events:all)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_unique_active ON jobs (unique_key)
+    WHERE status IN ('pendente', 'executando');
+
+-- Dimensões gravadas pelo job process_image
+ALTER TABLE uploaded_files ADD COLUMN IF NOT EXISTS width INTEGER NULL;
+ALTER TABLE uploaded_files ADD COLUMN IF NOT EXISTS height INTEGER NULL;
+ALTER TABLE uploaded_files ADD COLUMN IF NOT EXISTS processed_at TIMESTAMP WITH TIME ZONE NULL;
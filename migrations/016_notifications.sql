@@ -0,0 +1,29 @@
+-- Comentários em palpites e central de notificações
+
+CREATE TABLE IF NOT EXISTS palpite_comments (
+    id SERIAL PRIMARY KEY,
+    palpite_id INTEGER NOT NULL REFERENCES palpites(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    texto TEXT NOT NULL CHECK (char_length(texto) BETWEEN 1 AND 1000),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_palpite_comments_palpite ON palpite_comments (palpite_id, created_at, id);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    actor_id INTEGER NULL REFERENCES users(id) ON DELETE SET NULL,
+    palpite_id INTEGER NULL REFERENCES palpites(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    data JSONB NULL,
+    -- Evita duplicatas quando um evento é entregue mais de uma vez
+    dedupe_key VARCHAR(255) NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, dedupe_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications (user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications (user_id) WHERE read_at IS NULL;
@@ -0,0 +1,29 @@
+-- Eventos do stream SSE (/api/stream), por usuário. O id é o id SSE usado no Last-Event-ID.
+
+CREATE TABLE IF NOT EXISTS stream_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    -- Evita repetir o evento quando a entrega do barramento é reexecutada (NULL = sem deduplicação)
+    dedupe_key VARCHAR(255) NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, dedupe_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stream_events_user ON stream_events (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_stream_events_created ON stream_events (created_at);
+
+-- Acorda as conexões do usuário em todas as instâncias (NOTIFY só é entregue após o COMMIT e
+-- payloads iguais na mesma transação são enviados uma vez)
+CREATE OR REPLACE FUNCTION notify_stream_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('smartpicks_stream', NEW.user_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stream_events_notify ON stream_events;
+CREATE TRIGGER stream_events_notify
+    AFTER INSERT ON stream_events
+    FOR EACH ROW EXECUTE FUNCTION notify_stream_event();
@@ -0,0 +1,43 @@
+-- Webhooks de integradores e histórico de entregas
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    description VARCHAR(255) NULL,
+    -- Guardado em texto: é a chave do HMAC das assinaturas e precisa ser lido a cada envio
+    secret VARCHAR(128) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user ON webhooks (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    -- Igual em todas as entregas do mesmo evento, inclusive reenvios
+    event_id VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER NULL,
+    response_body TEXT NULL,
+    error TEXT NULL,
+    duration_ms INTEGER NULL,
+    -- Entrega de origem de um reenvio manual; sem FK para que a limpeza do histórico apague
+    -- a original sem mexer nos reenvios
+    redelivery_of INTEGER NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_attempt_at TIMESTAMP WITH TIME ZONE NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+-- Uma entrega original por webhook e evento: a reexecução do barramento não duplica envios
+CREATE UNIQUE INDEX IF NOT EXISTS uq_webhook_deliveries_event
+    ON webhook_deliveries (webhook_id, event_id) WHERE redelivery_of IS NULL;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created ON webhook_deliveries (created_at);
@@ -0,0 +1,38 @@
+-- Canais do Telegram e do Discord em que os tipsters republicam seus palpites
+
+CREATE TABLE IF NOT EXISTS social_channels (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform VARCHAR(20) NOT NULL CHECK (platform IN ('telegram', 'discord')),
+    name VARCHAR(100) NOT NULL,
+    -- chat_id do Telegram ou id do webhook do Discord
+    target VARCHAR(100) NOT NULL,
+    -- Token do webhook do Discord (o do Telegram é o do bot da plataforma, TELEGRAM_BOT_TOKEN)
+    token VARCHAR(255) NULL,
+    -- NULL usa o template padrão da plataforma
+    template TEXT NULL,
+    with_image BOOLEAN NOT NULL DEFAULT TRUE,
+    include_restricted BOOLEAN NOT NULL DEFAULT FALSE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_posted_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_social_channels_user ON social_channels (user_id);
+
+-- Uma publicação por canal e palpite: a reexecução do evento não repete a mensagem
+CREATE TABLE IF NOT EXISTS social_posts (
+    id SERIAL PRIMARY KEY,
+    channel_id INTEGER NOT NULL REFERENCES social_channels(id) ON DELETE CASCADE,
+    palpite_id INTEGER NOT NULL REFERENCES palpites(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente',
+    message_id VARCHAR(50) NULL,
+    error TEXT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    posted_at TIMESTAMP WITH TIME ZONE NULL,
+    UNIQUE (channel_id, palpite_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_social_posts_channel ON social_posts (channel_id, created_at DESC);
@@ -0,0 +1,30 @@
+-- Verificação em duas etapas (TOTP), códigos de recuperação e desafios do login em dois passos
+
+ALTER TABLE users
+    -- Segredo TOTP em base32; fica preenchido e inativo entre a configuração e a ativação
+    ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64) NULL,
+    ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP WITH TIME ZONE NULL,
+    -- Último período de 30s aceito: impede reutilizar um código já usado
+    ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NULL;
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- SHA-256 do código sem o hífen
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user ON user_recovery_codes (user_id, code_hash);
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_challenges_expires ON login_challenges (expires_at);
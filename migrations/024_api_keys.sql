@@ -0,0 +1,30 @@
+-- Chaves de API com escopos para acesso programático e contagem de uso por minuto
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    -- Início da chave (spk_xxxxxxxx), exibido para identificá-la
+    prefix VARCHAR(20) NOT NULL,
+    -- SHA-256 da chave completa; a chave em si só aparece na criação
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE NULL,
+    last_used_ip VARCHAR(45) NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys (user_id) WHERE revoked_at IS NULL;
+
+-- Requisições por chave, escopo e janela de um minuto (limite de uso das chaves)
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    api_key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    scope VARCHAR(50) NOT NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, scope, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_window ON api_key_usage (window_start);
@@ -0,0 +1,12 @@
+-- Verificação de posse dos chats do Telegram antes da publicação
+
+ALTER TABLE social_channels ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP;
+ALTER TABLE social_channels ADD COLUMN IF NOT EXISTS verification_code_hash VARCHAR(64);
+ALTER TABLE social_channels ADD COLUMN IF NOT EXISTS verification_expires_at TIMESTAMP;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_social_channels_verification
+    ON social_channels(verification_code_hash) WHERE verification_code_hash IS NOT NULL;
+
+-- Webhooks do Discord já comprovam o controle do canal; canais do Telegram existentes precisam
+-- ser verificados (POST /api/me/social-channels/{id}/verification)
+UPDATE social_channels SET verified_at = created_at WHERE platform = 'discord' AND verified_at IS NULL;
@@ -0,0 +1,12 @@
+-- Eventos de webhook já aplicados, para descartar reenvios do provedor de pagamento
+
+CREATE TABLE IF NOT EXISTS payment_events (
+    provider VARCHAR(30) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_events_subscription ON payment_events (subscription_id);
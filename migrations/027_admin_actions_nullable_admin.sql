@@ -0,0 +1,7 @@
+-- O histórico de moderação sobrevive à exclusão definitiva do admin que agiu: admin_id passa a
+-- aceitar NULL e é limpo quando a conta do admin é removida
+
+ALTER TABLE admin_actions ALTER COLUMN admin_id DROP NOT NULL;
+ALTER TABLE admin_actions DROP CONSTRAINT IF EXISTS admin_actions_admin_id_fkey;
+ALTER TABLE admin_actions ADD CONSTRAINT admin_actions_admin_id_fkey
+    FOREIGN KEY (admin_id) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,7 @@
+-- Convites sobrevivem à exclusão definitiva do admin que convidou: invited_by passa a aceitar
+-- NULL e é limpo quando a conta do admin é removida
+
+ALTER TABLE admin_invitations ALTER COLUMN invited_by DROP NOT NULL;
+ALTER TABLE admin_invitations DROP CONSTRAINT IF EXISTS admin_invitations_invited_by_fkey;
+ALTER TABLE admin_invitations ADD CONSTRAINT admin_invitations_invited_by_fkey
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+-- Bloqueio temporário da verificação em duas etapas após muitos códigos incorretos, somando os
+-- erros de todos os desafios do usuário
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS totp_locked_until TIMESTAMP WITH TIME ZONE NULL;